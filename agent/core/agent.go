@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/KamdynS/go-agents/llm"
 	"github.com/KamdynS/go-agents/tools"
@@ -30,8 +32,43 @@ type AgentConfig struct {
 	SystemPrompt  string
 	// Optional: model override for a request (used with router clients)
 	ModelOverride string
+	// OnMaxIterations selects what happens when the tool loop runs out of iterations
+	OnMaxIterations ExhaustionPolicy
 }
 
+// ExhaustionPolicy controls ChatAgent behavior when MaxIterations is reached
+// while the model is still requesting tool calls.
+type ExhaustionPolicy string
+
+const (
+	// ExhaustReturnLast returns whatever content came with the last model response (default)
+	ExhaustReturnLast ExhaustionPolicy = ""
+	// ExhaustForceFinal makes one more model call with ToolChoice "none" to force an answer
+	ExhaustForceFinal ExhaustionPolicy = "force_final"
+	// ExhaustError returns a *MaxIterationsError carrying the transcript so far
+	ExhaustError ExhaustionPolicy = "error"
+	// ExhaustFallback hands the original input to the configured fallback agent
+	ExhaustFallback ExhaustionPolicy = "fallback"
+)
+
+// ErrMaxIterations is matched (via errors.Is) by every *MaxIterationsError
+var ErrMaxIterations = errors.New("max iterations exhausted")
+
+// MaxIterationsError is returned when the tool loop is exhausted under ExhaustError,
+// or under ExhaustFallback when no fallback agent is configured.
+type MaxIterationsError struct {
+	Iterations int
+	// Transcript holds the messages exchanged with the model, including tool results
+	Transcript []llm.Message
+}
+
+func (e *MaxIterationsError) Error() string {
+	return fmt.Sprintf("%v after %d iterations", ErrMaxIterations, e.Iterations)
+}
+
+// Is reports whether target is ErrMaxIterations
+func (e *MaxIterationsError) Is(target error) bool { return target == ErrMaxIterations }
+
 // Middleware allows hooks around key lifecycle events
 type Middleware interface {
 	BeforeLLMCall(ctx context.Context, req *llm.ChatRequest) error
@@ -41,6 +78,32 @@ type Middleware interface {
 	AfterRun(ctx context.Context, final Message) error
 }
 
+// ShortCircuitMiddleware is a Middleware that can answer an LLM call itself.
+// When InterceptLLMCall returns a non-nil response the model is not called and
+// the response is treated as the model's (AfterLLMResponse hooks still run).
+// Useful for caches and canned answers.
+type ShortCircuitMiddleware interface {
+	Middleware
+	InterceptLLMCall(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error)
+}
+
+// ToolRewriteMiddleware is a Middleware that can rewrite tool inputs before
+// execution and tool results before they reach the model and AfterToolExecute,
+// e.g. to redact or detokenize sensitive values. An error aborts the run.
+type ToolRewriteMiddleware interface {
+	Middleware
+	RewriteToolInput(ctx context.Context, toolName, input string) (string, error)
+	RewriteToolResult(ctx context.Context, toolName, result string) (string, error)
+}
+
+// InputRewriteMiddleware is a Middleware that sees the user input before it
+// is stored in memory, so the stored copy can be redacted too. An error
+// aborts the run before anything is stored.
+type InputRewriteMiddleware interface {
+	Middleware
+	RewriteInput(ctx context.Context, input Message) (Message, error)
+}
+
 // MemoryProcessor can transform/prune conversation history before sending to LLM
 type MemoryProcessor interface {
 	Process(ctx context.Context, history []Message) []Message
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+func echoCall(id string) []llm.ToolCall {
+	return []llm.ToolCall{{ID: id, Type: "function", Function: llm.Function{Name: "echo", Arguments: `{"input":"x"}`}}}
+}
+
+func newExhaustingAgent(mock *MockLLMClient, policy ExhaustionPolicy, fallback Agent) *ChatAgent {
+	reg := tools.NewRegistry()
+	_ = reg.Register(&EchoTool{})
+	return NewChatAgent(ChatConfig{
+		Model:    mock,
+		Tools:    reg,
+		Fallback: fallback,
+		Config:   AgentConfig{SystemPrompt: "sys", MaxIterations: 1, OnMaxIterations: policy},
+	})
+}
+
+func TestExhaustion_DefaultReturnsLastContent(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("partial", echoCall("1"))
+	agent := newExhaustingAgent(mock, ExhaustReturnLast, nil)
+	out, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("run err: %v", err)
+	}
+	if out.Content != "partial" {
+		t.Fatalf("unexpected content: %q", out.Content)
+	}
+}
+
+func TestExhaustion_ForceFinal(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	mock.AddResponse("forced answer")
+	agent := newExhaustingAgent(mock, ExhaustForceFinal, nil)
+	out, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("run err: %v", err)
+	}
+	if out.Content != "forced answer" {
+		t.Fatalf("unexpected content: %q", out.Content)
+	}
+	calls := mock.GetCalls()
+	if len(calls) != 2 || calls[1].ToolChoice != "none" {
+		t.Fatalf("expected a final call with tool_choice none, got %+v", calls)
+	}
+}
+
+func TestExhaustion_Error(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	agent := newExhaustingAgent(mock, ExhaustError, nil)
+	_, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"})
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+	var mErr *MaxIterationsError
+	if !errors.As(err, &mErr) {
+		t.Fatalf("expected *MaxIterationsError, got %T", err)
+	}
+	last := mErr.Transcript[len(mErr.Transcript)-1]
+	if last.Role != "tool" || last.Content != "ECHO:x" {
+		t.Fatalf("transcript should end with tool result, got %+v", last)
+	}
+}
+
+func TestExhaustion_Fallback(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	fbModel := NewMockLLMClient()
+	fbModel.AddResponse("from fallback")
+	fallback := NewChatAgent(ChatConfig{Model: fbModel, Config: AgentConfig{SystemPrompt: "fb"}})
+	agent := newExhaustingAgent(mock, ExhaustFallback, fallback)
+	out, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("run err: %v", err)
+	}
+	if out.Content != "from fallback" {
+		t.Fatalf("unexpected content: %q", out.Content)
+	}
+
+	// Without a fallback agent the typed error is returned
+	mock = NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	agent = newExhaustingAgent(mock, ExhaustFallback, nil)
+	if _, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"}); !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+}
+
+func TestExhaustion_StreamForceFinal(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	mock.AddResponse("streamed final")
+	agent := newExhaustingAgent(mock, ExhaustForceFinal, nil)
+	out := make(chan Message, 8)
+	if err := agent.RunStream(context.Background(), Message{Role: "user", Content: "hi"}, out); err != nil {
+		t.Fatalf("stream err: %v", err)
+	}
+	var last Message
+	for m := range out {
+		last = m
+	}
+	if last.Content != "streamed final" {
+		t.Fatalf("unexpected final: %q", last.Content)
+	}
+	if calls := mock.GetCalls(); len(calls) != 2 || calls[1].ToolChoice != "none" {
+		t.Fatalf("expected forced final call, got %d calls", len(calls))
+	}
+}
+
+func TestExhaustion_StreamError(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("", echoCall("1"))
+	agent := newExhaustingAgent(mock, ExhaustError, nil)
+	out := make(chan Message, 8)
+	err := agent.RunStream(context.Background(), Message{Role: "user", Content: "hi"}, out)
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+}
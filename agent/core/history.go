@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// conversationKey holds the flat []Message history for plain memory.Store backends
+const conversationKey = "conversation"
+
+// ErrNoBranching is returned by Regenerate when Mem is not a memory.BranchingStore
+var ErrNoBranching = errors.New("memory store does not support branching")
+
+type skipInputKey struct{}
+
+// WithInputStored marks the input of the next run as already in the agent's
+// memory, so it is answered without being appended again (e.g. by the target
+// of a handoff, which received the conversation including that input)
+func WithInputStored(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipInputKey{}, true)
+}
+
+// rememberInput stores the user input unless it is already on the branch (regenerate)
+func (a *ChatAgent) rememberInput(ctx context.Context, input Message) error {
+	if skip, _ := ctx.Value(skipInputKey{}).(bool); skip {
+		return nil
+	}
+	return a.remember(ctx, input)
+}
+
+// remember appends msg to the conversation. Branching stores receive it on the
+// active branch of the session from SessionIDFromContext. Appends are atomic
+// (memory.MessageAppender, memory.Update), so concurrent runs on one
+// conversation do not drop each other's messages.
+func (a *ChatAgent) remember(ctx context.Context, msg Message) error {
+	if a.Mem == nil {
+		return nil
+	}
+	if bs, ok := a.Mem.(memory.BranchingStore); ok {
+		session := SessionIDFromContext(ctx)
+		m := memory.Message{Role: msg.Role, Content: msg.Content, Meta: msg.Meta}
+		if ap, ok := a.Mem.(memory.MessageAppender); ok {
+			_, err := ap.Append(ctx, session, m)
+			return err
+		}
+		head, err := bs.Head(ctx, session)
+		if err != nil {
+			return err
+		}
+		_, err = bs.AddMessage(ctx, session, head, m)
+		return err
+	}
+	err := memory.Update(ctx, a.Mem, conversationKey, func(msgs []Message, _ bool) ([]Message, error) {
+		// Clip so the append never writes into the stored slice's spare capacity
+		return append(msgs[:len(msgs):len(msgs)], msg), nil
+	})
+	if err != nil {
+		if legacy, lerr := memory.Get[Message](ctx, a.Mem, conversationKey); lerr == nil && legacy.Content != "" {
+			return memory.Put(ctx, a.Mem, conversationKey, []Message{legacy, msg})
+		}
+	}
+	return err
+}
+
+// storedHistory reads the flat history through memory.Get, so backends that
+// return generic JSON values (Redis) keep the conversation across turns
+func (a *ChatAgent) storedHistory(ctx context.Context) ([]Message, error) {
+	msgs, err := memory.Get[[]Message](ctx, a.Mem, conversationKey)
+	if err == nil || errors.Is(err, memory.ErrNotFound) {
+		return msgs, nil
+	}
+	if msg, lerr := memory.Get[Message](ctx, a.Mem, conversationKey); lerr == nil && msg.Content != "" { // legacy single message
+		return []Message{msg}, nil
+	}
+	return nil, err
+}
+
+// history returns the conversation so far, or nothing when memory fails
+func (a *ChatAgent) history(ctx context.Context) []Message {
+	msgs, _ := a.History(ctx)
+	return msgs
+}
+
+// History returns the conversation so far: the active branch of the session
+// from SessionIDFromContext for branching stores, the flat history otherwise
+func (a *ChatAgent) History(ctx context.Context) ([]Message, error) {
+	if a.Mem == nil {
+		return nil, nil
+	}
+	if bs, ok := a.Mem.(memory.BranchingStore); ok {
+		msgs, err := bs.GetMessages(ctx, SessionIDFromContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		out := make([]Message, len(msgs))
+		for i, m := range msgs {
+			out[i] = Message{Role: m.Role, Content: m.Content, Meta: m.Meta}
+		}
+		return out, nil
+	}
+	return a.storedHistory(ctx)
+}
+
+// AppendHistory adds messages to the conversation in order, the same way a
+// run records its turns
+func (a *ChatAgent) AppendHistory(ctx context.Context, msgs ...Message) error {
+	for _, m := range msgs {
+		if err := a.remember(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Regenerate answers the last user message of the active branch again. The
+// previous answer is kept as a sibling branch, so it can be restored with
+// SwitchBranch. Requires Mem to be a memory.BranchingStore; the session comes
+// from SessionIDFromContext.
+func (a *ChatAgent) Regenerate(ctx context.Context) (Message, error) {
+	bs, ok := a.Mem.(memory.BranchingStore)
+	if !ok {
+		return Message{}, ErrNoBranching
+	}
+	session := SessionIDFromContext(ctx)
+	head, err := bs.Head(ctx, session)
+	if err != nil {
+		return Message{}, err
+	}
+	path, err := bs.Path(ctx, session, head)
+	if err != nil {
+		return Message{}, err
+	}
+	// Walk back to the user message that produced the last assistant turn
+	i := len(path) - 1
+	for i >= 0 && path[i].Role != "user" {
+		i--
+	}
+	if i < 0 {
+		return Message{}, fmt.Errorf("no user message to regenerate from")
+	}
+	user := path[i]
+	if err := bs.Fork(ctx, session, user.ID); err != nil {
+		return Message{}, err
+	}
+	input := Message{Role: user.Role, Content: user.Content, Meta: user.Meta}
+	return a.run(WithInputStored(ctx), input, nil)
+}
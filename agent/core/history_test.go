@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+func TestChatAgent_RegenerateKeepsPreviousAnswer(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponse("first answer")
+	mock.AddResponse("second answer")
+	store := inmemory.NewConversationStore()
+	agent := NewChatAgent(ChatConfig{Model: mock, Mem: store})
+	ctx := WithSessionID(context.Background(), "s1")
+
+	if _, err := agent.Run(ctx, Message{Role: "user", Content: "question"}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := agent.Regenerate(ctx)
+	if err != nil || out.Content != "second answer" {
+		t.Fatalf("unexpected regenerate: %v %q", err, out.Content)
+	}
+
+	msgs, _ := store.GetMessages(ctx, "s1")
+	if len(msgs) != 2 || msgs[0].Content != "question" || msgs[1].Content != "second answer" {
+		t.Fatalf("active branch should hold the question once and the new answer: %+v", msgs)
+	}
+	branches, _ := store.Branches(ctx, "s1")
+	if len(branches) != 2 || branches[0].Active || !branches[1].Active {
+		t.Fatalf("expected old and new answers as branches: %+v", branches)
+	}
+
+	// The old answer is still reachable
+	if err := store.SwitchBranch(ctx, "s1", branches[0].LeafID); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = store.GetMessages(ctx, "s1")
+	if msgs[1].Content != "first answer" {
+		t.Fatalf("expected original branch, got %+v", msgs)
+	}
+
+	// Sessions are isolated
+	if other, _ := store.GetMessages(ctx, "s2"); len(other) != 0 {
+		t.Fatalf("unexpected messages in other session: %+v", other)
+	}
+}
+
+func TestChatAgent_RegenerateRequiresBranchingStore(t *testing.T) {
+	agent := NewChatAgent(ChatConfig{Model: NewMockLLMClient(), Mem: inmemory.NewStore()})
+	if _, err := agent.Regenerate(context.Background()); !errors.Is(err, ErrNoBranching) {
+		t.Fatalf("expected ErrNoBranching, got %v", err)
+	}
+}
+
+// genericJSONStore returns values as generic JSON like the Redis backend does
+type genericJSONStore struct{ backend }
+
+type backend = memory.Store
+
+func (s genericJSONStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	v, err := s.backend.Retrieve(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := json.Marshal(v)
+	var out interface{}
+	return out, json.Unmarshal(b, &out)
+}
+
+func TestChatAgent_HistorySurvivesGenericJSONStore(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponse("one")
+	mock.AddResponse("two")
+	store := genericJSONStore{inmemory.NewStore()}
+	agent := NewChatAgent(ChatConfig{Model: mock, Mem: store})
+	ctx := context.Background()
+
+	if _, err := agent.Run(ctx, Message{Role: "user", Content: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := agent.Run(ctx, Message{Role: "user", Content: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	// The second call must see the first turn
+	found := false
+	for _, m := range mock.calls[1].Messages {
+		if m.Content == "one" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("history was reset between turns: %+v", mock.calls[1].Messages)
+	}
+	msgs, err := memory.Get[[]Message](ctx, store, "conversation")
+	if err != nil || len(msgs) < 4 {
+		t.Fatalf("expected both turns stored, got %+v (%v)", msgs, err)
+	}
+}
+
+// echoModel answers every request with the last user message and is safe for
+// concurrent use
+type echoModel struct{ *MockLLMClient }
+
+func (echoModel) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	last := req.Messages[len(req.Messages)-1]
+	return &llm.Response{Role: "assistant", Content: "re: " + last.Content}, nil
+}
+
+// slowStore and slowTree widen the window between reading the history and
+// writing it back, where unsynchronized appends would overwrite each other
+type slowStore struct{ *flatStore }
+
+type flatStore = inmemory.Store
+
+func (s slowStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	time.Sleep(time.Millisecond)
+	return s.flatStore.Retrieve(ctx, key)
+}
+
+func (s slowStore) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	time.Sleep(time.Millisecond)
+	return s.flatStore.RetrieveVersion(ctx, key, out)
+}
+
+type slowTree struct{ *inmemory.ConversationStore }
+
+func (s slowTree) Head(ctx context.Context, sessionID string) (string, error) {
+	time.Sleep(time.Millisecond)
+	return s.ConversationStore.Head(ctx, sessionID)
+}
+
+func TestChatAgent_ConcurrentRunsKeepAllMessages(t *testing.T) {
+	const runs = 16
+	for name, store := range map[string]memory.Store{
+		"flat":      slowStore{inmemory.NewStore()},
+		"generic":   genericJSONStore{slowStore{inmemory.NewStore()}},
+		"branching": slowTree{inmemory.NewConversationStore()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			agent := NewChatAgent(ChatConfig{Model: echoModel{NewMockLLMClient()}, Mem: store})
+			ctx := WithSessionID(context.Background(), "busy")
+			var wg sync.WaitGroup
+			for i := 0; i < runs; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if _, err := agent.Run(ctx, Message{Role: "user", Content: fmt.Sprintf("q%d", i)}); err != nil {
+						t.Errorf("run: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			seen := map[string]bool{}
+			for _, m := range agent.history(ctx) {
+				seen[m.Content] = true
+			}
+			for i := 0; i < runs; i++ {
+				q := fmt.Sprintf("q%d", i)
+				if !seen[q] || !seen["re: "+q] {
+					t.Fatalf("lost messages of run %d: have %d messages", i, len(seen))
+				}
+			}
+		})
+	}
+}
@@ -90,3 +90,48 @@ func TestMiddleware_Error_Propagates(t *testing.T) {
 		t.Fatalf("expected error from middleware")
 	}
 }
+
+// cannedMW answers every LLM call without reaching the model
+type cannedMW struct {
+	countingMW
+	reply string
+}
+
+func (m *cannedMW) InterceptLLMCall(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	return &llm.Response{Role: "assistant", Content: m.reply}, nil
+}
+
+func TestShortCircuitMiddleware_SkipsModel(t *testing.T) {
+	mw := &cannedMW{reply: "canned"}
+	mock := NewMockLLMClient()
+	agent := NewChatAgent(ChatConfig{
+		Model:      mock,
+		Config:     AgentConfig{SystemPrompt: "sys"},
+		Middleware: []Middleware{mw},
+	})
+	out, err := agent.Run(context.Background(), Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("run err: %v", err)
+	}
+	if out.Content != "canned" {
+		t.Fatalf("expected canned reply, got %q", out.Content)
+	}
+	if len(mock.calls) != 0 {
+		t.Fatalf("model should not be called, got %d calls", len(mock.calls))
+	}
+	if mw.afterLLM == 0 {
+		t.Fatalf("AfterLLMResponse should still run")
+	}
+
+	ch := make(chan Message, 8)
+	if err := agent.RunStream(context.Background(), Message{Role: "user", Content: "hi"}, ch); err != nil {
+		t.Fatalf("stream err: %v", err)
+	}
+	var last Message
+	for m := range ch {
+		last = m
+	}
+	if last.Content != "canned" || len(mock.calls) != 0 {
+		t.Fatalf("stream should be short-circuited: %q, %d calls", last.Content, len(mock.calls))
+	}
+}
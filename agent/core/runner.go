@@ -22,6 +22,7 @@ type ChatAgent struct {
 	processors []MemoryProcessor
 	mw         []Middleware
 	resolver   ConfigResolver
+	fallback   Agent
 }
 
 // NewChatAgent creates a new ChatAgent with the given configuration
@@ -34,6 +35,7 @@ func NewChatAgent(config ChatConfig) *ChatAgent {
 		processors: config.Processors,
 		mw:         config.Middleware,
 		resolver:   config.Resolver,
+		fallback:   config.Fallback,
 	}
 }
 
@@ -47,10 +49,17 @@ type ChatConfig struct {
 	Processors []MemoryProcessor
 	Middleware []Middleware
 	Resolver   ConfigResolver
+	// Fallback receives the original input when OnMaxIterations is ExhaustFallback
+	Fallback Agent
 }
 
 // Run implements the Agent interface
 func (a *ChatAgent) Run(ctx context.Context, input Message) (Message, error) {
+	return a.run(ctx, input, nil)
+}
+
+// run executes the tool loop, recording steps into rec when it is non-nil
+func (a *ChatAgent) run(ctx context.Context, input Message, rec *RunResult) (Message, error) {
 	// Agent-level span
 	span, ctx := obs.TracerImpl.StartSpan(ctx, "agent.run")
 	defer span.End()
@@ -79,36 +88,18 @@ func (a *ChatAgent) Run(ctx context.Context, input Message) (Message, error) {
 		}
 	}
 
+	input, err := a.rewriteInput(ctx, input)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return Message{}, err
+	}
 	// Store input message in memory
-	if a.Mem != nil {
-		if existing, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-			if msgs, ok := existing.([]Message); ok {
-				msgs = append(msgs, input)
-				if err := a.Mem.Store(ctx, "conversation", msgs); err != nil {
-					return Message{}, fmt.Errorf("failed to store message: %w", err)
-				}
-			} else {
-				// initialize as slice with prior value if it was a single message
-				_ = a.Mem.Store(ctx, "conversation", []Message{input})
-			}
-		} else {
-			if err := a.Mem.Store(ctx, "conversation", []Message{input}); err != nil {
-				return Message{}, fmt.Errorf("failed to store message: %w", err)
-			}
-		}
+	if err := a.rememberInput(ctx, input); err != nil {
+		return Message{}, fmt.Errorf("failed to store message: %w", err)
 	}
 
 	// Get conversation history
-	var history []Message
-	if a.Mem != nil {
-		if h, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-			if msgs, ok := h.([]Message); ok {
-				history = msgs
-			} else if msg, ok := h.(Message); ok { // legacy single message
-				history = []Message{msg}
-			}
-		}
-	}
+	history := a.history(ctx)
 
 	// Prepare messages for LLM
 	messages := []llm.Message{{Role: "system", Content: effectiveConfig.SystemPrompt}}
@@ -151,6 +142,7 @@ func (a *ChatAgent) Run(ctx context.Context, input Message) (Message, error) {
 	}
 
 	var finalResp *llm.Response
+	exhausted := false
 	for iter := 0; iter < maxIterations; iter++ {
 		req := &llm.ChatRequest{
 			Messages:     messages,
@@ -159,116 +151,22 @@ func (a *ChatAgent) Run(ctx context.Context, input Message) (Message, error) {
 			SystemPrompt: "",  // already injected as first message
 		}
 
-		// Middleware: before LLM
-		for _, m := range a.mw {
-			if err := m.BeforeLLMCall(ctx, req); err != nil {
-				span.SetStatus(obs.StatusCodeError, err.Error())
-				return Message{}, err
-			}
-		}
-
-		response, err := a.Model.Chat(ctx, req)
+		response, err := a.callModel(ctx, span, req, rec)
 		if err != nil {
-			span.SetStatus(obs.StatusCodeError, err.Error())
-			return Message{}, fmt.Errorf("LLM call failed: %w", err)
+			return Message{}, err
 		}
 		finalResp = response
 
-		// Middleware: after LLM
-		for _, m := range a.mw {
-			if err := m.AfterLLMResponse(ctx, response); err != nil {
-				span.SetStatus(obs.StatusCodeError, err.Error())
-				return Message{}, err
-			}
-		}
-
-		// Trace attributes and token metrics
-		span.SetAttribute(obs.AttrProvider, response.Provider)
-		span.SetAttribute(obs.AttrModel, response.Model)
-		if response.FinishReason != "" {
-			span.SetAttribute(obs.AttrFinishReason, response.FinishReason)
-		}
-		if response.Usage != nil {
-			obs.MetricsImpl.IncrementTokensUsed(response.Usage.InputTokens, map[string]string{"direction": "input", "model": response.Model})
-			obs.MetricsImpl.IncrementTokensUsed(response.Usage.OutputTokens, map[string]string{"direction": "output", "model": response.Model})
-			span.SetAttribute(obs.AttrTokensInput, response.Usage.InputTokens)
-			span.SetAttribute(obs.AttrTokensOutput, response.Usage.OutputTokens)
-		}
-
 		// If tool calls are requested, execute them and continue loop
 		if len(response.ToolCalls) > 0 && effectiveTools != nil {
 			// Append assistant message that triggered tool call to conversation
 			messages = append(messages, llm.Message{Role: "assistant", Content: response.Content})
-
-			for _, tc := range response.ToolCalls {
-				// Resolve tool
-				toolName := tc.Function.Name
-				tool, ok := effectiveTools.Get(toolName)
-				if !ok {
-					span.AddEvent("tool.not_found", map[string]interface{}{"tool": toolName})
-					continue
-				}
-
-				// Parse arguments; support {"input":"..."} or raw string
-				inputStr := tc.Function.Arguments
-				var argObj map[string]interface{}
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &argObj); err == nil {
-					if v, ok := argObj["input"].(string); ok {
-						inputStr = v
-					}
-				}
-
-				// Basic schema validation for required fields if provided
-				if schema := tool.Schema(); schema != nil {
-					if reqFields, ok := schema["required"].([]string); ok {
-						// Convert argObj if JSON parsed ok, otherwise create object with only input
-						args := argObj
-						if args == nil {
-							args = map[string]interface{}{"input": inputStr}
-						}
-						missing := make([]string, 0)
-						for _, f := range reqFields {
-							if _, ok := args[f]; !ok {
-								missing = append(missing, f)
-							}
-						}
-						if len(missing) > 0 {
-							// Surface validation failure to model
-							messages = append(messages, llm.Message{Role: "tool", Content: fmt.Sprintf("error: missing required fields %v", missing), ToolCallID: tc.ID})
-							continue
-						}
-					}
-				}
-
-				// Middleware: before tool
-				for _, m := range a.mw {
-					if err := m.BeforeToolExecute(ctx, toolName, inputStr); err != nil {
-						span.SetStatus(obs.StatusCodeError, err.Error())
-						return Message{}, err
-					}
-				}
-
-				// Execute tool via registry (already instrumented)
-				result, err := effectiveTools.Execute(ctx, tool.Name(), inputStr)
-				if err != nil {
-					// Provide error back to model as tool content
-					result = fmt.Sprintf("error: %v", err)
-				}
-
-				// Middleware: after tool
-				for _, m := range a.mw {
-					_ = m.AfterToolExecute(ctx, toolName, result, err)
-				}
-
-				// Append tool result message
-				messages = append(messages, llm.Message{
-					Role:       "tool",
-					Content:    result,
-					ToolCallID: tc.ID,
-				})
+			messages, err = a.executeToolCalls(ctx, span, effectiveTools, response.ToolCalls, messages, rec)
+			if err != nil {
+				return Message{}, err
 			}
-
 			// Continue to next iteration for model to observe tool outputs
+			exhausted = iter == maxIterations-1
 			continue
 		}
 
@@ -282,32 +180,50 @@ func (a *ChatAgent) Run(ctx context.Context, input Message) (Message, error) {
 		return Message{}, fmt.Errorf("no response from model")
 	}
 
+	finalContent := finalResp.Content
+	if exhausted {
+		span.AddEvent("agent.max_iterations", map[string]interface{}{
+			"iterations": maxIterations,
+			"policy":     string(effectiveConfig.OnMaxIterations),
+		})
+		switch effectiveConfig.OnMaxIterations {
+		case ExhaustForceFinal:
+			req := &llm.ChatRequest{Messages: messages, Tools: toolDefs, ToolChoice: "none"}
+			response, err := a.callModel(ctx, span, req, rec)
+			if err != nil {
+				return Message{}, err
+			}
+			finalContent = response.Content
+		case ExhaustError:
+			err := &MaxIterationsError{Iterations: maxIterations, Transcript: messages}
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return Message{}, err
+		case ExhaustFallback:
+			if a.fallback == nil {
+				err := &MaxIterationsError{Iterations: maxIterations, Transcript: messages}
+				span.SetStatus(obs.StatusCodeError, err.Error())
+				return Message{}, err
+			}
+			fbStart := time.Now()
+			out, err := a.fallback.Run(ctx, input)
+			rec.addFallback(fbStart, out, err)
+			if err != nil {
+				span.SetStatus(obs.StatusCodeError, err.Error())
+				return Message{}, fmt.Errorf("fallback agent failed: %w", err)
+			}
+			finalContent = out.Content
+		}
+	}
+
 	result := Message{
 		Role:    "assistant",
-		Content: finalResp.Content,
+		Content: finalContent,
 	}
 
 	// Store response in memory
-	if a.Mem != nil {
-		if existing, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-			if msgs, ok := existing.([]Message); ok {
-				msgs = append(msgs, result)
-				if err := a.Mem.Store(ctx, "conversation", msgs); err != nil {
-					span.SetStatus(obs.StatusCodeError, err.Error())
-					return Message{}, fmt.Errorf("failed to store response: %w", err)
-				}
-			} else {
-				if err := a.Mem.Store(ctx, "conversation", []Message{result}); err != nil {
-					span.SetStatus(obs.StatusCodeError, err.Error())
-					return Message{}, fmt.Errorf("failed to store response: %w", err)
-				}
-			}
-		} else {
-			if err := a.Mem.Store(ctx, "conversation", []Message{result}); err != nil {
-				span.SetStatus(obs.StatusCodeError, err.Error())
-				return Message{}, fmt.Errorf("failed to store response: %w", err)
-			}
-		}
+	if err := a.remember(ctx, result); err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return Message{}, fmt.Errorf("failed to store response: %w", err)
 	}
 
 	// Middleware: after run
@@ -326,17 +242,13 @@ func (a *ChatAgent) RunStream(ctx context.Context, input Message, output chan<-
 	span, ctx := obs.TracerImpl.StartSpan(ctx, "agent.run_stream")
 	defer span.End()
 
-	// Store incoming message
-	if a.Mem != nil {
-		if existing, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-			if msgs, ok := existing.([]Message); ok {
-				msgs = append(msgs, input)
-				_ = a.Mem.Store(ctx, "conversation", msgs)
-			}
-		} else {
-			_ = a.Mem.Store(ctx, "conversation", []Message{input})
-		}
+	input, err := a.rewriteInput(ctx, input)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return err
 	}
+	// Store incoming message
+	_ = a.rememberInput(ctx, input)
 
 	// Resolve per-request config and tools if a resolver is provided
 	effectiveConfig := a.Config
@@ -352,16 +264,7 @@ func (a *ChatAgent) RunStream(ctx context.Context, input Message, output chan<-
 	}
 
 	// Build history
-	var history []Message
-	if a.Mem != nil {
-		if h, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-			if msgs, ok := h.([]Message); ok {
-				history = msgs
-			} else if msg, ok := h.(Message); ok {
-				history = []Message{msg}
-			}
-		}
-	}
+	history := a.history(ctx)
 
 	// Prepare LLM request
 	messages := []llm.Message{{Role: "system", Content: effectiveConfig.SystemPrompt}}
@@ -394,12 +297,194 @@ func (a *ChatAgent) RunStream(ctx context.Context, input Message, output chan<-
 		}
 	}
 
-	req := &llm.ChatRequest{Messages: messages, Tools: toolDefs}
+	maxIterations := effectiveConfig.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = 1
+	}
+
+	var buffer string
+	exhausted := false
+	for iter := 0; iter < maxIterations; iter++ {
+		req := &llm.ChatRequest{Messages: messages, Tools: toolDefs}
+		content, toolCalls, err := a.streamModel(ctx, span, req, output)
+		if err != nil {
+			return err
+		}
+		buffer = content
+		if len(toolCalls) > 0 && effectiveTools != nil {
+			messages = append(messages, llm.Message{Role: "assistant", Content: content})
+			messages, err = a.executeToolCalls(ctx, span, effectiveTools, toolCalls, messages, nil)
+			if err != nil {
+				return err
+			}
+			exhausted = iter == maxIterations-1
+			continue
+		}
+		break
+	}
+
+	// The fallback agent emits its own final message, so only emit ours otherwise
+	emitFinal := true
+	if exhausted {
+		span.AddEvent("agent.max_iterations", map[string]interface{}{
+			"iterations": maxIterations,
+			"policy":     string(effectiveConfig.OnMaxIterations),
+		})
+		switch effectiveConfig.OnMaxIterations {
+		case ExhaustForceFinal:
+			req := &llm.ChatRequest{Messages: messages, Tools: toolDefs, ToolChoice: "none"}
+			content, _, err := a.streamModel(ctx, span, req, output)
+			if err != nil {
+				return err
+			}
+			buffer = content
+		case ExhaustError:
+			err := &MaxIterationsError{Iterations: maxIterations, Transcript: messages}
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return err
+		case ExhaustFallback:
+			if a.fallback == nil {
+				err := &MaxIterationsError{Iterations: maxIterations, Transcript: messages}
+				span.SetStatus(obs.StatusCodeError, err.Error())
+				return err
+			}
+			fbOut := make(chan Message)
+			fbErr := make(chan error, 1)
+			go func() { fbErr <- a.fallback.RunStream(ctx, input, fbOut) }()
+			for m := range fbOut {
+				buffer = m.Content
+				select {
+				case output <- m:
+				default:
+				}
+			}
+			if err := <-fbErr; err != nil {
+				span.SetStatus(obs.StatusCodeError, err.Error())
+				return fmt.Errorf("fallback agent failed: %w", err)
+			}
+			emitFinal = false
+		}
+	}
+
+	// Streaming done, emit final message and persist
+	if buffer != "" {
+		final := Message{Role: "assistant", Content: buffer}
+		_ = a.remember(ctx, final)
+		if emitFinal {
+			select {
+			case output <- final:
+			default:
+			}
+		}
+	}
+	span.SetStatus(obs.StatusCodeOk, "")
+	return nil
+}
+
+// callModel runs a single non-streaming model call wrapped by middleware hooks
+func (a *ChatAgent) callModel(ctx context.Context, span obs.Span, req *llm.ChatRequest, rec *RunResult) (*llm.Response, error) {
+	// Middleware: before LLM
 	for _, m := range a.mw {
 		if err := m.BeforeLLMCall(ctx, req); err != nil {
 			span.SetStatus(obs.StatusCodeError, err.Error())
-			return err
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	response, err := a.intercept(ctx, span, req)
+	if response == nil && err == nil {
+		response, err = a.Model.Chat(ctx, req)
+	}
+	rec.addLLM(start, req, response, err)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+
+	// Middleware: after LLM
+	for _, m := range a.mw {
+		if err := m.AfterLLMResponse(ctx, response); err != nil {
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return nil, err
+		}
+	}
+
+	// Trace attributes and token metrics
+	span.SetAttribute(obs.AttrProvider, response.Provider)
+	span.SetAttribute(obs.AttrModel, response.Model)
+	if response.FinishReason != "" {
+		span.SetAttribute(obs.AttrFinishReason, response.FinishReason)
+	}
+	if response.Usage != nil {
+		obs.MetricsImpl.IncrementTokensUsed(response.Usage.InputTokens, map[string]string{"direction": "input", "model": response.Model})
+		obs.MetricsImpl.IncrementTokensUsed(response.Usage.OutputTokens, map[string]string{"direction": "output", "model": response.Model})
+		span.SetAttribute(obs.AttrTokensInput, response.Usage.InputTokens)
+		span.SetAttribute(obs.AttrTokensOutput, response.Usage.OutputTokens)
+	}
+	return response, nil
+}
+
+// rewriteInput passes the input through every InputRewriteMiddleware
+func (a *ChatAgent) rewriteInput(ctx context.Context, input Message) (Message, error) {
+	for _, m := range a.mw {
+		if rw, ok := m.(InputRewriteMiddleware); ok {
+			var err error
+			if input, err = rw.RewriteInput(ctx, input); err != nil {
+				return Message{}, err
+			}
+		}
+	}
+	return input, nil
+}
+
+// intercept returns the first response supplied by a ShortCircuitMiddleware, or nil
+func (a *ChatAgent) intercept(ctx context.Context, span obs.Span, req *llm.ChatRequest) (*llm.Response, error) {
+	for _, m := range a.mw {
+		sc, ok := m.(ShortCircuitMiddleware)
+		if !ok {
+			continue
+		}
+		resp, err := sc.InterceptLLMCall(ctx, req)
+		if err != nil {
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return nil, err
+		}
+		if resp != nil {
+			span.AddEvent("llm.short_circuit", map[string]interface{}{"middleware": fmt.Sprintf("%T", m)})
+			return resp, nil
+		}
+	}
+	return nil, nil
+}
+
+// streamModel runs a single streaming model call, forwarding content chunks to output.
+// It returns the aggregated content and any tool calls carried by the chunks.
+func (a *ChatAgent) streamModel(ctx context.Context, span obs.Span, req *llm.ChatRequest, output chan<- Message) (string, []llm.ToolCall, error) {
+	for _, m := range a.mw {
+		if err := m.BeforeLLMCall(ctx, req); err != nil {
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return "", nil, err
+		}
+	}
+
+	// A short-circuit response is forwarded as a single chunk
+	if resp, err := a.intercept(ctx, span, req); err != nil || resp != nil {
+		if err != nil {
+			return "", nil, err
 		}
+		for _, m := range a.mw {
+			if err := m.AfterLLMResponse(ctx, resp); err != nil {
+				return "", nil, err
+			}
+		}
+		if resp.Content != "" {
+			select {
+			case output <- Message{Role: "assistant", Content: resp.Content, Meta: map[string]string{"streaming": "true"}}:
+			default:
+			}
+		}
+		return resp.Content, resp.ToolCalls, nil
 	}
 
 	// Stream from LLM and forward chunks
@@ -411,34 +496,23 @@ func (a *ChatAgent) RunStream(ctx context.Context, input Message, output chan<-
 	}()
 
 	var buffer string
+	var toolCalls []llm.ToolCall
 	for {
 		select {
 		case resp, ok := <-inner:
 			if !ok {
-				// Streaming done, emit final message and persist
-				if buffer != "" {
-					final := Message{Role: "assistant", Content: buffer}
-					if a.Mem != nil {
-						if existing, err := a.Mem.Retrieve(ctx, "conversation"); err == nil {
-							if msgs, ok := existing.([]Message); ok {
-								msgs = append(msgs, final)
-								_ = a.Mem.Store(ctx, "conversation", msgs)
-							}
-						} else {
-							_ = a.Mem.Store(ctx, "conversation", []Message{final})
-						}
-					}
-					select {
-					case output <- final:
-					default:
-					}
-				}
-				span.SetStatus(obs.StatusCodeOk, "")
-				return nil
+				return buffer, toolCalls, nil
 			}
 			if resp == nil {
 				continue
 			}
+			// Middleware sees each chunk before it is forwarded, so it can rewrite or stop it
+			for _, m := range a.mw {
+				if err := m.AfterLLMResponse(ctx, resp); err != nil {
+					span.SetStatus(obs.StatusCodeError, err.Error())
+					return "", nil, err
+				}
+			}
 			// Forward incremental content
 			if resp.Content != "" {
 				buffer += resp.Content
@@ -447,17 +521,114 @@ func (a *ChatAgent) RunStream(ctx context.Context, input Message, output chan<-
 				default:
 				}
 			}
-			for _, m := range a.mw {
-				_ = m.AfterLLMResponse(ctx, resp)
-			}
+			toolCalls = append(toolCalls, resp.ToolCalls...)
 		case err := <-errCh:
 			if err != nil {
-				return err
+				return "", nil, err
 			}
 		case <-ctx.Done():
-			return ctx.Err()
+			return "", nil, ctx.Err()
+		}
+	}
+}
+
+// executeToolCalls runs the requested tools and appends their results to messages
+func (a *ChatAgent) executeToolCalls(ctx context.Context, span obs.Span, registry tools.Registry, calls []llm.ToolCall, messages []llm.Message, rec *RunResult) ([]llm.Message, error) {
+	for _, tc := range calls {
+		start := time.Now()
+		// Resolve tool
+		toolName := tc.Function.Name
+		tool, ok := registry.Get(toolName)
+		if !ok {
+			span.AddEvent("tool.not_found", map[string]interface{}{"tool": toolName})
+			rec.addTool(start, tc, "", fmt.Errorf("tool %s not found", toolName))
+			continue
+		}
+
+		// Parse arguments; support {"input":"..."} or raw string
+		inputStr := tc.Function.Arguments
+		var argObj map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &argObj); err == nil {
+			if v, ok := argObj["input"].(string); ok {
+				inputStr = v
+			}
+		}
+
+		// Basic schema validation for required fields if provided
+		if schema := tool.Schema(); schema != nil {
+			if reqFields, ok := schema["required"].([]string); ok {
+				// Convert argObj if JSON parsed ok, otherwise create object with only input
+				args := argObj
+				if args == nil {
+					args = map[string]interface{}{"input": inputStr}
+				}
+				missing := make([]string, 0)
+				for _, f := range reqFields {
+					if _, ok := args[f]; !ok {
+						missing = append(missing, f)
+					}
+				}
+				if len(missing) > 0 {
+					// Surface validation failure to model
+					content := fmt.Sprintf("error: missing required fields %v", missing)
+					messages = append(messages, llm.Message{Role: "tool", Content: content, ToolCallID: tc.ID})
+					rec.addTool(start, tc, content, nil)
+					continue
+				}
+			}
+		}
+
+		// Middleware: before tool
+		for _, m := range a.mw {
+			if err := m.BeforeToolExecute(ctx, toolName, inputStr); err != nil {
+				span.SetStatus(obs.StatusCodeError, err.Error())
+				return messages, err
+			}
+		}
+
+		// Middleware: rewrite tool input (e.g. detokenize)
+		for _, m := range a.mw {
+			if rw, ok := m.(ToolRewriteMiddleware); ok {
+				var rerr error
+				if inputStr, rerr = rw.RewriteToolInput(ctx, toolName, inputStr); rerr != nil {
+					span.SetStatus(obs.StatusCodeError, rerr.Error())
+					return messages, rerr
+				}
+			}
+		}
+
+		// Execute tool via registry (already instrumented)
+		result, err := registry.Execute(ctx, tool.Name(), inputStr)
+		if err != nil {
+			// Provide error back to model as tool content
+			result = fmt.Sprintf("error: %v", err)
+		}
+
+		// Middleware: rewrite tool result before the model and observers see it
+		for _, m := range a.mw {
+			if rw, ok := m.(ToolRewriteMiddleware); ok {
+				var rerr error
+				if result, rerr = rw.RewriteToolResult(ctx, toolName, result); rerr != nil {
+					span.SetStatus(obs.StatusCodeError, rerr.Error())
+					return messages, rerr
+				}
+			}
+		}
+
+		// Middleware: after tool
+		for _, m := range a.mw {
+			_ = m.AfterToolExecute(ctx, toolName, result, err)
 		}
+		rec.addTool(start, tc, result, err)
+
+		// Append tool result message
+		messages = append(messages, llm.Message{
+			Role:       "tool",
+			Content:    result,
+			ToolCallID: tc.ID,
+		})
 	}
+	return messages, nil
 }
 
 // applyProcessors applies configured memory processors to history
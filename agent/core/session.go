@@ -0,0 +1,17 @@
+package core
+
+import "context"
+
+type sessionIDKey struct{}
+
+// WithSessionID stores the conversation session id in the context. Servers set it
+// so multi-agent setups can keep per-session state such as the handoff owner.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// SessionIDFromContext returns the session id, or "" when none is set
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/KamdynS/go-agents/llm"
+)
+
+// StepType identifies what happened in a transcript step
+type StepType string
+
+const (
+	StepLLM      StepType = "llm"
+	StepTool     StepType = "tool"
+	StepFallback StepType = "fallback"
+)
+
+// Step is a single model call, tool execution or fallback hand-off within a run
+type Step struct {
+	Type      StepType      `json:"type"`
+	Iteration int           `json:"iteration"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+
+	// Model call fields
+	Model        string         `json:"model,omitempty"`
+	Provider     llm.Provider   `json:"provider,omitempty"`
+	Request      []llm.Message  `json:"request,omitempty"`
+	Content      string         `json:"content,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	ToolCalls    []llm.ToolCall `json:"tool_calls,omitempty"`
+	Usage        *llm.Usage     `json:"usage,omitempty"`
+
+	// Tool execution fields
+	ToolName   string `json:"tool_name,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Arguments  string `json:"arguments,omitempty"`
+	Output     string `json:"output,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// RunResult is the full record of a ChatAgent run. It is plain data and
+// serializes to JSON for auditing, debugging and building eval datasets.
+type RunResult struct {
+	Input     Message       `json:"input"`
+	Final     Message       `json:"final"`
+	Steps     []Step        `json:"steps"`
+	Usage     llm.Usage     `json:"usage"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// RunWithTranscript behaves like Run but also returns every intermediate step.
+// On failure the partial transcript is returned alongside the error.
+func (a *ChatAgent) RunWithTranscript(ctx context.Context, input Message) (*RunResult, error) {
+	rec := &RunResult{Input: input, StartedAt: time.Now(), Steps: []Step{}}
+	final, err := a.run(ctx, input, rec)
+	rec.Duration = time.Since(rec.StartedAt)
+	rec.Final = final
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return rec, err
+}
+
+// iteration returns the index of the latest model call (0-based)
+func (r *RunResult) iteration() int {
+	n := -1
+	for _, s := range r.Steps {
+		if s.Type == StepLLM {
+			n++
+		}
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+func (r *RunResult) addLLM(start time.Time, req *llm.ChatRequest, resp *llm.Response, err error) {
+	if r == nil {
+		return
+	}
+	step := Step{Type: StepLLM, StartedAt: start, Duration: time.Since(start)}
+	if req != nil {
+		step.Request = append([]llm.Message(nil), req.Messages...)
+	}
+	if resp != nil {
+		step.Model = resp.Model
+		step.Provider = resp.Provider
+		step.Content = resp.Content
+		step.FinishReason = resp.FinishReason
+		step.ToolCalls = resp.ToolCalls
+		step.Usage = resp.Usage
+		if resp.Usage != nil {
+			r.Usage.InputTokens += resp.Usage.InputTokens
+			r.Usage.OutputTokens += resp.Usage.OutputTokens
+			r.Usage.TotalTokens += resp.Usage.TotalTokens
+			r.Usage.Cost += resp.Usage.Cost
+		}
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+	r.Steps[len(r.Steps)-1].Iteration = r.iteration()
+}
+
+func (r *RunResult) addTool(start time.Time, tc llm.ToolCall, output string, err error) {
+	if r == nil {
+		return
+	}
+	step := Step{
+		Type:       StepTool,
+		Iteration:  r.iteration(),
+		StartedAt:  start,
+		Duration:   time.Since(start),
+		ToolName:   tc.Function.Name,
+		ToolCallID: tc.ID,
+		Arguments:  tc.Function.Arguments,
+		Output:     output,
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+func (r *RunResult) addFallback(start time.Time, out Message, err error) {
+	if r == nil {
+		return
+	}
+	step := Step{Type: StepFallback, Iteration: r.iteration(), StartedAt: start, Duration: time.Since(start), Content: out.Content}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+func TestRunWithTranscript_CapturesSteps(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.AddResponseWithToolCalls("calling", echoCall("c1"))
+	mock.responses[0].Usage = &llm.Usage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}
+	mock.AddResponse("done")
+	mock.responses[1].Usage = &llm.Usage{InputTokens: 15, OutputTokens: 3, TotalTokens: 18}
+
+	reg := tools.NewRegistry()
+	_ = reg.Register(&EchoTool{})
+	agent := NewChatAgent(ChatConfig{Model: mock, Tools: reg, Config: AgentConfig{SystemPrompt: "sys", MaxIterations: 3}})
+
+	res, err := agent.RunWithTranscript(context.Background(), Message{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("run err: %v", err)
+	}
+	if res.Final.Content != "done" {
+		t.Fatalf("unexpected final: %q", res.Final.Content)
+	}
+	if len(res.Steps) != 3 {
+		t.Fatalf("want 3 steps (llm, tool, llm) got %d: %+v", len(res.Steps), res.Steps)
+	}
+	if res.Steps[0].Type != StepLLM || res.Steps[1].Type != StepTool || res.Steps[2].Type != StepLLM {
+		t.Fatalf("unexpected step order: %+v", res.Steps)
+	}
+	tool := res.Steps[1]
+	if tool.ToolName != "echo" || tool.ToolCallID != "c1" || tool.Output != "ECHO:x" || tool.Iteration != 0 {
+		t.Fatalf("unexpected tool step: %+v", tool)
+	}
+	if res.Steps[2].Iteration != 1 {
+		t.Fatalf("second model call should be iteration 1, got %d", res.Steps[2].Iteration)
+	}
+	if res.Usage.TotalTokens != 30 {
+		t.Fatalf("want aggregated 30 tokens got %d", res.Usage.TotalTokens)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back RunResult
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(back.Steps) != 3 || back.Steps[1].Output != "ECHO:x" {
+		t.Fatalf("round trip lost steps: %+v", back.Steps)
+	}
+}
+
+func TestRunWithTranscript_ErrorKeepsPartial(t *testing.T) {
+	mock := NewMockLLMClient()
+	mock.SetError(errors.New("boom"))
+	agent := NewChatAgent(ChatConfig{Model: mock, Config: AgentConfig{SystemPrompt: "sys"}})
+	res, err := agent.RunWithTranscript(context.Background(), Message{Role: "user", Content: "hi"})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if res == nil || res.Error == "" || len(res.Steps) != 1 || res.Steps[0].Error == "" {
+		t.Fatalf("expected partial transcript with error, got %+v", res)
+	}
+}
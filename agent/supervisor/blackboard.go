@@ -0,0 +1,163 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+// TaskStatus is the lifecycle state of a blackboard task
+type TaskStatus string
+
+const (
+	TaskPending TaskStatus = "pending"
+	TaskRunning TaskStatus = "running"
+	TaskDone    TaskStatus = "done"
+	TaskFailed  TaskStatus = "failed"
+)
+
+// Task is one subtask assigned by a manager to a worker
+type Task struct {
+	ID          string     `json:"id"`
+	Round       int        `json:"round"`
+	Agent       string     `json:"agent"`
+	Description string     `json:"description"`
+	Status      TaskStatus `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Blackboard is a shared task list kept in a memory.Store under a single key.
+// Tasks are stored as a JSON string so any backend can hold them.
+type Blackboard struct {
+	store memory.Store
+	key   string
+	mu    sync.Mutex
+}
+
+// NewBlackboard returns a blackboard stored under blackboard:<id>
+func NewBlackboard(store memory.Store, id string) *Blackboard {
+	return &Blackboard{store: store, key: "blackboard:" + id}
+}
+
+// Key returns the memory key holding the blackboard
+func (b *Blackboard) Key() string { return b.key }
+
+// Put inserts or replaces a task by ID
+func (b *Blackboard) Put(ctx context.Context, t Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tasks, err := b.load(ctx)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range tasks {
+		if tasks[i].ID == t.ID {
+			tasks[i], replaced = t, true
+			break
+		}
+	}
+	if !replaced {
+		tasks = append(tasks, t)
+	}
+	data, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	return b.store.Store(ctx, b.key, string(data))
+}
+
+// Tasks returns all tasks in insertion order
+func (b *Blackboard) Tasks(ctx context.Context) ([]Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.load(ctx)
+}
+
+// Delete removes the blackboard from its store
+func (b *Blackboard) Delete(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.store.Delete(ctx, b.key)
+}
+
+// Summary renders the tasks for inclusion in a prompt
+func (b *Blackboard) Summary(ctx context.Context) (string, error) {
+	tasks, err := b.Tasks(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(tasks) == 0 {
+		return "(empty)", nil
+	}
+	var sb strings.Builder
+	for _, t := range tasks {
+		fmt.Fprintf(&sb, "- [%s] %s (%s): %s\n", t.ID, t.Agent, t.Status, t.Description)
+		switch {
+		case t.Result != "":
+			fmt.Fprintf(&sb, "  result: %s\n", t.Result)
+		case t.Error != "":
+			fmt.Fprintf(&sb, "  error: %s\n", t.Error)
+		}
+	}
+	return sb.String(), nil
+}
+
+func (b *Blackboard) load(ctx context.Context) ([]Task, error) {
+	v, err := b.store.Retrieve(ctx, b.key)
+	if errors.Is(err, memory.ErrNotFound) {
+		return []Task{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load blackboard: %w", err)
+	}
+	raw, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected blackboard value %T", v)
+	}
+	var tasks []Task
+	if err := json.Unmarshal([]byte(raw), &tasks); err != nil {
+		return nil, fmt.Errorf("decode blackboard: %w", err)
+	}
+	return tasks, nil
+}
+
+type blackboardKey struct{}
+
+// WithBlackboard makes the blackboard available to workers and BlackboardTool
+func WithBlackboard(ctx context.Context, b *Blackboard) context.Context {
+	return context.WithValue(ctx, blackboardKey{}, b)
+}
+
+// BlackboardFromContext returns the blackboard of the enclosing manager run, if any
+func BlackboardFromContext(ctx context.Context) (*Blackboard, bool) {
+	b, ok := ctx.Value(blackboardKey{}).(*Blackboard)
+	return b, ok
+}
+
+// BlackboardTool lets a worker read the other workers' results during a manager run
+type BlackboardTool struct{}
+
+func (BlackboardTool) Name() string { return "read_blackboard" }
+func (BlackboardTool) Description() string {
+	return "Read the shared blackboard with every subtask, its status and its result"
+}
+func (BlackboardTool) Schema() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+func (BlackboardTool) Execute(ctx context.Context, input string) (string, error) {
+	b, ok := BlackboardFromContext(ctx)
+	if !ok {
+		return "", errors.New("no blackboard in context")
+	}
+	return b.Summary(ctx)
+}
+
+var _ tools.Tool = BlackboardTool{}
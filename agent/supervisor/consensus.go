@@ -0,0 +1,335 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+var (
+	// ErrTooFewResponses is returned when fewer agents than required produced an answer
+	ErrTooFewResponses = errors.New("too few agent responses")
+	// ErrNoConsensus is returned by MajorityVote when a tie cannot be broken
+	ErrNoConsensus = errors.New("no consensus")
+)
+
+// candidate is one agent's answer within a voting or debate round
+type candidate struct {
+	index  int
+	name   string
+	answer string
+	err    error
+}
+
+// gather runs every agent concurrently with its own timeout. prompt builds the
+// input for the agent at index i. Results keep the order of agents.
+func gather(ctx context.Context, agents []NamedAgent, timeout time.Duration, prompt func(i int) string) []candidate {
+	out := make([]candidate, len(agents))
+	var wg sync.WaitGroup
+	for i, a := range agents {
+		wg.Add(1)
+		go func(i int, a NamedAgent) {
+			defer wg.Done()
+			actx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				actx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			c := candidate{index: i, name: a.Name}
+			msg, err := a.Run(actx, core.Message{Role: "user", Content: prompt(i)})
+			if err == nil && actx.Err() != nil {
+				err = actx.Err()
+			}
+			if err != nil {
+				c.err = fmt.Errorf("%s: %w", a.Name, err)
+			} else {
+				c.answer = msg.Content
+			}
+			out[i] = c
+		}(i, a)
+	}
+	wg.Wait()
+	return out
+}
+
+// succeeded filters failed candidates and enforces the minimum response count
+func succeeded(cands []candidate, need int) ([]candidate, error) {
+	if need <= 0 {
+		need = 1
+	}
+	var ok []candidate
+	var errs []error
+	for _, c := range cands {
+		if c.err != nil {
+			errs = append(errs, c.err)
+			continue
+		}
+		ok = append(ok, c)
+	}
+	if len(ok) < need {
+		return nil, fmt.Errorf("%w: got %d, need %d: %w", ErrTooFewResponses, len(ok), need, errors.Join(errs...))
+	}
+	return ok, nil
+}
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+// NormalizeAnswer lowercases, collapses whitespace and trims surrounding
+// punctuation so "Positive." and " positive" count as the same vote.
+func NormalizeAnswer(s string) string {
+	s = strings.ToLower(spaceRe.ReplaceAllString(strings.TrimSpace(s), " "))
+	return strings.Trim(s, " .,;:!?\"'`")
+}
+
+// TieBreak selects how MajorityVote resolves equally common answers
+type TieBreak string
+
+const (
+	// TieBreakFirst picks the tied answer given by the earliest agent in the list (default)
+	TieBreakFirst TieBreak = ""
+	// TieBreakError returns ErrNoConsensus on a tie
+	TieBreakError TieBreak = "error"
+)
+
+// MajorityVote runs all agents in parallel and returns the most common
+// normalized answer. The returned text is the first raw answer in the winning group.
+type MajorityVote struct {
+	// Normalize maps answers to vote keys; defaults to NormalizeAnswer
+	Normalize func(string) string
+	TieBreak  TieBreak
+	// AgentTimeout bounds each agent's run; zero means only ctx applies
+	AgentTimeout time.Duration
+	// MinResponses is the number of successful answers required (default 1).
+	// Failed agents are ignored as long as this is met.
+	MinResponses int
+}
+
+func (p MajorityVote) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.vote")
+	defer span.End()
+
+	named := describe(agents)
+	cands := gather(ctx, named, p.AgentTimeout, func(int) string { return prompt })
+	ok, err := succeeded(cands, p.MinResponses)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", err
+	}
+	winner, votes, err := p.tally(ok)
+	span.AddEvent("vote.result", map[string]interface{}{
+		"responses": len(ok),
+		"failures":  len(cands) - len(ok),
+		"votes":     votes,
+	})
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", err
+	}
+	span.SetAttribute(obs.AttrAgentName, winner.name)
+	span.SetStatus(obs.StatusCodeOk, "")
+	return winner.answer, nil
+}
+
+// tally counts votes and returns the winning candidate and the winning vote count
+func (p MajorityVote) tally(cands []candidate) (candidate, int, error) {
+	norm := p.Normalize
+	if norm == nil {
+		norm = NormalizeAnswer
+	}
+	counts := map[string]int{}
+	first := map[string]candidate{}
+	var order []string
+	for _, c := range cands {
+		k := norm(c.answer)
+		if _, seen := first[k]; !seen {
+			first[k] = c
+			order = append(order, k)
+		}
+		counts[k]++
+	}
+	// found rather than best == "": an empty normalized answer can win too
+	best, found, tied := "", false, false
+	for _, k := range order {
+		switch {
+		case !found || counts[k] > counts[best]:
+			best, found, tied = k, true, false
+		case counts[k] == counts[best]:
+			tied = true
+		}
+	}
+	if tied && p.TieBreak == TieBreakError {
+		return candidate{}, counts[best], fmt.Errorf("%w: %d answers tied with %d votes", ErrNoConsensus, len(order), counts[best])
+	}
+	return first[best], counts[best], nil
+}
+
+// JudgePolicy runs all agents in parallel and asks a judge agent to pick the
+// best candidate, or to write a new answer from them when Synthesize is set.
+type JudgePolicy struct {
+	Judge core.Agent
+	// Synthesize returns the judge's own answer instead of a selected candidate
+	Synthesize bool
+	// Instructions are added to the judge prompt, e.g. grading criteria
+	Instructions string
+	AgentTimeout time.Duration
+	MinResponses int
+}
+
+func (p JudgePolicy) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.judge")
+	defer span.End()
+
+	if p.Judge == nil {
+		span.SetStatus(obs.StatusCodeError, "nil judge")
+		return "", errors.New("judge policy requires a judge agent")
+	}
+	cands := gather(ctx, describe(agents), p.AgentTimeout, func(int) string { return prompt })
+	ok, err := succeeded(cands, p.MinResponses)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", err
+	}
+	out, err := judge(ctx, p.Judge, prompt, ok, p.Synthesize, p.Instructions)
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", err
+	}
+	span.AddEvent("judge.result", map[string]interface{}{"responses": len(ok), "failures": len(cands) - len(ok)})
+	span.SetStatus(obs.StatusCodeOk, "")
+	return out, nil
+}
+
+var firstNumber = regexp.MustCompile(`\d+`)
+
+// judge asks the judge agent to select (by number) or synthesize an answer
+func judge(ctx context.Context, j core.Agent, prompt string, cands []candidate, synthesize bool, instructions string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question:\n%s\n\nCandidate answers:\n", prompt)
+	for i, c := range cands {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, c.answer)
+	}
+	if synthesize {
+		b.WriteString("\nWrite the best possible answer to the question using the candidates above. Reply with the answer only.")
+	} else {
+		b.WriteString("\nReply with the number of the best candidate only.")
+	}
+	if instructions != "" {
+		b.WriteString("\n" + instructions)
+	}
+	out, err := j.Run(ctx, core.Message{Role: "user", Content: b.String()})
+	if err != nil {
+		return "", fmt.Errorf("judge: %w", err)
+	}
+	if synthesize {
+		return out.Content, nil
+	}
+	n, err := strconv.Atoi(firstNumber.FindString(out.Content))
+	if err != nil || n < 1 || n > len(cands) {
+		return "", fmt.Errorf("judge returned no valid candidate number: %q", out.Content)
+	}
+	return cands[n-1].answer, nil
+}
+
+// DebatePolicy runs Rounds of discussion: after the first round every agent sees
+// the other agents' latest answers and may revise its own. The final answers are
+// decided by Judge when set, otherwise by a MajorityVote.
+type DebatePolicy struct {
+	// Rounds is the number of rounds including the first (default 2)
+	Rounds int
+	// Judge selects the final answer; nil uses a majority vote over the last round
+	Judge      core.Agent
+	Synthesize bool
+	// Vote configures the final vote when Judge is nil
+	Vote         MajorityVote
+	AgentTimeout time.Duration
+	// MinResponses applies to every round; agents that fail drop out of later rounds
+	MinResponses int
+}
+
+func (p DebatePolicy) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.debate")
+	defer span.End()
+
+	rounds := p.Rounds
+	if rounds <= 0 {
+		rounds = 2
+	}
+	active := describe(agents)
+	var prev []candidate
+	for r := 0; r < rounds; r++ {
+		cands := gather(ctx, active, p.AgentTimeout, func(i int) string {
+			if r == 0 {
+				return prompt
+			}
+			return debatePrompt(prompt, active[i].Name, prev)
+		})
+		ok, err := succeeded(cands, p.MinResponses)
+		if err != nil {
+			span.SetStatus(obs.StatusCodeError, err.Error())
+			return "", fmt.Errorf("debate round %d: %w", r+1, err)
+		}
+		span.AddEvent("debate.round", map[string]interface{}{
+			"round":     r + 1,
+			"responses": len(ok),
+			"failures":  len(cands) - len(ok),
+		})
+		next := make([]NamedAgent, len(ok))
+		for i, c := range ok {
+			next[i] = active[c.index]
+			ok[i].index = i
+		}
+		active, prev = next, ok
+	}
+
+	var (
+		out string
+		err error
+	)
+	if p.Judge != nil {
+		out, err = judge(ctx, p.Judge, prompt, prev, p.Synthesize, "")
+	} else {
+		var winner candidate
+		winner, _, err = p.Vote.tally(prev)
+		out = winner.answer
+	}
+	if err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", err
+	}
+	span.SetStatus(obs.StatusCodeOk, "")
+	return out, nil
+}
+
+// debatePrompt shows an agent the other agents' answers from the previous round
+func debatePrompt(prompt, self string, prev []candidate) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Question:\n%s\n\n", prompt)
+	for _, c := range prev {
+		if c.name == self {
+			fmt.Fprintf(&b, "Your previous answer:\n%s\n\n", c.answer)
+		}
+	}
+	b.WriteString("Other agents answered:\n")
+	for _, c := range prev {
+		if c.name != self {
+			fmt.Fprintf(&b, "- %s: %s\n", c.name, c.answer)
+		}
+	}
+	b.WriteString("\nConsider their reasoning and give your final answer.")
+	return b.String()
+}
+
+var (
+	_ Policy = MajorityVote{}
+	_ Policy = JudgePolicy{}
+	_ Policy = DebatePolicy{}
+)
@@ -0,0 +1,137 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+)
+
+// funcAgent answers with the result of fn and records every input it sees
+type funcAgent struct {
+	fn     func(ctx context.Context, input string) (string, error)
+	mu     sync.Mutex
+	inputs []string
+}
+
+func answer(s string) *funcAgent {
+	return &funcAgent{fn: func(context.Context, string) (string, error) { return s, nil }}
+}
+
+func (f *funcAgent) Run(ctx context.Context, input core.Message) (core.Message, error) {
+	f.mu.Lock()
+	f.inputs = append(f.inputs, input.Content)
+	f.mu.Unlock()
+	out, err := f.fn(ctx, input.Content)
+	return core.Message{Role: "assistant", Content: out}, err
+}
+func (f *funcAgent) RunStream(ctx context.Context, input core.Message, output chan<- core.Message) error {
+	defer close(output)
+	m, err := f.Run(ctx, input)
+	if err == nil {
+		output <- m
+	}
+	return err
+}
+
+func slow() *funcAgent {
+	return &funcAgent{fn: func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+}
+
+func TestMajorityVote_NormalizesAndTolerantOfFailures(t *testing.T) {
+	agents := []core.Agent{
+		answer("Negative"),
+		answer(" positive."),
+		answer("POSITIVE"),
+		&funcAgent{fn: func(context.Context, string) (string, error) { return "", errors.New("boom") }},
+		slow(),
+	}
+	p := MajorityVote{AgentTimeout: 20 * time.Millisecond, MinResponses: 3}
+	out, err := p.Execute(context.Background(), "classify", agents)
+	if err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+	if out != " positive." {
+		t.Fatalf("expected first raw positive answer, got %q", out)
+	}
+
+	p.MinResponses = 4
+	if _, err := p.Execute(context.Background(), "classify", agents); !errors.Is(err, ErrTooFewResponses) {
+		t.Fatalf("expected ErrTooFewResponses, got %v", err)
+	}
+}
+
+func TestMajorityVote_TieBreak(t *testing.T) {
+	agents := []core.Agent{answer("b"), answer("a")}
+	out, err := MajorityVote{}.Execute(context.Background(), "q", agents)
+	if err != nil || out != "b" {
+		t.Fatalf("expected earliest agent to win tie, got %v %q", err, out)
+	}
+	if _, err := (MajorityVote{TieBreak: TieBreakError}).Execute(context.Background(), "q", agents); !errors.Is(err, ErrNoConsensus) {
+		t.Fatalf("expected ErrNoConsensus, got %v", err)
+	}
+
+	// Answers that normalize to "" can win like any other
+	out, err = MajorityVote{}.Execute(context.Background(), "q", []core.Agent{answer("..."), answer("?"), answer("yes")})
+	if err != nil || out != "..." {
+		t.Fatalf("expected the empty normalized answer to win, got %v %q", err, out)
+	}
+}
+
+func TestJudgePolicy_SelectAndSynthesize(t *testing.T) {
+	agents := []core.Agent{answer("short"), answer("detailed")}
+	judgeAgent := answer("Candidate 2 is best")
+	out, err := JudgePolicy{Judge: judgeAgent}.Execute(context.Background(), "q", agents)
+	if err != nil || out != "detailed" {
+		t.Fatalf("expected selected candidate, got %v %q", err, out)
+	}
+	if !strings.Contains(judgeAgent.inputs[0], "[1] short") {
+		t.Fatalf("judge should see numbered candidates: %q", judgeAgent.inputs[0])
+	}
+
+	out, err = JudgePolicy{Judge: answer("merged"), Synthesize: true}.Execute(context.Background(), "q", agents)
+	if err != nil || out != "merged" {
+		t.Fatalf("expected synthesized answer, got %v %q", err, out)
+	}
+
+	if _, err := (JudgePolicy{Judge: answer("none")}).Execute(context.Background(), "q", agents); err == nil {
+		t.Fatalf("expected error for unparseable judge reply")
+	}
+}
+
+func TestDebatePolicy_AgentsSeeEachOther(t *testing.T) {
+	// The stubborn agent switches once it sees the others agreeing
+	stubborn := &funcAgent{fn: func(_ context.Context, in string) (string, error) {
+		if strings.Contains(in, "Other agents answered") {
+			return "yes", nil
+		}
+		return "no", nil
+	}}
+	a, b := answer("yes"), answer("yes")
+	failing := &funcAgent{fn: func(context.Context, string) (string, error) { return "", errors.New("down") }}
+
+	out, err := DebatePolicy{Rounds: 2, MinResponses: 2}.Execute(context.Background(), "q", []core.Agent{
+		Named("stubborn", "", stubborn), Named("a", "", a), Named("b", "", b), Named("failing", "", failing),
+	})
+	if err != nil || out != "yes" {
+		t.Fatalf("unexpected debate result: %v %q", err, out)
+	}
+	if len(stubborn.inputs) != 2 || !strings.Contains(stubborn.inputs[1], "- a: yes") || !strings.Contains(stubborn.inputs[1], "Your previous answer:\nno") {
+		t.Fatalf("second round should show other answers: %q", stubborn.inputs)
+	}
+	if len(failing.inputs) != 1 {
+		t.Fatalf("failed agent should drop out after round 1, saw %d calls", len(failing.inputs))
+	}
+
+	out, err = DebatePolicy{Rounds: 1, Judge: answer("1")}.Execute(context.Background(), "q", []core.Agent{answer("x"), answer("y")})
+	if err != nil || out != "x" {
+		t.Fatalf("expected judge to decide, got %v %q", err, out)
+	}
+}
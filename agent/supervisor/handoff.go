@@ -0,0 +1,360 @@
+package supervisor
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	obs "github.com/KamdynS/go-agents/observability"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+// HandoffEvent is the Meta["event"] value of the stream message emitted on a handoff
+const HandoffEvent = "handoff"
+
+// HandoffTool lets the model transfer the conversation to another agent of the
+// Team. Register it in the tools of every agent that may hand off. Unlike
+// AgentTool, control does not come back: the target owns the session afterwards.
+type HandoffTool struct {
+	Target string
+	Desc   string
+}
+
+// NewHandoffTool returns a transfer_to_<target> tool
+func NewHandoffTool(target, description string) *HandoffTool {
+	return &HandoffTool{Target: target, Desc: description}
+}
+
+func (h *HandoffTool) Name() string { return "transfer_to_" + h.Target }
+func (h *HandoffTool) Description() string {
+	if h.Desc != "" {
+		return h.Desc
+	}
+	return "Transfer the conversation to the " + h.Target + " agent"
+}
+func (h *HandoffTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"reason": map[string]interface{}{"type": "string", "description": "Why the conversation is transferred"},
+		},
+	}
+}
+
+func (h *HandoffTool) Execute(ctx context.Context, input string) (string, error) {
+	st, ok := ctx.Value(handoffKey{}).(*handoffState)
+	if !ok {
+		return "", errors.New("handoff is only available inside a Team")
+	}
+	var args struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal([]byte(input), &args)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.target, st.reason = h.Target, args.Reason
+	return "Transferred to " + h.Target + ". Tell the user they are being connected.", nil
+}
+
+type handoffKey struct{}
+
+// handoffState collects the handoff requested during one agent run
+type handoffState struct {
+	mu     sync.Mutex
+	target string
+	reason string
+}
+
+func (s *handoffState) take() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, r := s.target, s.reason
+	s.target, s.reason = "", ""
+	return t, r
+}
+
+// TeamConfig configures a Team
+type TeamConfig struct {
+	Agents []NamedAgent
+	// Initial owns new sessions; defaults to the first agent
+	Initial string
+	// MaxHandoffs bounds transfers within a single turn (default 3)
+	MaxHandoffs int
+	// MaxSessions bounds the sessions whose owner is remembered (default
+	// 10000); the least recently used ones fall back to Initial
+	MaxSessions int
+}
+
+// Team is a core.Agent made of named agents that hand conversations to each other.
+// The session id from core.SessionIDFromContext selects the current owner, which
+// answers every turn until it hands off again. Requests without a session id
+// always start at the initial agent; a handoff only lasts for that turn. When the owner and target are
+// *core.ChatAgent values with different memory stores, the messages of the
+// session the target has not seen are appended to its memory on handoff.
+type Team struct {
+	agents      map[string]NamedAgent
+	initial     string
+	maxHandoffs int
+	maxSessions int
+
+	mu     sync.Mutex
+	owners map[string]*list.Element // of *sessionOwner, most recently used first
+	lru    *list.List
+}
+
+type sessionOwner struct{ session, agent string }
+
+// NewTeam creates a Team. Agent names must be unique and non-empty.
+func NewTeam(cfg TeamConfig) (*Team, error) {
+	if len(cfg.Agents) == 0 {
+		return nil, errors.New("team requires at least one agent")
+	}
+	t := &Team{
+		agents:      make(map[string]NamedAgent, len(cfg.Agents)),
+		initial:     cfg.Initial,
+		maxHandoffs: cfg.MaxHandoffs,
+		maxSessions: cfg.MaxSessions,
+		owners:      map[string]*list.Element{},
+		lru:         list.New(),
+	}
+	for _, a := range cfg.Agents {
+		if a.Name == "" {
+			return nil, errors.New("team agents must be named")
+		}
+		if _, dup := t.agents[a.Name]; dup {
+			return nil, fmt.Errorf("duplicate agent %q", a.Name)
+		}
+		t.agents[a.Name] = a
+	}
+	if t.initial == "" {
+		t.initial = cfg.Agents[0].Name
+	}
+	if _, ok := t.agents[t.initial]; !ok {
+		return nil, fmt.Errorf("unknown initial agent %q", t.initial)
+	}
+	if t.maxHandoffs <= 0 {
+		t.maxHandoffs = 3
+	}
+	if t.maxSessions <= 0 {
+		t.maxSessions = 10000
+	}
+	return t, nil
+}
+
+// Owner returns the agent currently owning the session
+func (t *Team) Owner(sessionID string) string {
+	if sessionID == "" {
+		return t.initial
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.owners[sessionID]; ok {
+		t.lru.MoveToFront(e)
+		return e.Value.(*sessionOwner).agent
+	}
+	return t.initial
+}
+
+// Reset returns the session to the initial agent; call it when a session ends
+func (t *Team) Reset(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.owners[sessionID]; ok {
+		t.lru.Remove(e)
+		delete(t.owners, sessionID)
+	}
+}
+
+// setOwner records the owner of a session; only sessions that moved away from
+// the initial agent are kept, and never the unnamed session shared by every
+// session-less caller
+func (t *Team) setOwner(sessionID, agent string) {
+	if sessionID == "" {
+		return
+	}
+	if agent == t.initial {
+		t.Reset(sessionID)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.owners[sessionID]; ok {
+		e.Value.(*sessionOwner).agent = agent
+		t.lru.MoveToFront(e)
+		return
+	}
+	t.owners[sessionID] = t.lru.PushFront(&sessionOwner{session: sessionID, agent: agent})
+	for t.lru.Len() > t.maxSessions {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.owners, oldest.Value.(*sessionOwner).session)
+	}
+}
+
+// Run answers with the session owner, following handoffs requested during the turn.
+// The reply's Meta["agent"] names the agent that produced it.
+func (t *Team) Run(ctx context.Context, input core.Message) (core.Message, error) {
+	session := core.SessionIDFromContext(ctx)
+	owner := t.Owner(session)
+	st := &handoffState{}
+	ctx = context.WithValue(ctx, handoffKey{}, st)
+	runCtx := ctx
+	for hops := 0; ; hops++ {
+		out, err := t.agents[owner].Run(runCtx, input)
+		if err != nil {
+			return core.Message{}, err
+		}
+		target, reason := st.take()
+		if target == "" || hops >= t.maxHandoffs {
+			return withAgent(out, owner), nil
+		}
+		if err := t.handoff(ctx, session, owner, target, reason); err != nil {
+			return core.Message{}, err
+		}
+		runCtx = t.continueCtx(ctx, owner, target)
+		owner = target
+	}
+}
+
+// RunStream streams the owner's reply. On a handoff a message with
+// Meta["event"] = HandoffEvent is emitted before the target's output.
+func (t *Team) RunStream(ctx context.Context, input core.Message, output chan<- core.Message) error {
+	defer close(output)
+	session := core.SessionIDFromContext(ctx)
+	owner := t.Owner(session)
+	st := &handoffState{}
+	ctx = context.WithValue(ctx, handoffKey{}, st)
+	runCtx := ctx
+	for hops := 0; ; hops++ {
+		inner := make(chan core.Message)
+		errCh := make(chan error, 1)
+		go func(a core.Agent, ctx context.Context) { errCh <- a.RunStream(ctx, input, inner) }(t.agents[owner], runCtx)
+		for m := range inner {
+			select {
+			case output <- withAgent(m, owner):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err := <-errCh; err != nil {
+			return err
+		}
+		target, reason := st.take()
+		if target == "" || hops >= t.maxHandoffs {
+			return nil
+		}
+		if err := t.handoff(ctx, session, owner, target, reason); err != nil {
+			return err
+		}
+		ev := core.Message{Role: "system", Meta: map[string]string{
+			"event":  HandoffEvent,
+			"from":   owner,
+			"to":     target,
+			"reason": reason,
+		}}
+		select {
+		case output <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		runCtx = t.continueCtx(ctx, owner, target)
+		owner = target
+	}
+}
+
+// continueCtx is the context the target of a handoff runs the turn in. When
+// both agents keep ChatAgent memory the input is already in the target's
+// history (shared store or copied), so it must not be stored twice.
+func (t *Team) continueCtx(ctx context.Context, from, to string) context.Context {
+	if hasMemory(t.agents[from].Agent) && hasMemory(t.agents[to].Agent) {
+		return core.WithInputStored(ctx)
+	}
+	return ctx
+}
+
+func hasMemory(a core.Agent) bool {
+	c, ok := a.(*core.ChatAgent)
+	return ok && c.Mem != nil
+}
+
+// handoff transfers ownership and history and records the transfer in tracing
+func (t *Team) handoff(ctx context.Context, session, from, to, reason string) error {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.handoff")
+	defer span.End()
+	span.SetAttribute(obs.AttrAgentName, to)
+	span.AddEvent(HandoffEvent, map[string]interface{}{"from": from, "to": to, "reason": reason, "session_id": session})
+
+	target, ok := t.agents[to]
+	if !ok {
+		err := fmt.Errorf("handoff to unknown agent %q", to)
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return err
+	}
+	if err := copyHistory(ctx, t.agents[from].Agent, target.Agent); err != nil {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return fmt.Errorf("handoff %s -> %s: %w", from, to, err)
+	}
+	t.setOwner(session, to)
+	span.SetStatus(obs.StatusCodeOk, "")
+	return nil
+}
+
+// copyHistory appends the session's messages the target has not seen to its
+// memory when the agents use different stores. The session comes from ctx, so
+// per-session and branching stores copy the right conversation, and the
+// target's earlier messages are kept.
+func copyHistory(ctx context.Context, from, to core.Agent) error {
+	src, ok1 := from.(*core.ChatAgent)
+	dst, ok2 := to.(*core.ChatAgent)
+	if !ok1 || !ok2 || src.Mem == nil || dst.Mem == nil || src.Mem == dst.Mem {
+		return nil
+	}
+	msgs, err := src.History(ctx)
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	have, err := dst.History(ctx)
+	if err != nil {
+		return fmt.Errorf("read target history: %w", err)
+	}
+	return dst.AppendHistory(ctx, msgs[overlap(have, msgs):]...)
+}
+
+// overlap returns the length of the longest prefix of msgs that have ends
+// with, i.e. the part of the conversation the target already holds (e.g.
+// when a session is handed back to an agent that owned it before)
+func overlap(have, msgs []core.Message) int {
+	for k := min(len(have), len(msgs)); k > 0; k-- {
+		tail := have[len(have)-k:]
+		same := true
+		for i := range tail {
+			if tail[i].Role != msgs[i].Role || tail[i].Content != msgs[i].Content {
+				same = false
+				break
+			}
+		}
+		if same {
+			return k
+		}
+	}
+	return 0
+}
+
+func withAgent(m core.Message, name string) core.Message {
+	meta := make(map[string]string, len(m.Meta)+1)
+	for k, v := range m.Meta {
+		meta[k] = v
+	}
+	meta["agent"] = name
+	m.Meta = meta
+	return m
+}
+
+var (
+	_ tools.Tool = (*HandoffTool)(nil)
+	_ core.Agent = (*Team)(nil)
+)
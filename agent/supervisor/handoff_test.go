@@ -0,0 +1,256 @@
+package supervisor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+	obs "github.com/KamdynS/go-agents/observability"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+// transferring returns an agent that hands off to target when the input contains trigger
+func transferring(reply, trigger, target string) *funcAgent {
+	tool := NewHandoffTool(target, "")
+	return &funcAgent{fn: func(ctx context.Context, in string) (string, error) {
+		if strings.Contains(in, trigger) {
+			if _, err := tool.Execute(ctx, `{"reason":"needs `+target+`"}`); err != nil {
+				return "", err
+			}
+			return "connecting you", nil
+		}
+		return reply, nil
+	}}
+}
+
+func TestTeam_HandoffOwnsFollowUps(t *testing.T) {
+	oldT := obs.TracerImpl
+	tracer := obs.NewDefaultTracer()
+	obs.TracerImpl = tracer
+	t.Cleanup(func() { obs.TracerImpl = oldT })
+
+	triage := transferring("triage here", "refund", "billing")
+	billing := answer("billing here")
+	team, err := NewTeam(TeamConfig{Agents: []NamedAgent{
+		Named("triage", "", triage),
+		Named("billing", "", billing),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := core.WithSessionID(context.Background(), "s1")
+	out, err := team.Run(ctx, core.Message{Role: "user", Content: "I want a refund"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if out.Content != "billing here" || out.Meta["agent"] != "billing" {
+		t.Fatalf("expected billing to answer after handoff, got %+v", out)
+	}
+	if team.Owner("s1") != "billing" || team.Owner("s2") != "triage" {
+		t.Fatalf("ownership should be per session")
+	}
+
+	// Follow-up goes straight to the new owner
+	if _, err := team.Run(ctx, core.Message{Role: "user", Content: "thanks"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(triage.inputs) != 1 || len(billing.inputs) != 2 {
+		t.Fatalf("unexpected call counts triage=%d billing=%d", len(triage.inputs), len(billing.inputs))
+	}
+
+	found := false
+	for _, s := range tracer.GetSpans() {
+		for _, e := range s.Events {
+			if s.Name == "supervisor.handoff" && e.Name == HandoffEvent && e.Attributes["to"] == "billing" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected handoff span event")
+	}
+
+	team.Reset("s1")
+	if team.Owner("s1") != "triage" {
+		t.Fatalf("reset should restore initial owner")
+	}
+}
+
+func TestTeam_StreamEmitsHandoffEvent(t *testing.T) {
+	team, err := NewTeam(TeamConfig{Agents: []NamedAgent{
+		Named("triage", "", transferring("", "bug", "tech")),
+		Named("tech", "", answer("tech here")),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan core.Message)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- team.RunStream(context.Background(), core.Message{Role: "user", Content: "bug report"}, out)
+	}()
+	var got []core.Message
+	for m := range out {
+		got = append(got, m)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if len(got) != 3 || got[1].Meta["event"] != HandoffEvent || got[1].Meta["to"] != "tech" || got[2].Meta["agent"] != "tech" {
+		t.Fatalf("unexpected stream: %+v", got)
+	}
+}
+
+// scriptLLM returns the scripted responses in order, repeating the last one
+type scriptLLM struct {
+	replyLLM
+	mu        sync.Mutex
+	responses []llm.Response
+}
+
+func (s *scriptLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	return &r, nil
+}
+
+// chatTeam builds a triage ChatAgent that hands off to a billing ChatAgent
+func chatTeam(t *testing.T, triageMem, billingMem memory.Store) (*Team, *core.ChatAgent) {
+	t.Helper()
+	reg := tools.NewRegistry()
+	if err := reg.Register(NewHandoffTool("billing", "")); err != nil {
+		t.Fatal(err)
+	}
+	triage := core.NewChatAgent(core.ChatConfig{Tools: reg, Mem: triageMem, Config: core.AgentConfig{MaxIterations: 3}, Model: &scriptLLM{responses: []llm.Response{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Type: "function", Function: llm.Function{Name: "transfer_to_billing", Arguments: `{}`}}}},
+		{Content: "connecting you"},
+	}}})
+	billing := core.NewChatAgent(core.ChatConfig{Mem: billingMem, Config: core.AgentConfig{MaxIterations: 3}, Model: &scriptLLM{responses: []llm.Response{{Content: "billing here"}}}})
+	team, err := NewTeam(TeamConfig{Agents: []NamedAgent{Named("triage", "", triage), Named("billing", "", billing)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return team, billing
+}
+
+func contents(msgs []core.Message) string {
+	parts := make([]string, len(msgs))
+	for i, m := range msgs {
+		parts[i] = m.Role + ":" + m.Content
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestTeam_SharedStoreStoresInputOnce(t *testing.T) {
+	team, billing := chatTeam(t, nil, nil)
+	store := inmemory.NewStore()
+	for _, a := range team.agents {
+		a.Agent.(*core.ChatAgent).Mem = store
+	}
+	if _, err := team.Run(context.Background(), core.Message{Role: "user", Content: "refund"}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := billing.History(context.Background())
+	if want := "user:refund | assistant:connecting you | assistant:billing here"; err != nil || contents(h) != want {
+		t.Fatalf("got %q (%v), want %q", contents(h), err, want)
+	}
+}
+
+func TestTeam_CopiesHistoryBetweenStores(t *testing.T) {
+	ctx := context.Background()
+	src := &core.ChatAgent{Mem: inmemory.NewStore()}
+	dst := &core.ChatAgent{Mem: inmemory.NewStore()}
+	_ = src.AppendHistory(ctx, core.Message{Role: "user", Content: "hi"}, core.Message{Role: "assistant", Content: "hello"})
+	_ = dst.AppendHistory(ctx, core.Message{Role: "user", Content: "earlier"}, core.Message{Role: "user", Content: "hi"})
+	if err := copyHistory(ctx, src, dst); err != nil {
+		t.Fatal(err)
+	}
+	// The target keeps its own messages and only receives what it has not seen
+	h, err := dst.History(ctx)
+	if want := "user:earlier | user:hi | assistant:hello"; err != nil || contents(h) != want {
+		t.Fatalf("got %q (%v), want %q", contents(h), err, want)
+	}
+}
+
+func TestTeam_CopiesSessionBetweenConversationStores(t *testing.T) {
+	triageMem, billingMem := inmemory.NewConversationStore(), inmemory.NewConversationStore()
+	team, billing := chatTeam(t, triageMem, billingMem)
+	ctx := core.WithSessionID(context.Background(), "s1")
+	out, err := team.Run(ctx, core.Message{Role: "user", Content: "refund"})
+	if err != nil || out.Meta["agent"] != "billing" {
+		t.Fatalf("unexpected reply %+v (%v)", out, err)
+	}
+	h, err := billing.History(ctx)
+	if want := "user:refund | assistant:connecting you | assistant:billing here"; err != nil || contents(h) != want {
+		t.Fatalf("got %q (%v), want %q", contents(h), err, want)
+	}
+	if other, _ := billing.History(core.WithSessionID(context.Background(), "s2")); len(other) != 0 {
+		t.Fatalf("other sessions should be untouched: %q", contents(other))
+	}
+}
+
+func TestTeam_BoundsSessionOwners(t *testing.T) {
+	team, err := NewTeam(TeamConfig{MaxSessions: 2, Agents: []NamedAgent{
+		Named("triage", "", transferring("", "refund", "billing")),
+		Named("billing", "", transferring("billing here", "back", "triage")),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"s1", "s2", "s3"} {
+		if _, err := team.Run(core.WithSessionID(context.Background(), s), core.Message{Role: "user", Content: "refund"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if team.Owner("s1") != "triage" || team.Owner("s2") != "billing" || team.Owner("s3") != "billing" {
+		t.Fatalf("least recently used session should be evicted")
+	}
+	// Handing back to the initial agent drops the entry
+	if _, err := team.Run(core.WithSessionID(context.Background(), "s3"), core.Message{Role: "user", Content: "back"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(team.owners) != 1 || team.Owner("s3") != "triage" {
+		t.Fatalf("expected s3 to be forgotten, owners: %d", len(team.owners))
+	}
+}
+
+func TestTeam_SessionlessRequestsStartAtInitial(t *testing.T) {
+	team, err := NewTeam(TeamConfig{Agents: []NamedAgent{
+		Named("triage", "", transferring("triage here", "refund", "billing")),
+		Named("billing", "", answer("billing here")),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := team.Run(context.Background(), core.Message{Role: "user", Content: "refund"})
+	if err != nil || out.Meta["agent"] != "billing" {
+		t.Fatalf("handoff should still apply within the turn: %+v %v", out, err)
+	}
+	// Another caller without a session is not rerouted by the first one's handoff
+	out, err = team.Run(context.Background(), core.Message{Role: "user", Content: "hello"})
+	if err != nil || out.Meta["agent"] != "triage" {
+		t.Fatalf("session-less request should start at triage: %+v %v", out, err)
+	}
+	if len(team.owners) != 0 {
+		t.Fatalf("no owner should be kept without a session, got %d", len(team.owners))
+	}
+}
+
+func TestHandoffTool_OutsideTeam(t *testing.T) {
+	if _, err := NewHandoffTool("x", "").Execute(context.Background(), "{}"); err == nil {
+		t.Fatalf("expected error outside a team")
+	}
+	if _, err := NewTeam(TeamConfig{Agents: []NamedAgent{Named("a", "", answer("")), Named("a", "", answer(""))}}); err == nil {
+		t.Fatalf("expected duplicate name error")
+	}
+}
@@ -0,0 +1,307 @@
+package supervisor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+var (
+	// ErrMaxDepth is returned when managers are nested deeper than MaxDepth
+	ErrMaxDepth = errors.New("manager depth limit reached")
+	// ErrManagerExhausted is returned when the planner never declares the work done
+	ErrManagerExhausted = errors.New("manager rounds exhausted")
+)
+
+// Plan is the structured output requested from the manager's model each round
+type Plan struct {
+	llm.BaseStructured
+	Done   bool       `json:"done"`
+	Answer string     `json:"answer,omitempty"`
+	Tasks  []PlanTask `json:"tasks,omitempty"`
+}
+
+// PlanTask assigns one subtask to a worker
+type PlanTask struct {
+	Agent string `json:"agent"`
+	Task  string `json:"task"`
+}
+
+func (p Plan) Validate() error {
+	if p.Done && p.Answer == "" {
+		return errors.New("answer is required when done")
+	}
+	if !p.Done && len(p.Tasks) == 0 {
+		return errors.New("tasks are required when not done")
+	}
+	return nil
+}
+
+func (p Plan) JSONSchema() map[string]interface{} { return planSchema(nil) }
+
+// planSchema restricts task agents to the given worker names when provided
+func planSchema(names []string) map[string]interface{} {
+	agent := map[string]interface{}{"type": "string"}
+	if len(names) > 0 {
+		agent["enum"] = names
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"done":   map[string]interface{}{"type": "boolean", "description": "True when the blackboard holds enough to answer"},
+			"answer": map[string]interface{}{"type": "string", "description": "Final answer, required when done"},
+			"tasks": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"agent": agent,
+						"task":  map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"agent", "task"},
+				},
+			},
+		},
+		"required": []string{"done"},
+	}
+}
+
+// ManagerPolicy decomposes the prompt into subtasks, runs them on worker agents
+// in parallel, and collects results on a Blackboard until the planner model is done.
+// Workers see finished results in their prompt and can use BlackboardTool to read
+// the board themselves. A worker may itself run a ManagerPolicy; MaxDepth bounds
+// that nesting.
+type ManagerPolicy struct {
+	Model llm.Client
+	// Store holds the blackboard; defaults to a fresh in-memory store per run.
+	// The blackboard key is deleted when Run returns.
+	Store memory.Store
+	// MaxRounds bounds plan/execute iterations (default 5)
+	MaxRounds int
+	// MaxDepth bounds nested managers (default 3)
+	MaxDepth     int
+	AgentTimeout time.Duration
+	// Instructions are appended to the planning system prompt
+	Instructions string
+}
+
+func (p ManagerPolicy) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	out, _, err := p.Run(ctx, prompt, agents)
+	return out, err
+}
+
+// Run executes the manager loop and also returns the final blackboard, which
+// is then removed from the store
+func (p ManagerPolicy) Run(ctx context.Context, prompt string, agents []core.Agent) (string, []Task, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.manager")
+	defer span.End()
+
+	maxDepth, maxRounds := p.MaxDepth, p.MaxRounds
+	if maxDepth <= 0 {
+		maxDepth = 3
+	}
+	if maxRounds <= 0 {
+		maxRounds = 5
+	}
+	depth := managerDepth(ctx)
+	span.SetAttribute("supervisor.depth", depth)
+	if depth >= maxDepth {
+		err := fmt.Errorf("%w (%d)", ErrMaxDepth, maxDepth)
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", nil, err
+	}
+	if p.Model == nil {
+		span.SetStatus(obs.StatusCodeError, "no model")
+		return "", nil, errors.New("manager policy requires a model")
+	}
+
+	workers := describe(agents)
+	byName := make(map[string]NamedAgent, len(workers))
+	names := make([]string, len(workers))
+	var roster strings.Builder
+	for i, w := range workers {
+		byName[w.Name] = w
+		names[i] = w.Name
+		fmt.Fprintf(&roster, "- %s: %s\n", w.Name, w.Description)
+	}
+	store := p.Store
+	if store == nil {
+		store = inmemory.NewStore()
+	}
+	board := NewBlackboard(store, newRunID())
+	span.SetAttribute("supervisor.blackboard", board.Key())
+	defer func() {
+		// The tasks are returned to the caller, so the key is not needed past the run
+		if err := board.Delete(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, memory.ErrNotFound) {
+			span.AddEvent("blackboard.delete_failed", map[string]interface{}{"error": err.Error()})
+		}
+	}()
+	ctx = WithBlackboard(ctx, board)
+
+	system := "You are a manager coordinating worker agents. Break the goal into subtasks, " +
+		"assign each to a worker, and read their results from the blackboard. " +
+		"When the blackboard holds enough to answer, set done=true and write the final answer.\nWorkers:\n" + roster.String()
+	if p.Instructions != "" {
+		system += "\n" + p.Instructions
+	}
+
+	fail := func(err error) (string, []Task, error) {
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		tasks, _ := board.Tasks(ctx)
+		return "", tasks, err
+	}
+
+	for round := 1; round <= maxRounds+1; round++ {
+		summary, err := board.Summary(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		user := fmt.Sprintf("Goal:\n%s\n\nBlackboard:\n%s\nRound %d of %d.", prompt, summary, round, maxRounds)
+		if round > maxRounds {
+			user += " No rounds remain: set done=true and give the best final answer now."
+		}
+		resp, err := llm.StructuredChat(ctx, p.Model, llm.StructuredRequest[Plan]{
+			SystemPrompt: system,
+			Messages:     []llm.Message{{Role: "user", Content: user}},
+			Schema:       planSchema(names),
+			OutputType:   Plan{},
+		})
+		if err != nil {
+			return fail(fmt.Errorf("plan round %d: %w", round, err))
+		}
+		plan := resp.Data
+		if plan.Done {
+			tasks, err := board.Tasks(ctx)
+			if err != nil {
+				return fail(err)
+			}
+			span.AddEvent("manager.done", map[string]interface{}{"rounds": round, "tasks": len(tasks)})
+			span.SetStatus(obs.StatusCodeOk, "")
+			return plan.Answer, tasks, nil
+		}
+		if round > maxRounds {
+			break
+		}
+		if err := p.runRound(ctx, board, byName, prompt, round, plan.Tasks); err != nil {
+			return fail(err)
+		}
+		tasks, err := board.Tasks(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		span.AddEvent("manager.round", map[string]interface{}{"round": round, "status": statusView(tasks)})
+	}
+	return fail(fmt.Errorf("%w after %d rounds", ErrManagerExhausted, maxRounds))
+}
+
+// runRound posts the planned tasks and runs them concurrently
+func (p ManagerPolicy) runRound(ctx context.Context, board *Blackboard, byName map[string]NamedAgent, goal string, round int, planned []PlanTask) error {
+	tasks := make([]Task, len(planned))
+	for i, pt := range planned {
+		tasks[i] = Task{ID: fmt.Sprintf("r%d-t%d", round, i+1), Round: round, Agent: pt.Agent, Description: pt.Task, Status: TaskPending}
+		if err := board.Put(ctx, tasks[i]); err != nil {
+			return err
+		}
+	}
+	summary, err := board.Summary(ctx)
+	if err != nil {
+		return err
+	}
+	wctx := context.WithValue(ctx, depthKey{}, managerDepth(ctx)+1)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(tasks))
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t Task) {
+			defer wg.Done()
+			errCh <- p.runTask(wctx, board, byName, goal, summary, t)
+		}(t)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTask executes one subtask on a supervisor.task span. Worker failures are
+// recorded on the blackboard for the planner; only blackboard errors are returned.
+func (p ManagerPolicy) runTask(ctx context.Context, board *Blackboard, byName map[string]NamedAgent, goal, summary string, t Task) error {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.task")
+	defer span.End()
+	span.SetAttribute("task.id", t.ID)
+	span.SetAttribute(obs.AttrAgentName, t.Agent)
+
+	setStatus := func(t Task) error {
+		span.SetAttribute("task.status", string(t.Status))
+		span.AddEvent("task.status", map[string]interface{}{"status": string(t.Status)})
+		return board.Put(ctx, t)
+	}
+
+	worker, ok := byName[t.Agent]
+	if !ok {
+		t.Status, t.Error = TaskFailed, "unknown agent"
+		span.SetStatus(obs.StatusCodeError, t.Error)
+		return setStatus(t)
+	}
+	t.Status = TaskRunning
+	if err := setStatus(t); err != nil {
+		return err
+	}
+
+	tctx := ctx
+	if p.AgentTimeout > 0 {
+		var cancel context.CancelFunc
+		tctx, cancel = context.WithTimeout(ctx, p.AgentTimeout)
+		defer cancel()
+	}
+	input := fmt.Sprintf("Overall goal:\n%s\n\nYour task (%s):\n%s\n\nBlackboard:\n%s", goal, t.ID, t.Description, summary)
+	out, err := (&AgentTool{NameStr: worker.Name, Agent: worker}).Execute(tctx, input)
+	if err != nil {
+		t.Status, t.Error = TaskFailed, err.Error()
+		span.SetStatus(obs.StatusCodeError, err.Error())
+	} else {
+		t.Status, t.Result = TaskDone, out
+		span.SetStatus(obs.StatusCodeOk, "")
+	}
+	return setStatus(t)
+}
+
+// statusView maps task ids to their status for tracing
+func statusView(tasks []Task) map[string]string {
+	out := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		out[t.ID] = t.Agent + ":" + string(t.Status)
+	}
+	return out
+}
+
+type depthKey struct{}
+
+func managerDepth(ctx context.Context) int {
+	d, _ := ctx.Value(depthKey{}).(int)
+	return d
+}
+
+func newRunID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+var _ Policy = ManagerPolicy{}
@@ -0,0 +1,139 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// seqLLM replies with the scripted contents in order, repeating the last one
+type seqLLM struct {
+	replyLLM
+	mu      sync.Mutex
+	replies []string
+	prompts []string
+}
+
+func (s *seqLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = append(s.prompts, req.Messages[len(req.Messages)-1].Content)
+	r := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	return &llm.Response{Content: r}, nil
+}
+
+func TestManagerPolicy_DecomposesAndCollects(t *testing.T) {
+	oldT := obs.TracerImpl
+	tracer := obs.NewDefaultTracer()
+	obs.TracerImpl = tracer
+	t.Cleanup(func() { obs.TracerImpl = oldT })
+
+	model := &seqLLM{replies: []string{
+		`{"done":false,"tasks":[{"agent":"research","task":"find facts"},{"agent":"math","task":"compute"}]}`,
+		`{"done":false,"tasks":[{"agent":"writer","task":"summarize"}]}`,
+		`{"done":true,"answer":"final report"}`,
+	}}
+	// The writer reads the board through the tool, as a ChatAgent would
+	writer := &funcAgent{fn: func(ctx context.Context, in string) (string, error) {
+		return BlackboardTool{}.Execute(ctx, "{}")
+	}}
+	store := inmemory.NewStore()
+	p := ManagerPolicy{Model: model, Store: store}
+	out, tasks, err := p.Run(context.Background(), "write a report", []core.Agent{
+		Named("research", "finds facts", answer("facts")),
+		Named("math", "computes", answer("42")),
+		Named("writer", "writes", writer),
+	})
+	if err != nil || out != "final report" {
+		t.Fatalf("unexpected result: %v %q", err, out)
+	}
+	if len(tasks) != 3 || tasks[0].Status != TaskDone || tasks[1].Result != "42" {
+		t.Fatalf("unexpected blackboard: %+v", tasks)
+	}
+	if !strings.Contains(tasks[2].Result, "result: facts") {
+		t.Fatalf("writer should see earlier results, got %q", tasks[2].Result)
+	}
+	if !strings.Contains(model.prompts[1], "[r1-t2] math (done)") {
+		t.Fatalf("planner should see the blackboard: %q", model.prompts[1])
+	}
+	if keys, _ := store.List(context.Background()); len(keys) != 0 {
+		t.Fatalf("blackboard should be deleted after the run: %v", keys)
+	}
+
+	taskSpans := 0
+	for _, s := range tracer.GetSpans() {
+		if s.Name == "supervisor.task" && s.Attributes["task.status"] == string(TaskDone) {
+			taskSpans++
+		}
+	}
+	if taskSpans != 3 {
+		t.Fatalf("expected 3 finished task spans, got %d", taskSpans)
+	}
+}
+
+func TestManagerPolicy_FailuresAndLimits(t *testing.T) {
+	failing := &funcAgent{fn: func(context.Context, string) (string, error) { return "", errors.New("down") }}
+	model := &seqLLM{replies: []string{
+		`{"done":false,"tasks":[{"agent":"w","task":"a"},{"agent":"ghost","task":"b"}]}`,
+		`{"done":true,"answer":"partial"}`,
+	}}
+	out, tasks, err := ManagerPolicy{Model: model}.Run(context.Background(), "goal", []core.Agent{Named("w", "", failing)})
+	if err != nil || out != "partial" {
+		t.Fatalf("worker failures should be reported to the planner, got %v %q", err, out)
+	}
+	if tasks[0].Status != TaskFailed || tasks[1].Error != "unknown agent" {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+
+	// The planner never finishes, even on the forced final round
+	never := &seqLLM{replies: []string{`{"done":false,"tasks":[{"agent":"w","task":"again"}]}`}}
+	_, _, err = ManagerPolicy{Model: never, MaxRounds: 2}.Run(context.Background(), "goal", []core.Agent{Named("w", "", answer("x"))})
+	if !errors.Is(err, ErrManagerExhausted) || len(never.prompts) != 3 {
+		t.Fatalf("expected exhaustion after 2 rounds plus final call, got %v (%d calls)", err, len(never.prompts))
+	}
+
+	// A nested manager beyond MaxDepth refuses to run
+	inner := &Supervisor{Policy: ManagerPolicy{Model: never, MaxDepth: 1}, Agents: []core.Agent{answer("x")}}
+	outer := &seqLLM{replies: []string{`{"done":false,"tasks":[{"agent":"sub","task":"nested"}]}`, `{"done":true,"answer":"ok"}`}}
+	_, tasks, err = ManagerPolicy{Model: outer, MaxDepth: 1}.Run(context.Background(), "goal", []core.Agent{Named("sub", "", inner)})
+	if err != nil || tasks[0].Status != TaskFailed || !strings.Contains(tasks[0].Error, ErrMaxDepth.Error()) {
+		t.Fatalf("expected nested manager to hit depth limit: %v %+v", err, tasks)
+	}
+}
+
+// memStore lets downStore embed the store without clashing with its Store method
+type memStore = inmemory.Store
+
+// downStore fails every read with a backend error
+type downStore struct{ *memStore }
+
+func (downStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestBlackboard_ReadErrorDoesNotOverwrite(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewStore()
+	b := NewBlackboard(store, "run1")
+	if err := b.Put(ctx, Task{ID: "t1", Description: "first"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	failing := NewBlackboard(downStore{store}, "run1")
+	if err := failing.Put(ctx, Task{ID: "t2"}); err == nil {
+		t.Fatal("expected the read error to be returned")
+	}
+	tasks, err := b.Tasks(ctx)
+	if err != nil || len(tasks) != 1 || tasks[0].ID != "t1" {
+		t.Fatalf("tasks were overwritten: %+v (%v)", tasks, err)
+	}
+}
@@ -0,0 +1,57 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+)
+
+// NamedAgent gives an agent a name and a description used by routing policies.
+// It implements core.Agent, so it can be passed anywhere a []core.Agent is expected.
+type NamedAgent struct {
+	Name        string
+	Description string
+	Agent       core.Agent
+}
+
+func (n NamedAgent) Run(ctx context.Context, input core.Message) (core.Message, error) {
+	if n.Agent == nil {
+		return core.Message{}, fmt.Errorf("nil agent %q", n.Name)
+	}
+	return n.Agent.Run(ctx, input)
+}
+
+func (n NamedAgent) RunStream(ctx context.Context, input core.Message, output chan<- core.Message) error {
+	if n.Agent == nil {
+		close(output)
+		return fmt.Errorf("nil agent %q", n.Name)
+	}
+	return n.Agent.RunStream(ctx, input, output)
+}
+
+// Named wraps an agent with a name and description
+func Named(name, description string, a core.Agent) NamedAgent {
+	return NamedAgent{Name: name, Description: description, Agent: a}
+}
+
+// describe returns a NamedAgent view for each agent; anonymous agents are named agent_<i>
+func describe(agents []core.Agent) []NamedAgent {
+	out := make([]NamedAgent, len(agents))
+	for i, a := range agents {
+		switch v := a.(type) {
+		case NamedAgent:
+			out[i] = v
+		case *NamedAgent:
+			out[i] = *v
+		default:
+			out[i] = NamedAgent{Name: fmt.Sprintf("agent_%d", i), Agent: a}
+		}
+		if out[i].Name == "" {
+			out[i].Name = fmt.Sprintf("agent_%d", i)
+		}
+	}
+	return out
+}
+
+var _ core.Agent = NamedAgent{}
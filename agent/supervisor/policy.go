@@ -2,9 +2,11 @@ package supervisor
 
 import (
 	"context"
-	"sync"
+	"fmt"
+	"time"
 
 	core "github.com/KamdynS/go-agents/agent/core"
+	obs "github.com/KamdynS/go-agents/observability"
 )
 
 // Policy defines how a supervisor coordinates agents.
@@ -28,36 +30,145 @@ func (SequentialPolicy) Execute(ctx context.Context, prompt string, agents []cor
 }
 
 // FanOutFirst wins: run all agents in parallel and return the first response.
-type FanOutFirst struct{}
+// The remaining agents are cancelled through a derived context as soon as a
+// winner is found. With HedgeAfter set, agents are started one at a time: the
+// next one is launched when the last one has not answered within HedgeAfter,
+// and right away whenever a running agent fails.
+type FanOutFirst struct {
+	// AgentTimeout bounds each agent's run; zero means only ctx applies
+	AgentTimeout time.Duration
+	// HedgeAfter enables hedged mode when > 0
+	HedgeAfter time.Duration
+}
+
+// FanOutStats describes how a FanOutFirst run was resolved
+type FanOutStats struct {
+	// Winner is the name of the agent whose answer was returned ("" on failure)
+	Winner      string
+	WinnerIndex int
+	// Started counts agents that were launched; in hedged mode this may be fewer than len(agents)
+	Started int
+	// Cancelled counts agents still running when the winner was found
+	Cancelled int
+	Failed    int
+	Latency   time.Duration
+}
+
+func (p FanOutFirst) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	out, _, err := p.Run(ctx, prompt, agents)
+	return out, err
+}
+
+// Run is Execute with accounting. Stats are also recorded on a supervisor.fanout span.
+func (p FanOutFirst) Run(ctx context.Context, prompt string, agents []core.Agent) (string, FanOutStats, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.fanout")
+	defer span.End()
+	start := time.Now()
+	stats := FanOutStats{WinnerIndex: -1}
+	named := describe(agents)
+	if len(named) == 0 {
+		// With no agents there is nothing to run, which is not an error
+		return "", stats, nil
+	}
+
+	// Losers are cancelled through this context once a winner is found
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-func (FanOutFirst) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
 	type res struct {
+		i   int
 		s   string
 		err error
 	}
-	ch := make(chan res, len(agents))
-	var wg sync.WaitGroup
-	for _, a := range agents {
-		wg.Add(1)
-		go func(ag core.Agent) {
-			defer wg.Done()
-			out, err := ag.Run(ctx, core.Message{Role: "user", Content: prompt})
-			if err != nil {
-				ch <- res{"", err}
-				return
+	// Buffered so losers never block after we stop reading
+	ch := make(chan res, len(named))
+	next, pending := 0, 0
+	var hedge *time.Timer
+	launch := func() {
+		i := next
+		next++
+		pending++
+		stats.Started++
+		go func() {
+			actx := ctx
+			if p.AgentTimeout > 0 {
+				var acancel context.CancelFunc
+				actx, acancel = context.WithTimeout(ctx, p.AgentTimeout)
+				defer acancel()
+			}
+			out, err := named[i].Run(actx, core.Message{Role: "user", Content: prompt})
+			if err == nil && actx.Err() != nil {
+				err = actx.Err()
+			}
+			ch <- res{i, out.Content, err}
+		}()
+		if p.HedgeAfter > 0 {
+			if hedge != nil {
+				hedge.Stop()
 			}
-			ch <- res{out.Content, nil}
-		}(a)
+			hedge = time.NewTimer(p.HedgeAfter)
+		}
+	}
+	defer func() {
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}()
+
+	if p.HedgeAfter > 0 {
+		launch()
+	} else {
+		for next < len(named) {
+			launch()
+		}
 	}
-	// Return first successful; if all fail, return last error
+
+	record := func() {
+		stats.Latency = time.Since(start)
+		span.AddEvent("fanout.result", map[string]interface{}{
+			"winner":    stats.Winner,
+			"started":   stats.Started,
+			"cancelled": stats.Cancelled,
+			"failed":    stats.Failed,
+		})
+	}
+	fail := func(err error) (string, FanOutStats, error) {
+		record()
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return "", stats, err
+	}
+
 	var lastErr error
-	for i := 0; i < len(agents); i++ {
-		r := <-ch
-		if r.err == nil {
-			return r.s, nil
+	for pending > 0 {
+		var hedgeC <-chan time.Time
+		if hedge != nil && next < len(named) {
+			hedgeC = hedge.C
+		}
+		select {
+		case r := <-ch:
+			pending--
+			if r.err != nil {
+				stats.Failed++
+				lastErr = fmt.Errorf("%s: %w", named[r.i].Name, r.err)
+				if next < len(named) {
+					// Replace the failed agent without waiting for the hedge delay
+					launch()
+				}
+				continue
+			}
+			cancel()
+			stats.Winner, stats.WinnerIndex, stats.Cancelled = named[r.i].Name, r.i, pending
+			record()
+			span.SetAttribute(obs.AttrAgentName, stats.Winner)
+			span.SetStatus(obs.StatusCodeOk, "")
+			return r.s, stats, nil
+		case <-hedgeC:
+			launch()
+		case <-ctx.Done():
+			stats.Cancelled = pending
+			return fail(ctx.Err())
 		}
-		lastErr = r.err
 	}
-	wg.Wait()
-	return "", lastErr
+	// Every agent failed; return the last error
+	return fail(lastErr)
 }
@@ -0,0 +1,150 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// RouteDecision is the structured output requested from the routing model
+type RouteDecision struct {
+	llm.BaseStructured
+	Agent      string  `json:"agent" description:"Name of the agent that should handle the request"`
+	Confidence float64 `json:"confidence" description:"Confidence between 0 and 1"`
+	Rationale  string  `json:"rationale,omitempty" description:"Short explanation of the choice"`
+}
+
+func (d RouteDecision) Validate() error {
+	if d.Agent == "" {
+		return errors.New("agent cannot be empty")
+	}
+	if d.Confidence < 0 || d.Confidence > 1 {
+		return fmt.Errorf("confidence must be between 0 and 1, got %f", d.Confidence)
+	}
+	return nil
+}
+
+func (d RouteDecision) JSONSchema() map[string]interface{} { return routeSchema(nil) }
+
+// routeSchema restricts the agent field to the given names when provided
+func routeSchema(names []string) map[string]interface{} {
+	agent := map[string]interface{}{"type": "string", "description": "Name of the agent that should handle the request"}
+	if len(names) > 0 {
+		agent["enum"] = names
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"agent":      agent,
+			"confidence": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+			"rationale":  map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"agent", "confidence"},
+	}
+}
+
+// RouterPolicy asks an LLM which specialist agent should handle the prompt and
+// runs only that agent. Pass NamedAgent values so the model sees descriptions.
+type RouterPolicy struct {
+	Model llm.Client
+	// MinConfidence routes to Default when the model is less confident than this
+	MinConfidence float64
+	// Default names the agent used when routing fails or is below MinConfidence.
+	// When empty those cases return an error.
+	Default string
+	// Instructions are appended to the routing system prompt
+	Instructions string
+}
+
+// Execute routes the prompt and returns the chosen agent's answer
+func (p RouterPolicy) Execute(ctx context.Context, prompt string, agents []core.Agent) (string, error) {
+	chosen, _, err := p.Route(ctx, prompt, agents)
+	if err != nil {
+		return "", err
+	}
+	out, err := chosen.Run(ctx, core.Message{Role: "user", Content: prompt})
+	if err != nil {
+		return "", err
+	}
+	return out.Content, nil
+}
+
+// Route picks an agent for the prompt without running it. The decision and its
+// rationale are recorded on a supervisor.route span.
+func (p RouterPolicy) Route(ctx context.Context, prompt string, agents []core.Agent) (NamedAgent, RouteDecision, error) {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "supervisor.route")
+	defer span.End()
+
+	named := describe(agents)
+	if len(named) == 0 {
+		span.SetStatus(obs.StatusCodeError, "no agents")
+		return NamedAgent{}, RouteDecision{}, errors.New("no agents to route to")
+	}
+	byName := make(map[string]NamedAgent, len(named))
+	for _, a := range named {
+		byName[a.Name] = a
+	}
+
+	fallback := func(reason string, decision RouteDecision, cause error) (NamedAgent, RouteDecision, error) {
+		span.AddEvent("route.fallback", map[string]interface{}{"reason": reason, "default": p.Default})
+		if def, ok := byName[p.Default]; ok {
+			span.SetAttribute(obs.AttrAgentName, def.Name)
+			span.SetStatus(obs.StatusCodeOk, "")
+			return def, decision, nil
+		}
+		err := fmt.Errorf("routing failed: %s", reason)
+		if cause != nil {
+			err = fmt.Errorf("routing failed: %s: %w", reason, cause)
+		}
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return NamedAgent{}, decision, err
+	}
+
+	if p.Model == nil {
+		return fallback("no routing model", RouteDecision{}, nil)
+	}
+
+	names := make([]string, len(named))
+	var b strings.Builder
+	for i, a := range named {
+		names[i] = a.Name
+		fmt.Fprintf(&b, "- %s: %s\n", a.Name, a.Description)
+	}
+	system := "You route user requests to the single best specialist agent. Available agents:\n" + b.String()
+	if p.Instructions != "" {
+		system += "\n" + p.Instructions
+	}
+	resp, err := llm.StructuredChat(ctx, p.Model, llm.StructuredRequest[RouteDecision]{
+		SystemPrompt: system,
+		Messages:     []llm.Message{{Role: "user", Content: prompt}},
+		Schema:       routeSchema(names),
+		OutputType:   RouteDecision{},
+	})
+	if err != nil {
+		return fallback("model error", RouteDecision{}, err)
+	}
+	decision := resp.Data
+	span.AddEvent("route.decision", map[string]interface{}{
+		"agent":      decision.Agent,
+		"confidence": decision.Confidence,
+		"rationale":  decision.Rationale,
+	})
+
+	chosen, ok := byName[decision.Agent]
+	if !ok {
+		return fallback(fmt.Sprintf("unknown agent %q", decision.Agent), decision, nil)
+	}
+	if decision.Confidence < p.MinConfidence {
+		return fallback(fmt.Sprintf("confidence %.2f below %.2f", decision.Confidence, p.MinConfidence), decision, nil)
+	}
+	span.SetAttribute(obs.AttrAgentName, chosen.Name)
+	span.SetStatus(obs.StatusCodeOk, "")
+	return chosen, decision, nil
+}
+
+var _ Policy = RouterPolicy{}
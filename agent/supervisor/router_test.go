@@ -0,0 +1,116 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// replyLLM answers every chat with a fixed content or error
+type replyLLM struct {
+	content string
+	err     error
+	last    *llm.ChatRequest
+}
+
+func (r *replyLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	r.last = req
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &llm.Response{Content: r.content}, nil
+}
+func (r *replyLLM) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return r.Chat(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: prompt}}})
+}
+func (r *replyLLM) Stream(ctx context.Context, req *llm.ChatRequest, out chan<- *llm.Response) error {
+	close(out)
+	return nil
+}
+func (r *replyLLM) Model() string          { return "mock" }
+func (r *replyLLM) Provider() llm.Provider { return llm.ProviderOpenAI }
+func (r *replyLLM) Validate() error        { return nil }
+
+func routedAgents() []core.Agent {
+	return []core.Agent{
+		Named("billing", "Invoices and refunds", fakeAgent{reply: "BILL"}),
+		Named("tech", "Technical support", fakeAgent{reply: "TECH"}),
+	}
+}
+
+func TestRouterPolicy_RoutesToChosenAgent(t *testing.T) {
+	oldT := obs.TracerImpl
+	tracer := obs.NewDefaultTracer()
+	obs.TracerImpl = tracer
+	t.Cleanup(func() { obs.TracerImpl = oldT })
+
+	model := &replyLLM{content: `{"agent":"tech","confidence":0.9,"rationale":"error message"}`}
+	p := RouterPolicy{Model: model, MinConfidence: 0.5}
+	out, err := p.Execute(context.Background(), "my app crashes", routedAgents())
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.HasPrefix(out, "TECH") {
+		t.Fatalf("expected tech agent, got %q", out)
+	}
+	if !strings.Contains(model.last.SystemPrompt, "billing: Invoices and refunds") {
+		t.Fatalf("routing prompt should list agent descriptions: %q", model.last.SystemPrompt)
+	}
+
+	spans := tracer.GetSpans()
+	found := false
+	for _, s := range spans {
+		if s.Name != "supervisor.route" {
+			continue
+		}
+		found = s.Attributes[obs.AttrAgentName] == "tech"
+		for _, e := range s.Events {
+			if e.Name == "route.decision" && e.Attributes["rationale"] != "error message" {
+				t.Fatalf("rationale not recorded: %+v", e)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected supervisor.route span with agent name, got %+v", spans)
+	}
+}
+
+func TestRouterPolicy_LowConfidenceUsesDefault(t *testing.T) {
+	model := &replyLLM{content: `{"agent":"tech","confidence":0.2}`}
+	p := RouterPolicy{Model: model, MinConfidence: 0.5, Default: "billing"}
+	out, err := p.Execute(context.Background(), "hmm", routedAgents())
+	if err != nil || !strings.HasPrefix(out, "BILL") {
+		t.Fatalf("expected default agent, got %v %q", err, out)
+	}
+
+	// Without a default the low-confidence decision is an error
+	p.Default = ""
+	if _, err := p.Execute(context.Background(), "hmm", routedAgents()); err == nil {
+		t.Fatalf("expected error without default")
+	}
+}
+
+func TestRouterPolicy_ModelErrorAndUnknownAgent(t *testing.T) {
+	p := RouterPolicy{Model: &replyLLM{err: errors.New("down")}, Default: "tech"}
+	out, err := p.Execute(context.Background(), "q", routedAgents())
+	if err != nil || !strings.HasPrefix(out, "TECH") {
+		t.Fatalf("expected default on model error, got %v %q", err, out)
+	}
+
+	p = RouterPolicy{Model: &replyLLM{content: `{"agent":"sales","confidence":1}`}}
+	if _, err := p.Execute(context.Background(), "q", routedAgents()); err == nil {
+		t.Fatalf("expected error for unknown agent")
+	}
+}
+
+func TestDescribe_AnonymousAgents(t *testing.T) {
+	named := describe([]core.Agent{fakeAgent{reply: "x"}, Named("n", "d", fakeAgent{})})
+	if named[0].Name != "agent_0" || named[1].Name != "n" {
+		t.Fatalf("unexpected names: %+v", named)
+	}
+}
@@ -35,4 +35,39 @@ func (a *AgentTool) Execute(ctx context.Context, input string) (string, error) {
 	return out.Content, nil
 }
 
-var _ tools.Tool = (*AgentTool)(nil)
+// Supervisor runs a Policy over a set of agents and is itself a core.Agent,
+// so supervisors can be nested as workers of other supervisors.
+type Supervisor struct {
+	Policy Policy
+	Agents []core.Agent
+}
+
+func (s *Supervisor) Run(ctx context.Context, input core.Message) (core.Message, error) {
+	if s.Policy == nil {
+		return core.Message{}, fmt.Errorf("nil policy")
+	}
+	out, err := s.Policy.Execute(ctx, input.Content, s.Agents)
+	if err != nil {
+		return core.Message{}, err
+	}
+	return core.Message{Role: "assistant", Content: out}, nil
+}
+
+func (s *Supervisor) RunStream(ctx context.Context, input core.Message, output chan<- core.Message) error {
+	defer close(output)
+	out, err := s.Run(ctx, input)
+	if err != nil {
+		return err
+	}
+	select {
+	case output <- out:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	_ tools.Tool = (*AgentTool)(nil)
+	_ core.Agent = (*Supervisor)(nil)
+)
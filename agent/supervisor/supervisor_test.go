@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	core "github.com/KamdynS/go-agents/agent/core"
 )
@@ -78,4 +79,70 @@ func TestFanOutFirst(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected error when all fail")
 	}
+
+	// no agents -> empty result, no error
+	if out, err := p.Execute(context.Background(), "q", nil); err != nil || out != "" {
+		t.Fatalf("expected empty result without agents, got %v %q", err, out)
+	}
+}
+
+func TestFanOutFirst_CancelsLosers(t *testing.T) {
+	cancelled := make(chan error, 1)
+	loser := &funcAgent{fn: func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return "", ctx.Err()
+	}}
+	out, stats, err := FanOutFirst{}.Run(context.Background(), "q", []core.Agent{loser, Named("fast", "", answer("OK"))})
+	if err != nil || out != "OK" {
+		t.Fatalf("unexpected result: %v %q", err, out)
+	}
+	if stats.Winner != "fast" || stats.WinnerIndex != 1 || stats.Started != 2 || stats.Cancelled != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("losing agent was not cancelled")
+	}
+}
+
+func TestFanOutFirst_AgentTimeout(t *testing.T) {
+	_, stats, err := FanOutFirst{AgentTimeout: 10 * time.Millisecond}.Run(context.Background(), "q", []core.Agent{slow(), slow()})
+	if !errors.Is(err, context.DeadlineExceeded) || stats.Failed != 2 {
+		t.Fatalf("expected per-agent deadlines to fail both, got %v %+v", err, stats)
+	}
+}
+
+func TestFanOutFirst_Hedged(t *testing.T) {
+	backup := answer("backup")
+	// A fast primary answers before the hedge delay, so the backup never starts
+	out, stats, err := FanOutFirst{HedgeAfter: time.Second}.Run(context.Background(), "q", []core.Agent{answer("primary"), backup})
+	if err != nil || out != "primary" || stats.Started != 1 || len(backup.inputs) != 0 {
+		t.Fatalf("backup should not start: %v %q %+v", err, out, stats)
+	}
+
+	// A stuck primary triggers the backup after the delay and is cancelled
+	out, stats, err = FanOutFirst{HedgeAfter: 10 * time.Millisecond}.Run(context.Background(), "q", []core.Agent{slow(), backup})
+	if err != nil || out != "backup" || stats.Started != 2 || stats.Cancelled != 1 {
+		t.Fatalf("expected hedged backup to win: %v %q %+v", err, out, stats)
+	}
+
+	// A failing primary launches the backup immediately
+	failing := &funcAgent{fn: func(context.Context, string) (string, error) { return "", errors.New("boom") }}
+	start := time.Now()
+	out, stats, err = FanOutFirst{HedgeAfter: time.Second}.Run(context.Background(), "q", []core.Agent{failing, backup})
+	if err != nil || out != "backup" || stats.Failed != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected immediate failover: %v %q %+v", err, out, stats)
+	}
+
+	// A hedge that fails while the primary is still stuck is replaced right away
+	start = time.Now()
+	out, stats, err = FanOutFirst{HedgeAfter: 200 * time.Millisecond}.Run(context.Background(), "q", []core.Agent{slow(), failing, backup})
+	if err != nil || out != "backup" || stats.Started != 3 || time.Since(start) > 350*time.Millisecond {
+		t.Fatalf("expected the failed hedge to be replaced immediately: %v %q %+v after %v", err, out, stats, time.Since(start))
+	}
 }
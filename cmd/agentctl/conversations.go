@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/file"
+)
+
+// conversations is what agentctl needs from a conversation store, either a
+// running server's /conversations endpoints or a local file store
+type conversations interface {
+	list(ctx context.Context) ([]memory.SessionInfo, error)
+	export(ctx context.Context, w io.Writer, sessions []string) error
+	importJSONL(ctx context.Context, r io.Reader) (memory.ImportStats, error)
+	search(ctx context.Context, query string, limit int) ([]memory.SearchHit, error)
+}
+
+func handleConversations() {
+	if err := runConversations(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+		fmt.Printf("conversations: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// sessionFlags collects repeated --session flags
+type sessionFlags []string
+
+func (s *sessionFlags) String() string     { return strings.Join(*s, ",") }
+func (s *sessionFlags) Set(v string) error { *s = append(*s, v); return nil }
+
+// runConversations implements `agentctl conversations <list|export|import|search>`
+func runConversations(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: agentctl conversations <list|export|import|search> [flags]")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("conversations "+sub, flag.ContinueOnError)
+	host := fs.String("host", "localhost:8080", "Host of the running server")
+	dir := fs.String("dir", "", "Read a local file store directory instead of a server")
+	var sessions sessionFlags
+	if sub == "export" {
+		fs.Var(&sessions, "session", "Session to export (repeatable; default all)")
+	}
+	limit := 0
+	if sub == "search" {
+		fs.IntVar(&limit, "limit", 20, "Maximum number of hits")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var src conversations = remoteConversations{base: "http://" + *host}
+	if *dir != "" {
+		// Only import writes; the other commands never modify the log and
+		// fail with file.ErrLocked while a server has the directory open
+		cs, err := file.NewConversationStore(file.Config{Dir: *dir, ReadOnly: sub != "import"})
+		if err != nil {
+			return err
+		}
+		defer cs.Close()
+		src = localConversations{cs}
+	}
+
+	ctx := context.Background()
+	switch sub {
+	case "list":
+		infos, err := src.list(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SESSION\tCREATED\tLAST ACTIVITY\tMESSAGES")
+		for _, s := range infos {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", s.ID, s.CreatedAt.Format(time.RFC3339), s.LastActivity.Format(time.RFC3339), s.Messages)
+		}
+		return tw.Flush()
+	case "export":
+		return src.export(ctx, stdout, sessions)
+	case "import":
+		in := stdin
+		if path := fs.Arg(0); path != "" && path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		stats, err := src.importJSONL(ctx, in)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "imported %d messages in %d sessions\n", stats.Messages, stats.Sessions)
+		return nil
+	case "search":
+		query := strings.Join(fs.Args(), " ")
+		if query == "" {
+			return errors.New("search needs a query")
+		}
+		hits, err := src.search(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		for _, h := range hits {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", h.SessionID, h.MessageID, h.Role, snippet(h.Content, 80))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown subcommand %q (want list, export, import or search)", sub)
+	}
+}
+
+// snippet shortens content to one line of at most n runes
+func snippet(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// localConversations reads a memory/file conversation store directly
+type localConversations struct{ cs memory.ConversationStore }
+
+func (l localConversations) list(ctx context.Context) ([]memory.SessionInfo, error) {
+	return memory.ListSessions(ctx, l.cs)
+}
+
+func (l localConversations) export(ctx context.Context, w io.Writer, sessions []string) error {
+	return memory.Export(ctx, l.cs, w, sessions...)
+}
+
+func (l localConversations) importJSONL(ctx context.Context, r io.Reader) (memory.ImportStats, error) {
+	return memory.Import(ctx, l.cs, r)
+}
+
+func (l localConversations) search(ctx context.Context, query string, limit int) ([]memory.SearchHit, error) {
+	return memory.Search(ctx, l.cs, query, limit)
+}
+
+// remoteConversations talks to the /conversations endpoints of a running server
+type remoteConversations struct{ base string }
+
+func (r remoteConversations) do(ctx context.Context, method, path string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, r.base+path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if w, ok := out.(io.Writer); ok {
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (r remoteConversations) list(ctx context.Context) ([]memory.SessionInfo, error) {
+	var out struct {
+		Sessions []memory.SessionInfo `json:"sessions"`
+	}
+	err := r.do(ctx, http.MethodGet, "/conversations", nil, &out)
+	return out.Sessions, err
+}
+
+func (r remoteConversations) export(ctx context.Context, w io.Writer, sessions []string) error {
+	path := "/conversations/export"
+	if len(sessions) > 0 {
+		path += "?" + url.Values{"session": sessions}.Encode()
+	}
+	return r.do(ctx, http.MethodGet, path, nil, w)
+}
+
+func (r remoteConversations) importJSONL(ctx context.Context, in io.Reader) (memory.ImportStats, error) {
+	// Read the whole input so the request has a length and can be retried by proxies
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return memory.ImportStats{}, err
+	}
+	var out struct {
+		Imported memory.ImportStats `json:"imported"`
+	}
+	err = r.do(ctx, http.MethodPost, "/conversations/import", bytes.NewReader(b), &out)
+	return out.Imported, err
+}
+
+func (r remoteConversations) search(ctx context.Context, query string, limit int) ([]memory.SearchHit, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", fmt.Sprint(limit))
+	}
+	var out struct {
+		Hits []memory.SearchHit `json:"hits"`
+	}
+	err := r.do(ctx, http.MethodGet, "/conversations/search?"+q.Encode(), nil, &out)
+	return out.Hits, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/memory/file"
+)
+
+func TestConversationsLocalRoundTrip(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	cs, err := file.NewConversationStore(file.Config{Dir: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_ = cs.AppendMessage(ctx, "s1", "user", "deploy the staging cluster")
+	_ = cs.AppendMessage(ctx, "s2", "user", "hello")
+	_ = cs.Close()
+
+	var out bytes.Buffer
+	if err := runConversations([]string{"list", "--dir", src}, nil, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(out.String(), "SESSION") || !strings.Contains(out.String(), "s1") || !strings.Contains(out.String(), "s2") {
+		t.Fatalf("list output:\n%s", out.String())
+	}
+
+	var export bytes.Buffer
+	if err := runConversations([]string{"export", "--dir", src, "--session", "s1"}, nil, &export); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	out.Reset()
+	if err := runConversations([]string{"import", "--dir", dst}, &export, &out); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if out.String() != "imported 1 messages in 1 sessions\n" {
+		t.Fatalf("import output: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runConversations([]string{"search", "--dir", dst, "STAGING", "deploy"}, nil, &out); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "s1") {
+		t.Fatalf("search output: %q", out.String())
+	}
+
+	if err := runConversations([]string{"bogus", "--dir", dst}, nil, &out); err == nil {
+		t.Fatal("expected error for unknown subcommand")
+	}
+}
+
+func TestConversationsLocalRefusesDirInUse(t *testing.T) {
+	dir := t.TempDir()
+	cs, err := file.NewConversationStore(file.Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	var out bytes.Buffer
+	if err := runConversations([]string{"list", "--dir", dir}, nil, &out); !errors.Is(err, file.ErrLocked) {
+		t.Fatalf("expected file.ErrLocked while a writer has the directory, got %v", err)
+	}
+}
+
+func TestConversationsRemote(t *testing.T) {
+	var imported string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/conversations/export":
+			if got := r.URL.Query()["session"]; len(got) != 2 {
+				t.Errorf("sessions not forwarded: %v", got)
+			}
+			_, _ = io.WriteString(w, `{"session_id":"a","id":"m1","role":"user","content":"hi"}`+"\n")
+		case "/conversations/import":
+			b, _ := io.ReadAll(r.Body)
+			imported = string(b)
+			_, _ = io.WriteString(w, `{"imported":{"sessions":1,"messages":1}}`)
+		case "/conversations/search":
+			if r.URL.Query().Get("q") != "hello world" || r.URL.Query().Get("limit") != "5" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"hits":[{"session_id":"a","message_id":"m1","role":"user","content":"hello world"}]}`)
+		default:
+			http.Error(w, "nope", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	var out bytes.Buffer
+	if err := runConversations([]string{"export", "--host", host, "--session", "a", "--session", "b"}, nil, &out); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	export := out.String()
+	out.Reset()
+	if err := runConversations([]string{"import", "--host", host}, strings.NewReader(export), &out); err != nil || imported != export {
+		t.Fatalf("import: %v (server got %q)", err, imported)
+	}
+	out.Reset()
+	if err := runConversations([]string{"search", "--host", host, "--limit", "5", "hello", "world"}, nil, &out); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(out.String(), "hello world") {
+		t.Fatalf("search output: %q", out.String())
+	}
+	if err := runConversations([]string{"list", "--host", host}, nil, &out); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
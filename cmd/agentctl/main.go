@@ -24,6 +24,8 @@ func main() {
 		handleInit()
 	case "graph":
 		handleGraph()
+	case "conversations":
+		handleConversations()
 	case "version":
 		handleVersion()
 	case "help":
@@ -40,6 +42,8 @@ func printUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  agentctl init [project-name]  Initialize a new agent project (use --type minimal|basic|rag|multi-agent)")
 	fmt.Println("  agentctl graph --name <workflow> [--host localhost:8080] [--dir TD|LR] [--conds]")
+	fmt.Println("  agentctl conversations list|export|import|search [--host localhost:8080 | --dir <file store>]")
+	fmt.Println("                                List, export/import (JSONL) or search stored conversations")
 	fmt.Println("  agentctl version              Show version information")
 	fmt.Println("  agentctl help                 Show this help message")
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	srv "github.com/KamdynS/go-agents/server/http"
+	"github.com/KamdynS/go-agents/tools"
+)
+
+// Built holds the components assembled from a Config
+type Built struct {
+	Model        llm.Client
+	Tools        tools.Registry
+	Memory       memory.Store
+	Agent        *core.ChatAgent
+	ServerConfig srv.Config
+}
+
+// NewServer wraps the built agent in the reference HTTP server
+func (b *Built) NewServer() *srv.Server {
+	return srv.NewServer(b.Agent, b.ServerConfig)
+}
+
+// Build validates the config and assembles a ready-to-serve agent. Factory
+// failures are reported as ValidationErrors pointing at the offending block.
+func (c *Config) Build() (*Built, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	var errs ValidationErrors
+	out := &Built{}
+
+	model := c.Model
+	if model.APIKey == "" && model.APIKeyEnv != "" {
+		model.APIKey = os.Getenv(model.APIKeyEnv)
+	}
+	pf, _ := lookupProvider(model.Provider)
+	client, err := pf(model)
+	if err != nil {
+		errs.add("model", "%v", err)
+	}
+	out.Model = client
+
+	reg := tools.NewRegistry()
+	for i, t := range c.Tools {
+		tf, _ := lookupTool(t.Name)
+		tool, err := tf(t.Options)
+		if err != nil {
+			errs.add(fmt.Sprintf("tools[%d].options", i), "%v", err)
+			continue
+		}
+		if err := reg.Register(tool); err != nil {
+			errs.add(fmt.Sprintf("tools[%d].name", i), "%v", err)
+		}
+	}
+	out.Tools = reg
+
+	if c.Memory != nil {
+		mf, _ := lookupMemory(c.Memory.Backend)
+		store, err := mf(c.Memory.Options)
+		if err != nil {
+			errs.add("memory.options", "%v", err)
+		}
+		out.Memory = store
+	}
+
+	var processors []core.MemoryProcessor
+	for i, p := range c.Processors {
+		pf, _ := lookupProcessor(p.Type)
+		proc, err := pf(p.Options)
+		if err != nil {
+			errs.add(fmt.Sprintf("processors[%d].options", i), "%v", err)
+			continue
+		}
+		processors = append(processors, proc)
+	}
+
+	var mw []core.Middleware
+	if g := c.Guardrails; g != nil {
+		mw = append(mw, &core.SimpleGuardrails{
+			DenySubstrings:  g.Deny,
+			AllowSubstrings: g.Allow,
+			MaxInputChars:   g.MaxInputChars,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	policy := core.ExhaustionPolicy(c.Agent.OnMaxIterations)
+	if policy == "return_last" {
+		policy = core.ExhaustReturnLast
+	}
+	chat := core.ChatConfig{
+		Model: client,
+		Tools: reg,
+		Mem:   out.Memory,
+		Config: core.AgentConfig{
+			SystemPrompt:    c.Agent.SystemPrompt,
+			MaxIterations:   c.Agent.MaxIterations,
+			Timeout:         c.Agent.Timeout,
+			OnMaxIterations: policy,
+		},
+		Processors: processors,
+		Middleware: mw,
+	}
+	out.Agent = core.NewChatAgent(chat)
+
+	out.ServerConfig = srv.Config{
+		Port:                c.Server.Port,
+		ReadTimeout:         parseDuration(c.Server.ReadTimeout),
+		WriteTimeout:        parseDuration(c.Server.WriteTimeout),
+		RequestTimeout:      parseDuration(c.Server.RequestTimeout),
+		MaxRequestBodyBytes: c.Server.MaxRequestBodyBytes,
+	}
+	return out, nil
+}
+
+// Load reads, validates and builds a config file in one step
+func Load(path string) (*Built, error) {
+	cfg, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Build()
+}
@@ -0,0 +1,272 @@
+// Package config loads declarative agent definitions from YAML or JSON and
+// builds ready-to-serve agents from them, so prompts, tools and limits can be
+// changed without a code change.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies the encoding of a config document
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Config is the root of a declarative agent definition
+type Config struct {
+	Model      ModelConfig       `json:"model" yaml:"model"`
+	Agent      AgentSection      `json:"agent" yaml:"agent"`
+	Tools      []ToolConfig      `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Memory     *MemoryConfig     `json:"memory,omitempty" yaml:"memory,omitempty"`
+	Processors []ProcessorConfig `json:"processors,omitempty" yaml:"processors,omitempty"`
+	Guardrails *GuardrailsConfig `json:"guardrails,omitempty" yaml:"guardrails,omitempty"`
+	Server     ServerConfig      `json:"server,omitempty" yaml:"server,omitempty"`
+}
+
+// ModelConfig selects the LLM provider and model
+type ModelConfig struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	// APIKey is used verbatim; prefer APIKeyEnv to keep secrets out of config files
+	APIKey      string       `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+	APIKeyEnv   string       `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+	BaseURL     string       `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	Temperature float64      `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	MaxTokens   int          `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	Timeout     string       `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retry       *RetryConfig `json:"retry,omitempty" yaml:"retry,omitempty"`
+}
+
+// RetryConfig mirrors llm.RetryConfig with string durations
+type RetryConfig struct {
+	MaxRetries    int     `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	InitialDelay  string  `json:"initial_delay,omitempty" yaml:"initial_delay,omitempty"`
+	MaxDelay      string  `json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+	BackoffFactor float64 `json:"backoff_factor,omitempty" yaml:"backoff_factor,omitempty"`
+}
+
+// AgentSection maps onto core.AgentConfig
+type AgentSection struct {
+	SystemPrompt    string `json:"system_prompt" yaml:"system_prompt"`
+	MaxIterations   int    `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	Timeout         string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	OnMaxIterations string `json:"on_max_iterations,omitempty" yaml:"on_max_iterations,omitempty"`
+}
+
+// ToolConfig enables a tool by its registered factory name
+type ToolConfig struct {
+	Name    string                 `json:"name" yaml:"name"`
+	Options map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// MemoryConfig selects a registered memory backend
+type MemoryConfig struct {
+	Backend string                 `json:"backend" yaml:"backend"`
+	Options map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// ProcessorConfig enables a registered memory processor
+type ProcessorConfig struct {
+	Type    string                 `json:"type" yaml:"type"`
+	Options map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// GuardrailsConfig maps onto core.SimpleGuardrails
+type GuardrailsConfig struct {
+	Deny          []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+	Allow         []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	MaxInputChars int      `json:"max_input_chars,omitempty" yaml:"max_input_chars,omitempty"`
+}
+
+// ServerConfig maps onto server/http.Config
+type ServerConfig struct {
+	Port                int    `json:"port,omitempty" yaml:"port,omitempty"`
+	ReadTimeout         string `json:"read_timeout,omitempty" yaml:"read_timeout,omitempty"`
+	WriteTimeout        string `json:"write_timeout,omitempty" yaml:"write_timeout,omitempty"`
+	RequestTimeout      string `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"`
+	MaxRequestBodyBytes int64  `json:"max_request_body_bytes,omitempty" yaml:"max_request_body_bytes,omitempty"`
+}
+
+// LoadFile reads a config file, choosing the format from its extension (.yaml, .yml or .json)
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+	return Parse(data, format)
+}
+
+// Parse decodes a config document. Unknown fields are rejected.
+func Parse(data []byte, format Format) (*Config, error) {
+	var cfg Config
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("decode json config: %w", err)
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("decode yaml config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+	return &cfg, nil
+}
+
+// ValidationError points at the offending field using a dotted path, e.g. "tools[1].name"
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (v ValidationError) Error() string { return v.Field + ": " + v.Message }
+
+// ValidationErrors collects every problem found in a config
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	parts := make([]string, len(v))
+	for i, e := range v {
+		parts[i] = e.Error()
+	}
+	return "invalid config: " + strings.Join(parts, "; ")
+}
+
+func (v *ValidationErrors) add(field, format string, args ...interface{}) {
+	*v = append(*v, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *ValidationErrors) duration(field, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil {
+		v.add(field, "invalid duration %q", value)
+	} else if d < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+// Validate checks the config against the registered factories. The returned
+// error is ValidationErrors when any field is invalid.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+
+	// model
+	if c.Model.Provider == "" {
+		errs.add("model.provider", "is required")
+	} else if _, ok := lookupProvider(c.Model.Provider); !ok {
+		errs.add("model.provider", "unknown provider %q (registered: %s)", c.Model.Provider, strings.Join(Providers(), ", "))
+	}
+	if c.Model.APIKey == "" && c.Model.APIKeyEnv != "" && os.Getenv(c.Model.APIKeyEnv) == "" {
+		errs.add("model.api_key_env", "environment variable %s is not set", c.Model.APIKeyEnv)
+	}
+	if c.Model.Temperature < 0 || c.Model.Temperature > 2 {
+		errs.add("model.temperature", "must be between 0 and 2")
+	}
+	if c.Model.MaxTokens < 0 {
+		errs.add("model.max_tokens", "must not be negative")
+	}
+	errs.duration("model.timeout", c.Model.Timeout)
+	if r := c.Model.Retry; r != nil {
+		if r.MaxRetries < 0 {
+			errs.add("model.retry.max_retries", "must not be negative")
+		}
+		if r.BackoffFactor != 0 && r.BackoffFactor < 1 {
+			errs.add("model.retry.backoff_factor", "must be at least 1")
+		}
+		errs.duration("model.retry.initial_delay", r.InitialDelay)
+		errs.duration("model.retry.max_delay", r.MaxDelay)
+	}
+
+	// agent
+	if c.Agent.MaxIterations < 0 {
+		errs.add("agent.max_iterations", "must not be negative")
+	}
+	errs.duration("agent.timeout", c.Agent.Timeout)
+	switch c.Agent.OnMaxIterations {
+	case "", "return_last", "force_final", "error", "fallback":
+	default:
+		errs.add("agent.on_max_iterations", "must be one of return_last, force_final, error, fallback")
+	}
+	if c.Agent.OnMaxIterations == "fallback" {
+		errs.add("agent.on_max_iterations", "fallback requires a fallback agent and cannot be configured declaratively")
+	}
+
+	// tools
+	seen := map[string]int{}
+	for i, t := range c.Tools {
+		field := fmt.Sprintf("tools[%d].name", i)
+		switch {
+		case t.Name == "":
+			errs.add(field, "is required")
+		case seen[t.Name] > 0:
+			errs.add(field, "duplicate tool %q (also tools[%d])", t.Name, seen[t.Name]-1)
+		default:
+			if _, ok := lookupTool(t.Name); !ok {
+				errs.add(field, "unknown tool %q (registered: %s)", t.Name, strings.Join(Tools(), ", "))
+			}
+		}
+		seen[t.Name] = i + 1
+	}
+
+	// memory
+	if m := c.Memory; m != nil {
+		if m.Backend == "" {
+			errs.add("memory.backend", "is required")
+		} else if _, ok := lookupMemory(m.Backend); !ok {
+			errs.add("memory.backend", "unknown backend %q (registered: %s)", m.Backend, strings.Join(MemoryBackends(), ", "))
+		}
+	}
+
+	// processors
+	for i, p := range c.Processors {
+		field := fmt.Sprintf("processors[%d].type", i)
+		if p.Type == "" {
+			errs.add(field, "is required")
+		} else if _, ok := lookupProcessor(p.Type); !ok {
+			errs.add(field, "unknown processor %q (registered: %s)", p.Type, strings.Join(Processors(), ", "))
+		}
+	}
+
+	// guardrails
+	if g := c.Guardrails; g != nil && g.MaxInputChars < 0 {
+		errs.add("guardrails.max_input_chars", "must not be negative")
+	}
+
+	// server
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		errs.add("server.port", "must be between 0 and 65535")
+	}
+	errs.duration("server.read_timeout", c.Server.ReadTimeout)
+	errs.duration("server.write_timeout", c.Server.WriteTimeout)
+	errs.duration("server.request_timeout", c.Server.RequestTimeout)
+	if c.Server.MaxRequestBodyBytes < 0 {
+		errs.add("server.max_request_body_bytes", "must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
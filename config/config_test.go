@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+)
+
+type stubClient struct{ model string }
+
+func (s stubClient) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	return &llm.Response{Content: "stub:" + req.Messages[0].Content, Model: s.model}, nil
+}
+func (s stubClient) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return &llm.Response{Content: prompt}, nil
+}
+func (s stubClient) Stream(ctx context.Context, req *llm.ChatRequest, out chan<- *llm.Response) error {
+	close(out)
+	return nil
+}
+func (s stubClient) Model() string          { return s.model }
+func (s stubClient) Provider() llm.Provider { return llm.Provider("stub") }
+func (s stubClient) Validate() error        { return nil }
+
+func init() {
+	_ = RegisterProvider("stub", func(cfg ModelConfig) (llm.Client, error) {
+		return stubClient{model: cfg.Name}, nil
+	})
+}
+
+const sampleYAML = `
+model:
+  provider: stub
+  name: stub-1
+  timeout: 5s
+  retry:
+    max_retries: 2
+    initial_delay: 100ms
+agent:
+  system_prompt: You are terse.
+  max_iterations: 3
+  on_max_iterations: force_final
+tools:
+  - name: calculator
+  - name: http_request
+    options:
+      timeout: 2s
+memory:
+  backend: inmemory
+processors:
+  - type: token_limiter
+    options:
+      max_chars: 4000
+  - type: tool_call_filter
+guardrails:
+  deny: ["secret"]
+server:
+  port: 9091
+  request_timeout: 30s
+`
+
+func TestParseAndBuild_YAML(t *testing.T) {
+	cfg, err := Parse([]byte(sampleYAML), FormatYAML)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	built, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if built.Agent.Config.OnMaxIterations != core.ExhaustForceFinal || built.Agent.Config.MaxIterations != 3 {
+		t.Fatalf("unexpected agent config: %+v", built.Agent.Config)
+	}
+	if len(built.Tools.List()) != 2 || built.Memory == nil {
+		t.Fatalf("expected tools and memory to be wired")
+	}
+	if built.ServerConfig.Port != 9091 || built.ServerConfig.RequestTimeout.String() != "30s" {
+		t.Fatalf("unexpected server config: %+v", built.ServerConfig)
+	}
+	if built.NewServer() == nil {
+		t.Fatalf("expected server")
+	}
+	out, err := built.Agent.Run(context.Background(), core.Message{Role: "user", Content: "hi"})
+	if err != nil || out.Content != "stub:You are terse." {
+		t.Fatalf("unexpected run: %v %q", err, out.Content)
+	}
+	// Guardrails are wired as middleware
+	if _, err := built.Agent.Run(context.Background(), core.Message{Role: "user", Content: "tell me the secret"}); err == nil {
+		t.Fatalf("expected guardrails to block")
+	}
+}
+
+func TestLoadFile_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	doc := `{"model":{"provider":"stub"},"agent":{"system_prompt":"sys"}}`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	built, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if built.Agent.Mem != nil {
+		t.Fatalf("memory should be nil when not configured")
+	}
+}
+
+func TestParse_RejectsUnknownFields(t *testing.T) {
+	if _, err := Parse([]byte("model:\n  provider: stub\n  modle: x\n"), FormatYAML); err == nil {
+		t.Fatalf("expected unknown field error for yaml")
+	}
+	if _, err := Parse([]byte(`{"model":{"provider":"stub"},"agnt":{}}`), FormatJSON); err == nil {
+		t.Fatalf("expected unknown field error for json")
+	}
+}
+
+func TestValidate_PointsToFields(t *testing.T) {
+	cfg := &Config{
+		Model:      ModelConfig{Provider: "nope", Timeout: "soon", APIKeyEnv: "GO_AGENTS_TEST_UNSET_KEY"},
+		Agent:      AgentSection{MaxIterations: -1, OnMaxIterations: "panic"},
+		Tools:      []ToolConfig{{Name: "calculator"}, {Name: "calculator"}, {Name: "missing"}},
+		Memory:     &MemoryConfig{Backend: "etcd"},
+		Processors: []ProcessorConfig{{Type: ""}},
+		Server:     ServerConfig{Port: 70000, ReadTimeout: "1 minute"},
+	}
+	err := cfg.Validate()
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %T %v", err, err)
+	}
+	want := []string{
+		"model.provider", "model.api_key_env", "model.timeout",
+		"agent.max_iterations", "agent.on_max_iterations",
+		"tools[1].name", "tools[2].name", "memory.backend",
+		"processors[0].type", "server.port", "server.read_timeout",
+	}
+	got := map[string]bool{}
+	for _, e := range verrs {
+		got[e.Field] = true
+	}
+	for _, f := range want {
+		if !got[f] {
+			t.Errorf("missing validation error for %s in %v", f, err)
+		}
+	}
+}
+
+func TestBuild_FactoryErrorsPointToOptions(t *testing.T) {
+	cfg := &Config{
+		Model:      ModelConfig{Provider: "stub"},
+		Processors: []ProcessorConfig{{Type: "token_limiter", Options: map[string]interface{}{"max_chars": "lots"}}},
+	}
+	_, err := cfg.Build()
+	if err == nil || !strings.Contains(err.Error(), "processors[0].options") {
+		t.Fatalf("expected processor options error, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/llm/anthropic"
+	"github.com/KamdynS/go-agents/llm/openai"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+	"github.com/KamdynS/go-agents/tools"
+	httptool "github.com/KamdynS/go-agents/tools/http"
+)
+
+// ProviderFactory builds an LLM client. The API key is already resolved from api_key_env.
+type ProviderFactory func(cfg ModelConfig) (llm.Client, error)
+
+// ToolFactory builds a tool from its options block
+type ToolFactory func(opts map[string]interface{}) (tools.Tool, error)
+
+// MemoryFactory builds a memory backend from its options block
+type MemoryFactory func(opts map[string]interface{}) (memory.Store, error)
+
+// ProcessorFactory builds a memory processor from its options block
+type ProcessorFactory func(opts map[string]interface{}) (core.MemoryProcessor, error)
+
+// Global registries for factories referenced by name from config files
+var (
+	regMu        sync.RWMutex
+	providerReg  = map[string]ProviderFactory{}
+	toolReg      = map[string]ToolFactory{}
+	memoryReg    = map[string]MemoryFactory{}
+	processorReg = map[string]ProcessorFactory{}
+)
+
+// RegisterProvider adds an LLM provider factory. Returns error if the name already exists.
+func RegisterProvider(name string, f ProviderFactory) error {
+	return register(providerReg, name, f)
+}
+
+// RegisterTool adds a tool factory. Returns error if the name already exists.
+func RegisterTool(name string, f ToolFactory) error {
+	return register(toolReg, name, f)
+}
+
+// RegisterMemory adds a memory backend factory. Returns error if the name already exists.
+func RegisterMemory(name string, f MemoryFactory) error {
+	return register(memoryReg, name, f)
+}
+
+// RegisterProcessor adds a memory processor factory. Returns error if the name already exists.
+func RegisterProcessor(name string, f ProcessorFactory) error {
+	return register(processorReg, name, f)
+}
+
+// Providers returns sorted registered provider names.
+func Providers() []string { return names(providerReg) }
+
+// Tools returns sorted registered tool names.
+func Tools() []string { return names(toolReg) }
+
+// MemoryBackends returns sorted registered memory backend names.
+func MemoryBackends() []string { return names(memoryReg) }
+
+// Processors returns sorted registered processor names.
+func Processors() []string { return names(processorReg) }
+
+func register[F any](reg map[string]F, name string, f F) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	regMu.Lock()
+	defer regMu.Unlock()
+	if _, exists := reg[name]; exists {
+		return fmt.Errorf("%s already registered", name)
+	}
+	reg[name] = f
+	return nil
+}
+
+func lookup[F any](reg map[string]F, name string) (F, bool) {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	f, ok := reg[name]
+	return f, ok
+}
+
+func names[F any](reg map[string]F) []string {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	out := make([]string, 0, len(reg))
+	for k := range reg {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func lookupProvider(name string) (ProviderFactory, bool)   { return lookup(providerReg, name) }
+func lookupTool(name string) (ToolFactory, bool)           { return lookup(toolReg, name) }
+func lookupMemory(name string) (MemoryFactory, bool)       { return lookup(memoryReg, name) }
+func lookupProcessor(name string) (ProcessorFactory, bool) { return lookup(processorReg, name) }
+
+// ----- built-ins -----
+
+func init() {
+	_ = RegisterProvider("openai", func(cfg ModelConfig) (llm.Client, error) {
+		return openai.NewClient(openai.Config{
+			APIKey:      cfg.APIKey,
+			Model:       cfg.Name,
+			BaseURL:     cfg.BaseURL,
+			Temperature: cfg.Temperature,
+			MaxTokens:   cfg.MaxTokens,
+			Timeout:     parseDuration(cfg.Timeout),
+			RetryConfig: cfg.retryConfig(),
+		})
+	})
+	_ = RegisterProvider("anthropic", func(cfg ModelConfig) (llm.Client, error) {
+		return anthropic.NewClient(anthropic.Config{
+			APIKey:      cfg.APIKey,
+			Model:       cfg.Name,
+			BaseURL:     cfg.BaseURL,
+			Temperature: cfg.Temperature,
+			MaxTokens:   cfg.MaxTokens,
+			Timeout:     parseDuration(cfg.Timeout),
+			RetryConfig: cfg.retryConfig(),
+		})
+	})
+
+	_ = RegisterTool("calculator", func(map[string]interface{}) (tools.Tool, error) {
+		return &tools.CalculatorTool{}, nil
+	})
+	_ = RegisterTool("http_request", func(opts map[string]interface{}) (tools.Tool, error) {
+		timeout, err := durationOption(opts, "timeout")
+		if err != nil {
+			return nil, err
+		}
+		return httptool.NewRequestTool(timeout), nil
+	})
+
+	_ = RegisterMemory("inmemory", func(map[string]interface{}) (memory.Store, error) {
+		return inmemory.NewStore(), nil
+	})
+
+	_ = RegisterProcessor("token_limiter", func(opts map[string]interface{}) (core.MemoryProcessor, error) {
+		n, err := intOption(opts, "max_chars")
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, errors.New("max_chars must be positive")
+		}
+		return core.TokenLimiter{MaxChars: n}, nil
+	})
+	_ = RegisterProcessor("tool_call_filter", func(map[string]interface{}) (core.MemoryProcessor, error) {
+		return core.ToolCallFilter{}, nil
+	})
+}
+
+// retryConfig converts the optional retry block; zero MaxRetries lets clients apply defaults
+func (m ModelConfig) retryConfig() llm.RetryConfig {
+	if m.Retry == nil {
+		return llm.RetryConfig{}
+	}
+	rc := llm.DefaultRetryConfig()
+	rc.MaxRetries = m.Retry.MaxRetries
+	if d := parseDuration(m.Retry.InitialDelay); d > 0 {
+		rc.InitialDelay = d
+	}
+	if d := parseDuration(m.Retry.MaxDelay); d > 0 {
+		rc.MaxDelay = d
+	}
+	if m.Retry.BackoffFactor > 0 {
+		rc.BackoffFactor = m.Retry.BackoffFactor
+	}
+	return rc
+}
+
+// parseDuration parses a duration already checked by Validate
+func parseDuration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
+func durationOption(opts map[string]interface{}, key string) (time.Duration, error) {
+	v, ok := opts[key]
+	if !ok {
+		return 0, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a duration string", key)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid duration %q", key, s)
+	}
+	return d, nil
+}
+
+func intOption(opts map[string]interface{}, key string) (int, error) {
+	switch v := opts[key].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case float64: // JSON numbers
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("%s must be an integer", key)
+		}
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+}
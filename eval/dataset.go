@@ -0,0 +1,64 @@
+// Package eval runs agents over datasets and scores their answers so prompt,
+// model and tool changes can be compared run over run.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Case is one dataset entry
+type Case struct {
+	ID       string            `json:"id"`
+	Input    string            `json:"input"`
+	Expected string            `json:"expected,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// LoadDataset reads a JSONL dataset from path
+func LoadDataset(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDataset(f)
+}
+
+// ReadDataset parses JSONL cases, one per line; blank lines are skipped and
+// cases without an id are numbered by line
+func ReadDataset(r io.Reader) ([]Case, error) {
+	var cases []Case
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var c Case
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if c.Input == "" {
+			return nil, fmt.Errorf("line %d: input is required", line)
+		}
+		if c.ID == "" {
+			c.ID = strconv.Itoa(line)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("line %d: duplicate id %q", line, c.ID)
+		}
+		seen[c.ID] = true
+		cases = append(cases, c)
+	}
+	return cases, sc.Err()
+}
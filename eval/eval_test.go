@@ -0,0 +1,205 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/llm/llmtest"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+func TestReadDataset(t *testing.T) {
+	data := `{"id":"a","input":"2+2","expected":"4","metadata":{"topic":"math"}}
+
+{"input":"capital of France","expected":"Paris"}
+`
+	cases, err := ReadDataset(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(cases) != 2 || cases[0].Metadata["topic"] != "math" || cases[1].ID != "3" {
+		t.Fatalf("unexpected cases %+v", cases)
+	}
+	if _, err := ReadDataset(strings.NewReader(`{"id":"a","input":"x"}` + "\n" + `{"id":"a","input":"y"}`)); err == nil {
+		t.Fatalf("duplicate ids should fail")
+	}
+	if _, err := ReadDataset(strings.NewReader(`{"id":"a"}`)); err == nil {
+		t.Fatalf("missing input should fail")
+	}
+}
+
+// echoAgent answers from a table and reports running concurrency
+type echoAgent struct {
+	answers          map[string]string
+	running, maxSeen atomic.Int32
+}
+
+func (e *echoAgent) Run(ctx context.Context, in core.Message) (core.Message, error) {
+	n := e.running.Add(1)
+	defer e.running.Add(-1)
+	for {
+		m := e.maxSeen.Load()
+		if n <= m || e.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	out, ok := e.answers[in.Content]
+	if !ok {
+		return core.Message{}, errors.New("no answer")
+	}
+	return core.Message{Role: "assistant", Content: out}, nil
+}
+func (e *echoAgent) RunStream(ctx context.Context, in core.Message, out chan<- core.Message) error {
+	defer close(out)
+	m, err := e.Run(ctx, in)
+	if err == nil {
+		out <- m
+	}
+	return err
+}
+
+func TestRunner_ScoresAndSummarizes(t *testing.T) {
+	agent := &echoAgent{answers: map[string]string{"a": "1", "b": "2", "c": "wrong"}}
+	cases := []Case{
+		{ID: "a", Input: "a", Expected: "1"},
+		{ID: "b", Input: "b", Expected: "2"},
+		{ID: "c", Input: "c", Expected: "3"},
+		{ID: "d", Input: "d", Expected: "4"},
+	}
+	rep, err := Runner{Name: "v1", Agent: agent, Scorers: []Scorer{ExactMatch{}}, Concurrency: 2}.Run(context.Background(), cases)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if agent.maxSeen.Load() > 2 {
+		t.Fatalf("concurrency limit exceeded: %d", agent.maxSeen.Load())
+	}
+	s := rep.Summary
+	if s.Total != 4 || s.Passed != 2 || s.Failed != 2 || s.Errors != 1 || s.PassRate != 0.5 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if rep.Cases[3].Error == "" || rep.Cases[2].Pass {
+		t.Fatalf("results must stay in dataset order: %+v", rep.Cases)
+	}
+	if s.Latency.P50 <= 0 || s.Latency.P99 < s.Latency.P50 {
+		t.Fatalf("unexpected latency %+v", s.Latency)
+	}
+	if sc := s.Scorers["exact_match"]; sc.Scored != 3 || sc.Passed != 2 {
+		t.Fatalf("unexpected scorer summary %+v", sc)
+	}
+}
+
+// sessionAgent answers with the session id it runs in
+type sessionAgent struct{ echoAgent }
+
+func (*sessionAgent) Run(ctx context.Context, in core.Message) (core.Message, error) {
+	return core.Message{Role: "assistant", Content: core.SessionIDFromContext(ctx)}, nil
+}
+
+func TestRunner_RunsEachCaseInItsOwnSession(t *testing.T) {
+	cases := []Case{{ID: "a", Expected: "a"}, {ID: "b", Expected: "b"}}
+	rep, err := Runner{Agent: &sessionAgent{}, Scorers: []Scorer{ExactMatch{}}}.Run(context.Background(), cases)
+	if err != nil || rep.Summary.Passed != 2 {
+		t.Fatalf("cases should run in sessions named after them: %v %+v", err, rep.Cases)
+	}
+}
+
+func TestRunner_NewAgentGivesEachCaseFreshMemory(t *testing.T) {
+	model := llmtest.NewClient(t)
+	noHistory := func(other string) func(*llm.ChatRequest) error {
+		return func(req *llm.ChatRequest) error {
+			for _, m := range req.Messages {
+				if m.Content == other {
+					return errors.New("saw the other case's input")
+				}
+			}
+			return nil
+		}
+	}
+	model.Expect().Match("fresh memory", noHistory("second")).Reply("one")
+	model.Expect().Match("fresh memory", noHistory("first")).Reply("two")
+	newAgent := func() core.Agent {
+		return core.NewChatAgent(core.ChatConfig{Model: model, Mem: inmemory.NewStore(), Config: core.AgentConfig{SystemPrompt: "sys"}})
+	}
+	cases := []Case{{ID: "a", Input: "first", Expected: "one"}, {ID: "b", Input: "second", Expected: "two"}}
+	rep, err := Runner{NewAgent: newAgent, Scorers: []Scorer{ExactMatch{}}, Concurrency: 1}.Run(context.Background(), cases)
+	if err != nil || rep.Summary.Passed != 2 {
+		t.Fatalf("cases should not share history: %v %+v", err, rep.Cases)
+	}
+}
+
+// transcriptAgent returns a canned transcript with a tool call and usage
+type transcriptAgent struct{ echoAgent }
+
+func (a *transcriptAgent) RunWithTranscript(ctx context.Context, in core.Message) (*core.RunResult, error) {
+	return &core.RunResult{
+		Input: in,
+		Final: core.Message{Role: "assistant", Content: "sunny"},
+		Steps: []core.Step{
+			{Type: core.StepLLM, Model: llm.ModelGPT4o, Usage: &llm.Usage{InputTokens: 1000000, OutputTokens: 0, TotalTokens: 1000000}},
+			{Type: core.StepTool, ToolName: "weather", Arguments: `{"city":"Paris"}`},
+			{Type: core.StepLLM, Model: "custom", Usage: &llm.Usage{Cost: 0.5}},
+		},
+		Usage: llm.Usage{InputTokens: 1000000, TotalTokens: 1000000},
+	}, nil
+}
+
+func TestRunner_UsesTranscript(t *testing.T) {
+	rep, err := Runner{Agent: &transcriptAgent{}, Scorers: []Scorer{ToolCalled{Tool: "weather"}}}.Run(context.Background(), []Case{{ID: "w", Input: "weather?"}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	c := rep.Cases[0]
+	if !c.Pass || c.Output != "sunny" || len(c.ToolCalls) != 1 {
+		t.Fatalf("unexpected case %+v", c)
+	}
+	// 1M gpt-4o input tokens at $5/1M plus a reported $0.5
+	if c.Cost != 5.5 || rep.Summary.Cost != 5.5 || rep.Summary.TotalTokens != 1000000 {
+		t.Fatalf("unexpected cost/tokens %v %+v", c.Cost, rep.Summary)
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	base := &Report{Name: "v1", Cases: []CaseResult{
+		{ID: "a", Pass: true, Latency: 10 * time.Millisecond},
+		{ID: "b", Pass: false, Latency: 20 * time.Millisecond},
+		{ID: "c", Pass: true, Latency: 30 * time.Millisecond},
+	}}
+	base.Summary = Summarize(base.Cases)
+	head := &Report{Name: "v2", Cases: []CaseResult{
+		{ID: "a", Pass: false, Latency: 5 * time.Millisecond},
+		{ID: "b", Pass: true, Latency: 5 * time.Millisecond},
+		{ID: "d", Pass: true, Latency: 5 * time.Millisecond},
+	}}
+	head.Summary = Summarize(head.Cases)
+
+	path := filepath.Join(t.TempDir(), "base.json")
+	if err := SaveReport(path, base); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := LoadReport(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	d := Compare(loaded, head)
+	if len(d.Regressions) != 1 || d.Regressions[0] != "a" || len(d.Fixes) != 1 || d.Fixes[0] != "b" {
+		t.Fatalf("unexpected regressions/fixes %+v", d)
+	}
+	if len(d.Added) != 1 || d.Added[0] != "d" || len(d.Removed) != 1 || d.Removed[0] != "c" {
+		t.Fatalf("unexpected added/removed %+v", d)
+	}
+	if d.PassRate != 0 || d.LatencyP50 != -15*time.Millisecond {
+		t.Fatalf("unexpected deltas %+v", d)
+	}
+	if out := d.String(); !strings.Contains(out, "regressions: a") || !strings.Contains(out, "p50: -15ms") {
+		t.Fatalf("unexpected rendering:\n%s", out)
+	}
+}
@@ -0,0 +1,253 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the result of one evaluation run. It serializes to JSON so runs can
+// be stored and compared with Compare.
+type Report struct {
+	Name      string        `json:"name,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Summary   Summary       `json:"summary"`
+	Cases     []CaseResult  `json:"cases"`
+}
+
+// Summary aggregates case results
+type Summary struct {
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Errors   int     `json:"errors"`
+	PassRate float64 `json:"pass_rate"`
+	Latency  Latency `json:"latency"`
+
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost"`
+
+	Scorers map[string]ScorerSummary `json:"scorers,omitempty"`
+}
+
+// Latency holds latency percentiles over all cases
+type Latency struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// ScorerSummary aggregates one scorer over the cases it graded
+type ScorerSummary struct {
+	Scored    int     `json:"scored"`
+	Passed    int     `json:"passed"`
+	PassRate  float64 `json:"pass_rate"`
+	MeanValue float64 `json:"mean_value"`
+}
+
+// Summarize computes the summary of a set of case results
+func Summarize(cases []CaseResult) Summary {
+	s := Summary{Total: len(cases), Scorers: map[string]ScorerSummary{}}
+	latencies := make([]time.Duration, 0, len(cases))
+	var totalLatency time.Duration
+	for _, c := range cases {
+		switch {
+		case c.Error != "":
+			s.Errors++
+			s.Failed++
+		case c.Pass:
+			s.Passed++
+		default:
+			s.Failed++
+		}
+		latencies = append(latencies, c.Latency)
+		totalLatency += c.Latency
+		s.InputTokens += c.Usage.InputTokens
+		s.OutputTokens += c.Usage.OutputTokens
+		s.TotalTokens += c.Usage.TotalTokens
+		s.Cost += c.Cost
+		for _, sc := range c.Scores {
+			agg := s.Scorers[sc.Scorer]
+			agg.Scored++
+			if sc.Pass {
+				agg.Passed++
+			}
+			agg.MeanValue += sc.Value
+			s.Scorers[sc.Scorer] = agg
+		}
+	}
+	for name, agg := range s.Scorers {
+		agg.PassRate = float64(agg.Passed) / float64(agg.Scored)
+		agg.MeanValue /= float64(agg.Scored)
+		s.Scorers[name] = agg
+	}
+	if s.Total > 0 {
+		s.PassRate = float64(s.Passed) / float64(s.Total)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		s.Latency = Latency{
+			Mean: totalLatency / time.Duration(s.Total),
+			P50:  percentile(latencies, 50),
+			P90:  percentile(latencies, 90),
+			P95:  percentile(latencies, 95),
+			P99:  percentile(latencies, 99),
+			Max:  latencies[len(latencies)-1],
+		}
+	}
+	return s
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// SaveReport writes the report to path as JSON
+func SaveReport(path string, r *Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadReport reads a report written by SaveReport
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("decode report: %w", err)
+	}
+	return &r, nil
+}
+
+// Diff compares two reports; deltas are head minus base
+type Diff struct {
+	Base string `json:"base,omitempty"`
+	Head string `json:"head,omitempty"`
+
+	PassRate    float64       `json:"pass_rate"`
+	LatencyP50  time.Duration `json:"latency_p50"`
+	LatencyP95  time.Duration `json:"latency_p95"`
+	TotalTokens int           `json:"total_tokens"`
+	Cost        float64       `json:"cost"`
+	// Scorers holds pass-rate deltas per scorer present in either report
+	Scorers map[string]float64 `json:"scorers,omitempty"`
+
+	// Regressions passed in base and fail in head; Fixes the reverse
+	Regressions []string `json:"regressions,omitempty"`
+	Fixes       []string `json:"fixes,omitempty"`
+	// Added and Removed list case ids present in only one report
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Compare diffs head against base, matching cases by id
+func Compare(base, head *Report) Diff {
+	d := Diff{
+		Base:        base.Name,
+		Head:        head.Name,
+		PassRate:    head.Summary.PassRate - base.Summary.PassRate,
+		LatencyP50:  head.Summary.Latency.P50 - base.Summary.Latency.P50,
+		LatencyP95:  head.Summary.Latency.P95 - base.Summary.Latency.P95,
+		TotalTokens: head.Summary.TotalTokens - base.Summary.TotalTokens,
+		Cost:        head.Summary.Cost - base.Summary.Cost,
+		Scorers:     map[string]float64{},
+	}
+	for name, s := range head.Summary.Scorers {
+		d.Scorers[name] = s.PassRate - base.Summary.Scorers[name].PassRate
+	}
+	for name, s := range base.Summary.Scorers {
+		if _, ok := head.Summary.Scorers[name]; !ok {
+			d.Scorers[name] = -s.PassRate
+		}
+	}
+
+	before := make(map[string]bool, len(base.Cases))
+	for _, c := range base.Cases {
+		before[c.ID] = c.Pass
+	}
+	inHead := make(map[string]bool, len(head.Cases))
+	for _, c := range head.Cases {
+		inHead[c.ID] = true
+		was, ok := before[c.ID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, c.ID)
+		case was && !c.Pass:
+			d.Regressions = append(d.Regressions, c.ID)
+		case !was && c.Pass:
+			d.Fixes = append(d.Fixes, c.ID)
+		}
+	}
+	for _, c := range base.Cases {
+		if !inHead[c.ID] {
+			d.Removed = append(d.Removed, c.ID)
+		}
+	}
+	return d
+}
+
+// String renders the diff for terminals and CI logs
+func (d Diff) String() string {
+	var sb strings.Builder
+	if d.Base != "" || d.Head != "" {
+		fmt.Fprintf(&sb, "%s -> %s\n", d.Base, d.Head)
+	}
+	fmt.Fprintf(&sb, "pass rate: %+.1f%%\n", d.PassRate*100)
+	fmt.Fprintf(&sb, "latency p50: %s, p95: %s\n", signed(d.LatencyP50), signed(d.LatencyP95))
+	fmt.Fprintf(&sb, "tokens: %+d, cost: %+.4f USD\n", d.TotalTokens, d.Cost)
+	names := make([]string, 0, len(d.Scorers))
+	for name := range d.Scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  %s: %+.1f%%\n", name, d.Scorers[name]*100)
+	}
+	list := func(label string, ids []string) {
+		if len(ids) > 0 {
+			fmt.Fprintf(&sb, "%s: %s\n", label, strings.Join(ids, ", "))
+		}
+	}
+	list("regressions", d.Regressions)
+	list("fixes", d.Fixes)
+	list("added", d.Added)
+	list("removed", d.Removed)
+	return sb.String()
+}
+
+func signed(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
@@ -0,0 +1,179 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// TranscriptAgent is implemented by agents that can report tool calls and token
+// usage for a run (core.ChatAgent). Other agents only yield output and latency.
+type TranscriptAgent interface {
+	RunWithTranscript(ctx context.Context, input core.Message) (*core.RunResult, error)
+}
+
+// ToolCall records a tool invocation made during a case
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// CaseResult is the outcome of running and scoring one case
+type CaseResult struct {
+	ID        string            `json:"id"`
+	Input     string            `json:"input"`
+	Expected  string            `json:"expected,omitempty"`
+	Output    string            `json:"output"`
+	Error     string            `json:"error,omitempty"`
+	Latency   time.Duration     `json:"latency"`
+	Usage     llm.Usage         `json:"usage"`
+	Cost      float64           `json:"cost"`
+	ToolCalls []ToolCall        `json:"tool_calls,omitempty"`
+	Scores    []Score           `json:"scores"`
+	Pass      bool              `json:"pass"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Runner runs an agent over a dataset and scores every case. A case passes when
+// the agent succeeds and every scorer passes.
+type Runner struct {
+	// Name labels the report, e.g. a prompt or model version
+	Name  string
+	Agent core.Agent
+	// NewAgent, when set, builds a fresh agent for every case instead of
+	// sharing Agent. Use it for agents whose memory is not per session, e.g. a
+	// core.ChatAgent on a plain memory.Store, which keeps one history for all
+	// sessions.
+	NewAgent func() core.Agent
+	Scorers  []Scorer
+	// Concurrency bounds cases run in parallel (default 4)
+	Concurrency int
+	// Timeout bounds each case; zero means no limit
+	Timeout time.Duration
+}
+
+// Run evaluates cases and returns a report with results in dataset order
+func (r Runner) Run(ctx context.Context, cases []Case) (*Report, error) {
+	if r.Agent == nil && r.NewAgent == nil {
+		return nil, fmt.Errorf("eval runner requires an agent")
+	}
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "eval.run")
+	defer span.End()
+	span.SetAttribute("eval.name", r.Name)
+	span.SetAttribute("eval.cases", len(cases))
+
+	workers := r.Concurrency
+	if workers <= 0 {
+		workers = 4
+	}
+	report := &Report{Name: r.Name, StartedAt: time.Now()}
+	results := make([]CaseResult, len(cases))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			span.SetStatus(obs.StatusCodeError, ctx.Err().Error())
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, c Case) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.runCase(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(report.StartedAt)
+	report.Cases = results
+	report.Summary = Summarize(results)
+	span.SetAttribute("eval.pass_rate", report.Summary.PassRate)
+	span.SetStatus(obs.StatusCodeOk, "")
+	return report, nil
+}
+
+func (r Runner) runCase(ctx context.Context, c Case) CaseResult {
+	span, ctx := obs.TracerImpl.StartSpan(ctx, "eval.case")
+	defer span.End()
+	span.SetAttribute("eval.case", c.ID)
+	// Each case is its own session, so agents with per-session memory do not
+	// carry history from one case into the next
+	ctx = core.WithSessionID(ctx, c.ID)
+	agent := r.Agent
+	if r.NewAgent != nil {
+		agent = r.NewAgent()
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	res := CaseResult{ID: c.ID, Input: c.Input, Expected: c.Expected, Metadata: c.Metadata, Scores: []Score{}}
+	input := core.Message{Role: "user", Content: c.Input}
+	start := time.Now()
+	var out core.Message
+	var err error
+	if ta, ok := agent.(TranscriptAgent); ok {
+		var rr *core.RunResult
+		rr, err = ta.RunWithTranscript(ctx, input)
+		if rr != nil {
+			out = rr.Final
+			res.Usage = rr.Usage
+			res.Cost, res.ToolCalls = fromTranscript(rr)
+		}
+	} else {
+		out, err = agent.Run(ctx, input)
+	}
+	res.Latency = time.Since(start)
+	res.Output = out.Content
+	if err != nil {
+		res.Error = err.Error()
+		span.SetStatus(obs.StatusCodeError, err.Error())
+		return res
+	}
+
+	res.Pass = true
+	for _, s := range r.Scorers {
+		sc, err := s.Score(ctx, c, &res)
+		if err != nil {
+			sc = Score{Scorer: s.Name(), Reason: "scorer error: " + err.Error()}
+		}
+		res.Scores = append(res.Scores, sc)
+		res.Pass = res.Pass && sc.Pass
+	}
+	span.SetAttribute("eval.pass", res.Pass)
+	span.SetStatus(obs.StatusCodeOk, "")
+	return res
+}
+
+// fromTranscript sums cost (reported, or estimated from the model price table)
+// and collects tool calls
+func fromTranscript(rr *core.RunResult) (float64, []ToolCall) {
+	var cost float64
+	var calls []ToolCall
+	for _, s := range rr.Steps {
+		switch s.Type {
+		case core.StepLLM:
+			if s.Usage == nil {
+				continue
+			}
+			if s.Usage.Cost > 0 {
+				cost += s.Usage.Cost
+			} else if m, err := llm.GetModel(s.Model); err == nil {
+				cost += m.EstimateCost(s.Usage.InputTokens, s.Usage.OutputTokens)
+			}
+		case core.StepTool:
+			calls = append(calls, ToolCall{Name: s.ToolName, Arguments: s.Arguments})
+		}
+	}
+	return cost, calls
+}
@@ -0,0 +1,226 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/KamdynS/go-agents/llm"
+)
+
+// Score is the verdict of one scorer on one case
+type Score struct {
+	Scorer string `json:"scorer"`
+	Pass   bool   `json:"pass"`
+	// Value is in [0,1]; binary scorers use 0 or 1
+	Value  float64 `json:"value"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Scorer grades an agent's result for a case
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c Case, r *CaseResult) (Score, error)
+}
+
+func binary(name string, pass bool, reason string) Score {
+	s := Score{Scorer: name, Pass: pass, Reason: reason}
+	if pass {
+		s.Value = 1
+	}
+	return s
+}
+
+// ExactMatch passes when the output equals Case.Expected (whitespace trimmed)
+type ExactMatch struct {
+	IgnoreCase bool
+}
+
+func (ExactMatch) Name() string { return "exact_match" }
+
+func (s ExactMatch) Score(ctx context.Context, c Case, r *CaseResult) (Score, error) {
+	got, want := strings.TrimSpace(r.Output), strings.TrimSpace(c.Expected)
+	pass := got == want
+	if s.IgnoreCase {
+		pass = strings.EqualFold(got, want)
+	}
+	reason := ""
+	if !pass {
+		reason = fmt.Sprintf("expected %q, got %q", want, got)
+	}
+	return binary(s.Name(), pass, reason), nil
+}
+
+// Regex passes when the output matches Pattern, or Case.Expected when Pattern is empty
+type Regex struct {
+	Pattern string
+}
+
+func (Regex) Name() string { return "regex" }
+
+func (s Regex) Score(ctx context.Context, c Case, r *CaseResult) (Score, error) {
+	pattern := s.Pattern
+	if pattern == "" {
+		pattern = c.Expected
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Score{}, fmt.Errorf("regex scorer: %w", err)
+	}
+	if re.MatchString(r.Output) {
+		return binary(s.Name(), true, ""), nil
+	}
+	return binary(s.Name(), false, fmt.Sprintf("output does not match %s", pattern)), nil
+}
+
+// JSONFields parses the output as JSON and compares fields with Case.Expected,
+// which must be a JSON object. Fields limits the comparison to the given
+// top-level keys; by default every key of the expected object is checked.
+// Value is the fraction of matching fields.
+type JSONFields struct {
+	Fields []string
+}
+
+func (JSONFields) Name() string { return "json_fields" }
+
+func (s JSONFields) Score(ctx context.Context, c Case, r *CaseResult) (Score, error) {
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(c.Expected), &want); err != nil {
+		return Score{}, fmt.Errorf("json_fields scorer: expected is not a JSON object: %w", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(llm.ExtractJSON(r.Output)), &got); err != nil {
+		return binary(s.Name(), false, "output is not a JSON object"), nil
+	}
+	fields := s.Fields
+	if len(fields) == 0 {
+		for k := range want {
+			fields = append(fields, k)
+		}
+	}
+	if len(fields) == 0 {
+		return binary(s.Name(), true, ""), nil
+	}
+	var mismatched []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(got[f], want[f]) {
+			mismatched = append(mismatched, f)
+		}
+	}
+	sc := Score{Scorer: s.Name(), Pass: len(mismatched) == 0, Value: float64(len(fields)-len(mismatched)) / float64(len(fields))}
+	if len(mismatched) > 0 {
+		sc.Reason = "mismatched fields: " + strings.Join(mismatched, ", ")
+	}
+	return sc, nil
+}
+
+// ToolCalled passes when the agent called Tool during the run. Requires an agent
+// that records a transcript (see TranscriptAgent). With Forbid set it passes only
+// when the tool was not called.
+type ToolCalled struct {
+	Tool string
+	// ArgsContain, when set, must appear in the arguments of at least one call
+	ArgsContain string
+	Forbid      bool
+}
+
+func (s ToolCalled) Name() string { return "tool_called:" + s.Tool }
+
+func (s ToolCalled) Score(ctx context.Context, c Case, r *CaseResult) (Score, error) {
+	called := false
+	for _, tc := range r.ToolCalls {
+		if tc.Name == s.Tool && strings.Contains(tc.Arguments, s.ArgsContain) {
+			called = true
+			break
+		}
+	}
+	switch {
+	case s.Forbid && called:
+		return binary(s.Name(), false, "tool was called"), nil
+	case s.Forbid:
+		return binary(s.Name(), true, ""), nil
+	case called:
+		return binary(s.Name(), true, ""), nil
+	}
+	return binary(s.Name(), false, "tool was not called"), nil
+}
+
+// Verdict is the structured output requested from an LLM judge
+type Verdict struct {
+	llm.BaseStructured
+	Pass   bool    `json:"pass"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+func (v Verdict) Validate() error {
+	if v.Score < 0 || v.Score > 1 {
+		return fmt.Errorf("score must be between 0 and 1, got %f", v.Score)
+	}
+	return nil
+}
+
+func (v Verdict) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pass":   map[string]interface{}{"type": "boolean", "description": "Whether the answer meets the criteria"},
+			"score":  map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1, "description": "Quality between 0 and 1"},
+			"reason": map[string]interface{}{"type": "string", "description": "Short justification"},
+		},
+		"required": []string{"pass", "score", "reason"},
+	}
+}
+
+// Judge asks a model to grade the output against Criteria using structured output
+type Judge struct {
+	Model llm.Client
+	// Criteria describes what a good answer looks like
+	Criteria string
+	// Threshold, when set, overrides the judge's pass flag with score >= Threshold
+	Threshold float64
+}
+
+func (Judge) Name() string { return "judge" }
+
+func (s Judge) Score(ctx context.Context, c Case, r *CaseResult) (Score, error) {
+	if s.Model == nil {
+		return Score{}, fmt.Errorf("judge scorer requires a model")
+	}
+	system := "You grade answers produced by an AI agent. Be strict and concise."
+	if s.Criteria != "" {
+		system += "\nCriteria:\n" + s.Criteria
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Question:\n%s\n\n", c.Input)
+	if c.Expected != "" {
+		fmt.Fprintf(&sb, "Reference answer:\n%s\n\n", c.Expected)
+	}
+	fmt.Fprintf(&sb, "Answer to grade:\n%s", r.Output)
+	resp, err := llm.StructuredChat(ctx, s.Model, llm.StructuredRequest[Verdict]{
+		SystemPrompt: system,
+		Messages:     []llm.Message{{Role: "user", Content: sb.String()}},
+		Schema:       Verdict{}.JSONSchema(),
+		OutputType:   Verdict{},
+	})
+	if err != nil {
+		return Score{}, fmt.Errorf("judge scorer: %w", err)
+	}
+	v := resp.Data
+	pass := v.Pass
+	if s.Threshold > 0 {
+		pass = v.Score >= s.Threshold
+	}
+	return Score{Scorer: s.Name(), Pass: pass, Value: v.Score, Reason: v.Reason}, nil
+}
+
+var (
+	_ Scorer = ExactMatch{}
+	_ Scorer = Regex{}
+	_ Scorer = JSONFields{}
+	_ Scorer = ToolCalled{}
+	_ Scorer = Judge{}
+)
@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KamdynS/go-agents/llm"
+)
+
+func TestExactMatchAndRegex(t *testing.T) {
+	ctx := context.Background()
+	c := Case{Input: "q", Expected: "Paris"}
+	if s, _ := (ExactMatch{}).Score(ctx, c, &CaseResult{Output: " Paris\n"}); !s.Pass || s.Value != 1 {
+		t.Fatalf("expected exact match, got %+v", s)
+	}
+	if s, _ := (ExactMatch{}).Score(ctx, c, &CaseResult{Output: "paris"}); s.Pass {
+		t.Fatalf("case should matter by default")
+	}
+	if s, _ := (ExactMatch{IgnoreCase: true}).Score(ctx, c, &CaseResult{Output: "paris"}); !s.Pass {
+		t.Fatalf("IgnoreCase should match")
+	}
+	if s, _ := (Regex{Pattern: `(?i)\bparis\b`}).Score(ctx, c, &CaseResult{Output: "It is paris."}); !s.Pass {
+		t.Fatalf("regex should match")
+	}
+	if s, _ := (Regex{}).Score(ctx, c, &CaseResult{Output: "London"}); s.Pass {
+		t.Fatalf("expected is used as the default pattern")
+	}
+	if _, err := (Regex{Pattern: "("}).Score(ctx, c, &CaseResult{}); err == nil {
+		t.Fatalf("invalid pattern should error")
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	ctx := context.Background()
+	c := Case{Expected: `{"city":"Paris","country":"FR","pop":2}`}
+	out := "```json\n{\"city\":\"Paris\",\"country\":\"FR\",\"pop\":3}\n```"
+	s, err := (JSONFields{}).Score(ctx, c, &CaseResult{Output: out})
+	if err != nil || s.Pass || s.Value < 0.6 || s.Value > 0.7 {
+		t.Fatalf("expected 2/3 fields, got %+v err=%v", s, err)
+	}
+	s, _ = (JSONFields{Fields: []string{"city", "country"}}).Score(ctx, c, &CaseResult{Output: out})
+	if !s.Pass {
+		t.Fatalf("selected fields should match: %+v", s)
+	}
+	if s, _ := (JSONFields{}).Score(ctx, c, &CaseResult{Output: "not json"}); s.Pass {
+		t.Fatalf("non-JSON output must fail")
+	}
+}
+
+func TestToolCalled(t *testing.T) {
+	ctx := context.Background()
+	r := &CaseResult{ToolCalls: []ToolCall{{Name: "search", Arguments: `{"q":"weather"}`}}}
+	if s, _ := (ToolCalled{Tool: "search", ArgsContain: "weather"}).Score(ctx, Case{}, r); !s.Pass {
+		t.Fatalf("search was called")
+	}
+	if s, _ := (ToolCalled{Tool: "calculator"}).Score(ctx, Case{}, r); s.Pass {
+		t.Fatalf("calculator was not called")
+	}
+	if s, _ := (ToolCalled{Tool: "search", Forbid: true}).Score(ctx, Case{}, r); s.Pass {
+		t.Fatalf("forbidden tool was called")
+	}
+}
+
+type judgeLLM struct{ reply string }
+
+func (j judgeLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	return &llm.Response{Role: "assistant", Content: j.reply}, nil
+}
+func (j judgeLLM) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return j.Chat(ctx, nil)
+}
+func (j judgeLLM) Stream(ctx context.Context, req *llm.ChatRequest, out chan<- *llm.Response) error {
+	defer close(out)
+	return nil
+}
+func (j judgeLLM) Model() string          { return "judge" }
+func (j judgeLLM) Provider() llm.Provider { return llm.ProviderOpenAI }
+func (j judgeLLM) Validate() error        { return nil }
+
+func TestJudge(t *testing.T) {
+	ctx := context.Background()
+	j := Judge{Model: judgeLLM{reply: `{"pass":true,"score":0.6,"reason":"mostly right"}`}, Criteria: "factual"}
+	s, err := j.Score(ctx, Case{Input: "q"}, &CaseResult{Output: "a"})
+	if err != nil || !s.Pass || s.Value != 0.6 || s.Reason != "mostly right" {
+		t.Fatalf("unexpected verdict %+v err=%v", s, err)
+	}
+	j.Threshold = 0.8
+	if s, _ := j.Score(ctx, Case{Input: "q"}, &CaseResult{Output: "a"}); s.Pass {
+		t.Fatalf("threshold should override the judge's pass flag")
+	}
+}
@@ -3,10 +3,11 @@ module github.com/KamdynS/go-agents
 go 1.24.4
 
 require (
-    github.com/jackc/pgx/v5 v5.7.5
-    github.com/liushuangls/go-anthropic/v2 v2.15.2
-    github.com/redis/go-redis/v9 v9.12.0
-    github.com/sashabaranov/go-openai v1.40.5
+	github.com/jackc/pgx/v5 v5.7.5
+	github.com/liushuangls/go-anthropic/v2 v2.15.2
+	github.com/redis/go-redis/v9 v9.12.0
+	github.com/sashabaranov/go-openai v1.40.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -14,6 +15,8 @@ require (
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sync v0.13.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
@@ -0,0 +1,201 @@
+// Package guardrails detects sensitive data (PII, secrets) and redacts, masks,
+// tokenizes or blocks it in agent inputs, tool traffic and model outputs.
+package guardrails
+
+import (
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Match is one detected value in a text
+type Match struct {
+	Detector string
+	Value    string
+	Start    int
+	End      int
+}
+
+// Detector finds sensitive values in text
+type Detector interface {
+	Name() string
+	Detect(text string) []Match
+}
+
+// regexDetector matches a pattern and optionally validates each candidate
+type regexDetector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(string) bool
+}
+
+func (d *regexDetector) Name() string { return d.name }
+
+func (d *regexDetector) Detect(text string) []Match {
+	var out []Match
+	for _, loc := range d.re.FindAllStringIndex(text, -1) {
+		v := text[loc[0]:loc[1]]
+		if d.validate != nil && !d.validate(v) {
+			continue
+		}
+		out = append(out, Match{Detector: d.name, Value: v, Start: loc[0], End: loc[1]})
+	}
+	return out
+}
+
+// Regex returns a custom detector named name
+func Regex(name string, re *regexp.Regexp) Detector {
+	return &regexDetector{name: name, re: re}
+}
+
+var (
+	emailRe  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	phoneRe  = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{2,4}\)[\s.\-]?|\b\d{2,4}[\s.\-])?\b\d{3,4}[\s.\-]?\d{4}\b`)
+	cardRe   = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	ibanRe   = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`)
+	apiKeyRe = regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_\-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9\-]{10,}|AIza[0-9A-Za-z_\-]{35})`)
+)
+
+// Email detects email addresses
+func Email() Detector { return &regexDetector{name: "email", re: emailRe} }
+
+// Phone detects phone numbers with 7 to 15 digits written with separators or a
+// leading +, so plain numeric ids are not matched
+func Phone() Detector {
+	return &regexDetector{name: "phone", re: phoneRe, validate: func(s string) bool {
+		n := len(digits(s))
+		return n >= 7 && n <= 15 && (strings.HasPrefix(s, "+") || strings.ContainsAny(s, " .-()"))
+	}}
+}
+
+// CreditCard detects 13 to 19 digit card numbers that pass the Luhn check
+func CreditCard() Detector {
+	return &regexDetector{name: "credit_card", re: cardRe, validate: func(s string) bool {
+		d := digits(s)
+		return len(d) >= 13 && len(d) <= 19 && luhn(d)
+	}}
+}
+
+// IBAN detects international bank account numbers with a valid mod-97 checksum
+func IBAN() Detector { return ibanDetector{} }
+
+// APIKey detects common API key and token formats (OpenAI/Anthropic sk-, AWS
+// access keys, GitHub, Slack and Google API keys)
+func APIKey() Detector { return &regexDetector{name: "api_key", re: apiKeyRe} }
+
+// DefaultDetectors returns every built-in detector. Cards come before phones so
+// a card number is never reported as a phone number.
+func DefaultDetectors() []Detector {
+	return []Detector{APIKey(), Email(), CreditCard(), IBAN(), Phone()}
+}
+
+type ibanDetector struct{}
+
+func (ibanDetector) Name() string { return "iban" }
+
+// Detect drops trailing space-separated groups until the checksum holds, since
+// the pattern can run into a following uppercase word
+func (ibanDetector) Detect(text string) []Match {
+	var out []Match
+	for _, loc := range ibanRe.FindAllStringIndex(text, -1) {
+		v := text[loc[0]:loc[1]]
+		for {
+			if validIBAN(v) {
+				out = append(out, Match{Detector: "iban", Value: v, Start: loc[0], End: loc[0] + len(v)})
+				break
+			}
+			i := strings.LastIndexByte(v, ' ')
+			if i < 0 {
+				break
+			}
+			v = v[:i]
+		}
+	}
+	return out
+}
+
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	rearranged := s[4:] + s[:4]
+	var sb strings.Builder
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			sb.WriteString(big.NewInt(int64(r - 'A' + 10)).String())
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func luhn(d string) bool {
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func digits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Scan runs the detectors and returns non-overlapping matches ordered by
+// position. On overlap the longer match wins, then the earlier detector.
+func Scan(text string, detectors []Detector) []Match {
+	type ranked struct {
+		Match
+		order int
+	}
+	var all []ranked
+	for i, d := range detectors {
+		for _, m := range d.Detect(text) {
+			all = append(all, ranked{m, i})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		li, lj := all[i].End-all[i].Start, all[j].End-all[j].Start
+		if li != lj {
+			return li > lj
+		}
+		return all[i].order < all[j].order
+	})
+	var kept []Match
+	for _, m := range all {
+		overlaps := false
+		for _, k := range kept {
+			if m.Start < k.End && k.Start < m.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, m.Match)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Start < kept[j].Start })
+	return kept
+}
@@ -0,0 +1,350 @@
+package guardrails
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// ErrBlocked is matched (via errors.Is) by every *BlockedError
+var ErrBlocked = errors.New("blocked by guardrails")
+
+// Action is what happens to detected values
+type Action string
+
+const (
+	// ActionNone skips scanning
+	ActionNone Action = ""
+	// ActionLog records detections on the span and leaves the text unchanged
+	ActionLog Action = "log"
+	// ActionRedact replaces values with [REDACTED_<DETECTOR>]
+	ActionRedact Action = "redact"
+	// ActionMask keeps the last four characters (the domain for emails)
+	ActionMask Action = "mask"
+	// ActionTokenize replaces values with reversible vault tokens
+	ActionTokenize Action = "tokenize"
+	// ActionBlock fails the run with a *BlockedError
+	ActionBlock Action = "block"
+)
+
+// Surface is where text was scanned
+type Surface string
+
+const (
+	SurfaceInput      Surface = "input"
+	SurfaceToolInput  Surface = "tool_input"
+	SurfaceToolOutput Surface = "tool_output"
+	SurfaceOutput     Surface = "output"
+)
+
+// BlockedError reports sensitive data found under ActionBlock
+type BlockedError struct {
+	Surface   Surface
+	Detectors []string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%v: %s found in %s", ErrBlocked, strings.Join(e.Detectors, ", "), e.Surface)
+}
+
+// Is reports whether target is ErrBlocked
+func (e *BlockedError) Is(target error) bool { return target == ErrBlocked }
+
+// Config selects detectors and an action per surface
+type Config struct {
+	// Detectors defaults to DefaultDetectors()
+	Detectors []Detector
+
+	// Input applies to the user input before it is stored and to the user
+	// and assistant messages sent to the model
+	Input Action
+	// ToolInput applies to tool inputs written by the model, before detokenization
+	ToolInput Action
+	// ToolOutput applies to tool results before the model sees them
+	ToolOutput Action
+	// Output applies to model responses, including each streamed chunk
+	Output Action
+
+	// Overrides sets the action for a detector name on every enabled surface,
+	// e.g. always block api_key while tokenizing emails
+	Overrides map[string]Action
+
+	// MaxSessions bounds the sessions whose vault is kept (default 10000);
+	// the least recently used vaults are dropped
+	MaxSessions int
+	// MaxVaultValues bounds the values of each session's vault (default 1000)
+	MaxVaultValues int
+	// RevealOutput restores tokenized values in model responses returned to the caller
+	RevealOutput bool
+}
+
+// Guard is a core.Middleware enforcing a Config. Tool inputs are always
+// detokenized, so tools receive real values while the model only sees tokens.
+// Each session (core.SessionIDFromContext) has its own vault, so a token only
+// detokenizes in the session whose input produced it.
+//
+// Streamed chunks are scanned one at a time; a value split across chunks is
+// not detected until the stream is stored.
+type Guard struct {
+	cfg Config
+
+	mu     sync.Mutex
+	vaults map[string]*list.Element // of *sessionVault, most recently used first
+	lru    *list.List
+}
+
+type sessionVault struct {
+	session string
+	vault   *Vault
+}
+
+// New creates a guard
+func New(cfg Config) *Guard {
+	if len(cfg.Detectors) == 0 {
+		cfg.Detectors = DefaultDetectors()
+	}
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = 10000
+	}
+	if cfg.MaxVaultValues <= 0 {
+		cfg.MaxVaultValues = defaultVaultSize
+	}
+	return &Guard{cfg: cfg, vaults: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Vault returns the vault of the session in ctx, creating it if needed
+func (g *Guard) Vault(ctx context.Context) *Vault {
+	session := core.SessionIDFromContext(ctx)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.vaults[session]; ok {
+		g.lru.MoveToFront(e)
+		return e.Value.(*sessionVault).vault
+	}
+	v := newVault(g.cfg.MaxVaultValues)
+	g.vaults[session] = g.lru.PushFront(&sessionVault{session: session, vault: v})
+	for g.lru.Len() > g.cfg.MaxSessions {
+		old := g.lru.Remove(g.lru.Back()).(*sessionVault)
+		delete(g.vaults, old.session)
+	}
+	return v
+}
+
+// Forget drops the vault of a session; call it when the session ends
+func (g *Guard) Forget(sessionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.vaults[sessionID]; ok {
+		g.lru.Remove(e)
+		delete(g.vaults, sessionID)
+	}
+}
+
+func (g *Guard) action(s Surface) Action {
+	switch s {
+	case SurfaceInput:
+		return g.cfg.Input
+	case SurfaceToolInput:
+		return g.cfg.ToolInput
+	case SurfaceToolOutput:
+		return g.cfg.ToolOutput
+	case SurfaceOutput:
+		return g.cfg.Output
+	}
+	return ActionNone
+}
+
+// Apply scans text for the surface and returns it with its action applied.
+// Detections are recorded as guardrails.detection events on the span in ctx;
+// the values themselves are never recorded.
+func (g *Guard) Apply(ctx context.Context, surface Surface, text string) (string, error) {
+	return g.apply(ctx, surface, text, true)
+}
+
+// apply is Apply; without block, values under ActionBlock are redacted instead
+func (g *Guard) apply(ctx context.Context, surface Surface, text string, block bool) (string, error) {
+	base := g.action(surface)
+	if base == ActionNone || text == "" {
+		return text, nil
+	}
+	matches := Scan(text, g.cfg.Detectors)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	type key struct {
+		detector string
+		action   Action
+	}
+	counts := map[key]int{}
+	var blocked []string
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		act := base
+		if o, ok := g.cfg.Overrides[m.Detector]; ok && o != ActionNone {
+			act = o
+		}
+		if act == ActionBlock && !block {
+			act = ActionRedact
+		}
+		counts[key{m.Detector, act}]++
+		if act == ActionBlock {
+			blocked = append(blocked, m.Detector)
+			continue
+		}
+		sb.WriteString(text[last:m.Start])
+		sb.WriteString(g.replace(ctx, act, m))
+		last = m.End
+	}
+	sb.WriteString(text[last:])
+
+	span := obs.TracerImpl.SpanFromContext(ctx)
+	for k, n := range counts {
+		span.AddEvent("guardrails.detection", map[string]interface{}{
+			"surface":  string(surface),
+			"detector": k.detector,
+			"action":   string(k.action),
+			"count":    n,
+		})
+	}
+	if len(blocked) > 0 {
+		return "", &BlockedError{Surface: surface, Detectors: unique(blocked)}
+	}
+	return sb.String(), nil
+}
+
+func (g *Guard) replace(ctx context.Context, act Action, m Match) string {
+	switch act {
+	case ActionRedact:
+		return "[REDACTED_" + strings.ToUpper(m.Detector) + "]"
+	case ActionMask:
+		return mask(m)
+	case ActionTokenize:
+		return g.Vault(ctx).Tokenize(m.Detector, m.Value)
+	}
+	return m.Value
+}
+
+func mask(m Match) string {
+	if m.Detector == "email" {
+		if at := strings.LastIndexByte(m.Value, '@'); at > 0 {
+			return m.Value[:1] + strings.Repeat("*", at-1) + m.Value[at:]
+		}
+	}
+	keep := 4
+	out := []byte(m.Value)
+	for i := len(out) - 1; i >= 0; i-- {
+		c := out[i]
+		if !isAlnum(c) {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		out[i] = '*'
+	}
+	return string(out)
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func unique(names []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RewriteInput scans the user input before it is stored, so a blocked input
+// is never stored and the stored copy is redacted or tokenized
+func (g *Guard) RewriteInput(ctx context.Context, input core.Message) (core.Message, error) {
+	out, err := g.Apply(ctx, SurfaceInput, input.Content)
+	if err != nil {
+		return core.Message{}, err
+	}
+	input.Content = out
+	return input, nil
+}
+
+// BeforeLLMCall scans user and assistant messages. History is rescanned every
+// call, since stored answers may hold revealed values; only the last message
+// (the current input) can block, earlier ones are redacted, so one blocked
+// turn does not fail every later turn of the session.
+func (g *Guard) BeforeLLMCall(ctx context.Context, req *llm.ChatRequest) error {
+	if req == nil {
+		return nil
+	}
+	for i := range req.Messages {
+		m := &req.Messages[i]
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		out, err := g.apply(ctx, SurfaceInput, m.Content, i == len(req.Messages)-1)
+		if err != nil {
+			return err
+		}
+		m.Content = out
+	}
+	return nil
+}
+
+// AfterLLMResponse scans model output (each chunk when streaming)
+func (g *Guard) AfterLLMResponse(ctx context.Context, resp *llm.Response) error {
+	if resp == nil {
+		return nil
+	}
+	out, err := g.Apply(ctx, SurfaceOutput, resp.Content)
+	if err != nil {
+		return err
+	}
+	if g.cfg.RevealOutput {
+		out = g.Vault(ctx).Detokenize(out)
+	}
+	resp.Content = out
+	return nil
+}
+
+// RewriteToolInput scans the model-written input, then restores tokenized values
+func (g *Guard) RewriteToolInput(ctx context.Context, toolName, input string) (string, error) {
+	out, err := g.Apply(ctx, SurfaceToolInput, input)
+	if err != nil {
+		return "", err
+	}
+	return g.Vault(ctx).Detokenize(out), nil
+}
+
+// RewriteToolResult scans the tool result before the model sees it
+func (g *Guard) RewriteToolResult(ctx context.Context, toolName, result string) (string, error) {
+	return g.Apply(ctx, SurfaceToolOutput, result)
+}
+
+func (g *Guard) BeforeToolExecute(ctx context.Context, toolName string, input string) error {
+	return nil
+}
+func (g *Guard) AfterToolExecute(ctx context.Context, toolName string, result string, execErr error) error {
+	return nil
+}
+func (g *Guard) AfterRun(ctx context.Context, final core.Message) error { return nil }
+
+var (
+	_ core.ToolRewriteMiddleware  = (*Guard)(nil)
+	_ core.InputRewriteMiddleware = (*Guard)(nil)
+)
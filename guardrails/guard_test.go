@@ -0,0 +1,200 @@
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/llm/llmtest"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+func TestApplyActions(t *testing.T) {
+	ctx := context.Background()
+	text := "mail jane@example.com card 4111 1111 1111 1111"
+	tests := []struct {
+		action Action
+		want   string
+	}{
+		{ActionLog, text},
+		{ActionRedact, "mail [REDACTED_EMAIL] card [REDACTED_CREDIT_CARD]"},
+		{ActionMask, "mail j***@example.com card **** **** **** 1111"},
+	}
+	for _, tt := range tests {
+		g := New(Config{Output: tt.action})
+		got, err := g.Apply(ctx, SurfaceOutput, text)
+		if err != nil || got != tt.want {
+			t.Fatalf("%s: got %q err=%v, want %q", tt.action, got, err, tt.want)
+		}
+	}
+	g := New(Config{Output: ActionTokenize})
+	got, _ := g.Apply(ctx, SurfaceOutput, text)
+	if !regexp.MustCompile(`^mail <EMAIL_[0-9a-f]{16}> card <CREDIT_CARD_[0-9a-f]{16}>$`).MatchString(got) {
+		t.Fatalf("tokenize: got %q", got)
+	}
+
+	g = New(Config{Output: ActionRedact, Overrides: map[string]Action{"credit_card": ActionBlock}})
+	_, err := g.Apply(ctx, SurfaceOutput, text)
+	var be *BlockedError
+	if !errors.Is(err, ErrBlocked) || !errors.As(err, &be) || be.Detectors[0] != "credit_card" {
+		t.Fatalf("expected block on card, got %v", err)
+	}
+	if out, _ := New(Config{}).Apply(ctx, SurfaceOutput, text); out != text {
+		t.Fatalf("disabled surface must not change text")
+	}
+}
+
+func TestVault(t *testing.T) {
+	v := newVault(2)
+	a := v.Tokenize("email", "a@x.io")
+	b := v.Tokenize("email", "b@x.io")
+	if a == b || !strings.HasPrefix(a, "<EMAIL_") || v.Tokenize("email", "a@x.io") != a {
+		t.Fatalf("tokens must be stable and distinct: %s %s", a, b)
+	}
+	other := NewVault().Tokenize("email", "c@x.io")
+	if got := v.Detokenize("to " + a + " and " + other); got != "to a@x.io and "+other {
+		t.Fatalf("detokenize: %q", got)
+	}
+	// Full: the oldest value is forgotten
+	v.Tokenize("email", "c@x.io")
+	if _, ok := v.Lookup(a); ok || v.Len() != 2 {
+		t.Fatalf("vault should be bounded, has %d values", v.Len())
+	}
+}
+
+func TestGuard_VaultPerSession(t *testing.T) {
+	g := New(Config{Input: ActionTokenize, MaxSessions: 2})
+	alice := core.WithSessionID(context.Background(), "alice")
+	bob := core.WithSessionID(context.Background(), "bob")
+	tok, _ := g.Apply(alice, SurfaceInput, "alice@example.com")
+	// Bob typing Alice's token does not reach her value
+	if out, _ := g.RewriteToolInput(bob, "lookup", tok); out != tok {
+		t.Fatalf("token detokenized in another session: %q", out)
+	}
+	if out, _ := g.RewriteToolInput(alice, "lookup", tok); out != "alice@example.com" {
+		t.Fatalf("token not restored in its own session: %q", out)
+	}
+	// MaxSessions: a third session evicts the least recently used vault
+	_ = g.Vault(bob)
+	_ = g.Vault(core.WithSessionID(context.Background(), "carol"))
+	if out, _ := g.RewriteToolInput(alice, "lookup", tok); out != tok {
+		t.Fatalf("evicted vault still detokenizes: %q", out)
+	}
+}
+
+func TestGuard_TokenizesForModelAndDetokenizesForTools(t *testing.T) {
+	tr := obs.NewDefaultTracer()
+	prev := obs.TracerImpl
+	obs.TracerImpl = tr
+	defer func() { obs.TracerImpl = prev }()
+
+	g := New(Config{Input: ActionTokenize, ToolOutput: ActionRedact, Output: ActionMask, RevealOutput: true})
+	// Tokens are random but stable per value, so the script can use the same one
+	tok := g.Vault(context.Background()).Tokenize("email", "jane@example.com")
+	model := llmtest.NewClient(t)
+	model.Expect().
+		Match("no raw email", func(req *llm.ChatRequest) error {
+			for _, m := range req.Messages {
+				if strings.Contains(m.Content, "jane@example.com") {
+					return errors.New("raw email sent to model")
+				}
+			}
+			return nil
+		}).
+		UserContains(tok).
+		CallTool("lookup", `{"input":"`+tok+`"}`)
+	model.Expect().ToolResult("[REDACTED_PHONE]").Reply("Found " + tok + ", card 4111 1111 1111 1111")
+
+	rec := llmtest.NewRecorder()
+	agent := core.NewChatAgent(core.ChatConfig{
+		Model:      model,
+		Tools:      rec.Registry(llmtest.StaticTool("lookup", "phone 555-123-4567")),
+		Middleware: []core.Middleware{g},
+		Config:     core.AgentConfig{SystemPrompt: "sys", MaxIterations: 3},
+	})
+	out, err := agent.Run(context.Background(), core.Message{Role: "user", Content: "find jane@example.com"})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	rec.AssertCalled(t, "lookup", "jane@example.com")
+	if out.Content != "Found jane@example.com, card **** **** **** 1111" {
+		t.Fatalf("unexpected output %q", out.Content)
+	}
+
+	events := 0
+	for _, s := range tr.GetSpans() {
+		for _, e := range s.Events {
+			if e.Name != "guardrails.detection" {
+				continue
+			}
+			events++
+			for _, v := range e.Attributes {
+				if s, ok := v.(string); ok && strings.Contains(s, "@") {
+					t.Fatalf("detected value leaked into span: %v", e.Attributes)
+				}
+			}
+		}
+	}
+	if events < 3 {
+		t.Fatalf("expected detection events for input, tool output and output, got %d", events)
+	}
+}
+
+func TestGuard_BlocksAndRedactsStream(t *testing.T) {
+	model := llmtest.NewClient(t)
+	model.Expect().Stream("my mail is ", "jane@example.com", " bye")
+	g := New(Config{Output: ActionRedact})
+	agent := core.NewChatAgent(core.ChatConfig{Model: model, Middleware: []core.Middleware{g}, Config: core.AgentConfig{SystemPrompt: "sys"}})
+	ch := make(chan core.Message, 8)
+	if err := agent.RunStream(context.Background(), core.Message{Role: "user", Content: "hi"}, ch); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	var chunks []string
+	for m := range ch {
+		chunks = append(chunks, m.Content)
+	}
+	if strings.Contains(strings.Join(chunks, ""), "jane@") {
+		t.Fatalf("streamed chunk leaked the email: %q", chunks)
+	}
+
+	block := New(Config{Input: ActionBlock})
+	agent = core.NewChatAgent(core.ChatConfig{Model: llmtest.NewClient(t), Middleware: []core.Middleware{block}, Config: core.AgentConfig{SystemPrompt: "sys"}})
+	if _, err := agent.Run(context.Background(), core.Message{Role: "user", Content: "key sk-abcdefghijklmnopqrstuvwxyz"}); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected input to be blocked, got %v", err)
+	}
+}
+
+func TestGuard_BlockedTurnDoesNotPoisonSession(t *testing.T) {
+	ctx := core.WithSessionID(context.Background(), "s1")
+	store := inmemory.NewConversationStore()
+	model := llmtest.NewClient(t)
+	model.Expect().
+		Match("blocked input not sent", func(req *llm.ChatRequest) error {
+			for _, m := range req.Messages {
+				if strings.Contains(m.Content, "sk-") || strings.Contains(m.Content, "jane@") {
+					return errors.New("sensitive value sent to model")
+				}
+			}
+			return nil
+		}).
+		Reply("hello")
+	g := New(Config{Input: ActionRedact, Overrides: map[string]Action{"api_key": ActionBlock}})
+	agent := core.NewChatAgent(core.ChatConfig{Model: model, Mem: store, Middleware: []core.Middleware{g}, Config: core.AgentConfig{SystemPrompt: "sys"}})
+
+	if _, err := agent.Run(ctx, core.Message{Role: "user", Content: "key sk-abcdefghijklmnopqrstuvwxyz"}); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected input to be blocked, got %v", err)
+	}
+	out, err := agent.Run(ctx, core.Message{Role: "user", Content: "hi, I am jane@example.com"})
+	if err != nil || out.Content != "hello" {
+		t.Fatalf("clean turn after a blocked one: %q %v", out.Content, err)
+	}
+	msgs, _ := store.GetMessages(ctx, "s1")
+	if len(msgs) != 2 || msgs[0].Content != "hi, I am [REDACTED_EMAIL]" {
+		t.Fatalf("stored history should hold only the redacted clean turn: %+v", msgs)
+	}
+}
@@ -0,0 +1,89 @@
+package guardrails
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var tokenRe = regexp.MustCompile(`<[A-Z][A-Z_]*_[0-9a-f]{16}>`)
+
+// defaultVaultSize bounds the values of a vault created by NewVault
+const defaultVaultSize = 1000
+
+// Vault maps sensitive values to placeholder tokens such as
+// <EMAIL_3f9a0c2e81d4b7a6> and back. The same value always gets the same
+// token, so a conversation stays consistent across turns. Tokens are random,
+// so they cannot be guessed to read another vault's values. Values never
+// leave the process.
+type Vault struct {
+	mu      sync.Mutex
+	max     int
+	byValue map[string]*list.Element
+	byToken map[string]*list.Element
+	order   *list.List // of *vaultEntry, oldest first
+}
+
+type vaultEntry struct{ token, value string }
+
+// NewVault returns an empty vault holding up to 1000 values
+func NewVault() *Vault { return newVault(defaultVaultSize) }
+
+// newVault returns a vault that forgets its oldest values beyond max
+func newVault(max int) *Vault {
+	return &Vault{max: max, byValue: map[string]*list.Element{}, byToken: map[string]*list.Element{}, order: list.New()}
+}
+
+// Tokenize returns the token for value, creating one for kind if needed. Once
+// the vault is full the oldest value is forgotten and its token no longer
+// detokenizes.
+func (v *Vault) Tokenize(kind, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if e, ok := v.byValue[value]; ok {
+		return e.Value.(*vaultEntry).token
+	}
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	tok := "<" + strings.ToUpper(kind) + "_" + hex.EncodeToString(b[:]) + ">"
+	e := v.order.PushBack(&vaultEntry{token: tok, value: value})
+	v.byValue[value] = e
+	v.byToken[tok] = e
+	for v.max > 0 && v.order.Len() > v.max {
+		old := v.order.Remove(v.order.Front()).(*vaultEntry)
+		delete(v.byValue, old.value)
+		delete(v.byToken, old.token)
+	}
+	return tok
+}
+
+// Lookup returns the value behind a token
+func (v *Vault) Lookup(token string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, ok := v.byToken[token]
+	if !ok {
+		return "", false
+	}
+	return e.Value.(*vaultEntry).value, true
+}
+
+// Detokenize replaces every token of this vault in text with its value
+func (v *Vault) Detokenize(text string) string {
+	return tokenRe.ReplaceAllStringFunc(text, func(tok string) string {
+		if val, ok := v.Lookup(tok); ok {
+			return val
+		}
+		return tok
+	})
+}
+
+// Len returns the number of stored values
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.order.Len()
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// Backend stores serialized responses with an optional TTL
+type Backend interface {
+	// Get returns the value and whether it was found and not expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// defaultMaxEntries bounds the in-memory backend and vector index
+const defaultMaxEntries = 10000
+
+// sweepEvery is how often Set drops expired entries from a MemoryBackend
+const sweepEvery = time.Minute
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// MemoryBackend is an in-process Backend holding at most a fixed number of
+// entries; the least recently used are evicted first. Expired entries are
+// dropped on read and swept from time to time on write.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	max       int
+	items     map[string]*list.Element // of *entry
+	lru       *list.List               // most recently used first
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryBackend creates an empty in-memory backend of up to 10000 entries
+func NewMemoryBackend() *MemoryBackend { return newMemoryBackend(defaultMaxEntries) }
+
+func newMemoryBackend(max int) *MemoryBackend {
+	return &MemoryBackend{max: max, items: make(map[string]*list.Element), lru: list.New(), now: time.Now}
+}
+
+func (m *MemoryBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if m.expired(e) {
+		m.remove(el)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (m *MemoryBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.After(m.nextSweep) {
+		m.sweep()
+		m.nextSweep = now.Add(sweepEvery)
+	}
+	e := &entry{key: key, value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	if el, ok := m.items[key]; ok {
+		el.Value = e
+		m.lru.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.lru.PushFront(e)
+	for m.max > 0 && m.lru.Len() > m.max {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+func (m *MemoryBackend) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet swept
+func (m *MemoryBackend) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryBackend) expired(e *entry) bool {
+	return !e.expires.IsZero() && !m.now().Before(e.expires)
+}
+
+// sweep drops every expired entry. Caller holds mu.
+func (m *MemoryBackend) sweep() {
+	for el := m.lru.Front(); el != nil; {
+		next := el.Next()
+		if m.expired(el.Value.(*entry)) {
+			m.remove(el)
+		}
+		el = next
+	}
+}
+
+func (m *MemoryBackend) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.items, el.Value.(*entry).key)
+}
+
+// vectorIndex is a small in-memory VectorStore using cosine similarity. It
+// keeps at most max documents, dropping the oldest, and forgets documents
+// older than ttl, whose cached responses have expired too.
+type vectorIndex struct {
+	mu    sync.RWMutex
+	max   int
+	ttl   time.Duration
+	now   func() time.Time
+	docs  map[string]*list.Element // of *vectorDoc
+	order *list.List               // oldest first
+}
+
+type vectorDoc struct {
+	doc   memory.Document
+	added time.Time
+}
+
+func newVectorIndex(max int, ttl time.Duration) *vectorIndex {
+	return &vectorIndex{max: max, ttl: ttl, now: time.Now, docs: map[string]*list.Element{}, order: list.New()}
+}
+
+func (v *vectorIndex) AddDocument(ctx context.Context, id, content string, embedding []float64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if el, ok := v.docs[id]; ok {
+		v.order.Remove(el)
+	}
+	v.docs[id] = v.order.PushBack(&vectorDoc{doc: memory.Document{ID: id, Content: content, Embedding: embedding}, added: v.now()})
+	for el := v.order.Front(); el != nil; el = v.order.Front() {
+		if v.order.Len() <= v.max && !v.expired(el.Value.(*vectorDoc)) {
+			break
+		}
+		v.order.Remove(el)
+		delete(v.docs, el.Value.(*vectorDoc).doc.ID)
+	}
+	return nil
+}
+
+func (v *vectorIndex) expired(d *vectorDoc) bool {
+	return v.ttl > 0 && !v.now().Before(d.added.Add(v.ttl))
+}
+
+func (v *vectorIndex) QuerySimilar(ctx context.Context, q []float64, limit int) ([]memory.Document, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	out := make([]memory.Document, 0, len(v.docs))
+	for el := v.order.Front(); el != nil; el = el.Next() {
+		vd := el.Value.(*vectorDoc)
+		if v.expired(vd) {
+			continue
+		}
+		d := vd.doc
+		d.Score = cosine(q, d.Embedding)
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (v *vectorIndex) DeleteDocument(ctx context.Context, id string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if el, ok := v.docs[id]; ok {
+		v.order.Remove(el)
+		delete(v.docs, id)
+	}
+	return nil
+}
+
+func (v *vectorIndex) GetDocument(ctx context.Context, id string) (*memory.Document, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	el, ok := v.docs[id]
+	if !ok {
+		return nil, errors.New("document not found")
+	}
+	d := el.Value.(*vectorDoc).doc
+	return &d, nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+var (
+	_ Backend            = (*MemoryBackend)(nil)
+	_ memory.VectorStore = (*vectorIndex)(nil)
+)
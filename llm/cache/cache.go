@@ -0,0 +1,313 @@
+// Package cache provides a caching llm.Client wrapper with an exact-match cache
+// keyed on the normalized request and an optional semantic cache on embeddings.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// Embedder turns text into a vector; rag.Embedder satisfies it
+type Embedder interface {
+	EmbedText(ctx context.Context, input string) ([]float64, error)
+}
+
+// Config configures a CachingClient
+type Config struct {
+	// Backend stores responses; defaults to an in-memory backend
+	Backend Backend
+	// TTL bounds how long responses are reused; zero keeps them until evicted
+	TTL time.Duration
+	// Namespace prefixes every key so several caches can share a backend
+	Namespace string
+
+	// Embedder enables the semantic cache when set
+	Embedder Embedder
+	// Vectors holds prompt embeddings; defaults to an in-memory index
+	Vectors memory.VectorStore
+	// Threshold is the minimum cosine similarity for a semantic hit (default 0.95)
+	Threshold float64
+	// MaxEntries bounds the default in-memory backend and vector index
+	// (default 10000); the least recently used entries are evicted
+	MaxEntries int
+}
+
+// Stats counts cache lookups
+type Stats struct {
+	Hits         int64 `json:"hits"`
+	SemanticHits int64 `json:"semantic_hits"`
+	Misses       int64 `json:"misses"`
+}
+
+// CachingClient wraps an llm.Client and serves repeated requests from a cache.
+// Cached responses carry Meta["cache"] = "hit" or "semantic".
+type CachingClient struct {
+	llm.Client
+	cfg Config
+
+	hits, semanticHits, misses atomic.Int64
+}
+
+// NewCachingClient wraps client with the given cache configuration
+func NewCachingClient(client llm.Client, cfg Config) *CachingClient {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultMaxEntries
+	}
+	if cfg.Backend == nil {
+		cfg.Backend = newMemoryBackend(cfg.MaxEntries)
+	}
+	if cfg.Embedder != nil && cfg.Vectors == nil {
+		cfg.Vectors = newVectorIndex(cfg.MaxEntries, cfg.TTL)
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.95
+	}
+	return &CachingClient{Client: client, cfg: cfg}
+}
+
+// Stats returns the lookup counters since creation
+func (c *CachingClient) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), SemanticHits: c.semanticHits.Load(), Misses: c.misses.Load()}
+}
+
+// Chat returns a cached response when possible and caches successful model responses
+func (c *CachingClient) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	if resp := c.lookup(ctx, req); resp != nil {
+		return resp, nil
+	}
+	resp, err := c.Client.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.store(ctx, req, resp)
+	return resp, nil
+}
+
+// Completion routes through Chat so completions are cached too
+func (c *CachingClient) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return c.Chat(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: prompt}}})
+}
+
+// Stream replays a cached response as a single chunk, or streams from the
+// wrapped client and caches the aggregated response once it completes.
+func (c *CachingClient) Stream(ctx context.Context, req *llm.ChatRequest, output chan<- *llm.Response) error {
+	defer close(output)
+	if resp := c.lookup(ctx, req); resp != nil {
+		select {
+		case output <- resp:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	inner := make(chan *llm.Response)
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Client.Stream(ctx, req, inner) }()
+	agg := &llm.Response{Role: "assistant"}
+	for r := range inner {
+		if r == nil {
+			continue
+		}
+		agg.Content += r.Content
+		agg.ToolCalls = append(agg.ToolCalls, r.ToolCalls...)
+		if r.Model != "" {
+			agg.Model, agg.Provider = r.Model, r.Provider
+		}
+		if r.Usage != nil {
+			agg.Usage = r.Usage
+		}
+		if r.FinishReason != "" {
+			agg.FinishReason = r.FinishReason
+		}
+		select {
+		case output <- r:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := <-errCh; err != nil {
+		return err
+	}
+	c.store(ctx, req, agg)
+	return nil
+}
+
+// lookup tries the exact cache, then the semantic cache
+func (c *CachingClient) lookup(ctx context.Context, req *llm.ChatRequest) *llm.Response {
+	key := c.Key(req)
+	if resp := c.get(ctx, key); resp != nil {
+		c.hits.Add(1)
+		obs.IncrementCounter("llm_cache", map[string]string{"result": "hit"})
+		return mark(resp, "hit")
+	}
+	if c.cfg.Embedder != nil {
+		if resp := c.semanticLookup(ctx, req); resp != nil {
+			c.semanticHits.Add(1)
+			obs.IncrementCounter("llm_cache", map[string]string{"result": "semantic_hit"})
+			return mark(resp, "semantic")
+		}
+	}
+	c.misses.Add(1)
+	obs.IncrementCounter("llm_cache", map[string]string{"result": "miss"})
+	return nil
+}
+
+func (c *CachingClient) semanticLookup(ctx context.Context, req *llm.ChatRequest) *llm.Response {
+	text, scope := semanticParts(req)
+	if text == "" {
+		return nil
+	}
+	vec, err := c.cfg.Embedder.EmbedText(ctx, text)
+	if err != nil {
+		return nil
+	}
+	docs, err := c.cfg.Vectors.QuerySimilar(ctx, vec, 5)
+	if err != nil {
+		return nil
+	}
+	want := c.scopeKey(scope)
+	for _, d := range docs {
+		docScope, key, _ := strings.Cut(d.ID, "|")
+		if d.Score < c.cfg.Threshold || docScope != want {
+			continue
+		}
+		if resp := c.get(ctx, key); resp != nil {
+			return resp
+		}
+		// The response expired; drop the stale vector
+		_ = c.cfg.Vectors.DeleteDocument(ctx, d.ID)
+	}
+	return nil
+}
+
+func (c *CachingClient) get(ctx context.Context, key string) *llm.Response {
+	data, ok, err := c.cfg.Backend.Get(ctx, key)
+	if err != nil || !ok {
+		return nil
+	}
+	var resp llm.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil
+	}
+	return &resp
+}
+
+// store saves the response; cache failures never fail the call
+func (c *CachingClient) store(ctx context.Context, req *llm.ChatRequest, resp *llm.Response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	key := c.Key(req)
+	if err := c.cfg.Backend.Set(ctx, key, data, c.cfg.TTL); err != nil {
+		return
+	}
+	if c.cfg.Embedder == nil {
+		return
+	}
+	text, scope := semanticParts(req)
+	if text == "" {
+		return
+	}
+	// Vector IDs carry the scope so lookups can reject matches from other conversations
+	if vec, err := c.cfg.Embedder.EmbedText(ctx, text); err == nil {
+		_ = c.cfg.Vectors.AddDocument(ctx, c.scopeKey(scope)+"|"+key, text, vec)
+	}
+}
+
+// Key returns the exact-match cache key for a request. Whitespace around
+// message content and provider-specific fields (User, Meta) do not affect it.
+func (c *CachingClient) Key(req *llm.ChatRequest) string {
+	model := req.Model
+	if model == "" {
+		model = c.Client.Model()
+	}
+	return c.hash("req", normalized{
+		Model:          model,
+		SystemPrompt:   strings.TrimSpace(req.SystemPrompt),
+		Messages:       normalizeMessages(req.Messages),
+		Temperature:    req.Temperature,
+		MaxTokens:      req.MaxTokens,
+		TopP:           req.TopP,
+		Stop:           req.Stop,
+		Tools:          req.Tools,
+		ToolChoice:     req.ToolChoice,
+		ResponseFormat: req.ResponseFormat,
+		Seed:           req.Seed,
+	})
+}
+
+// scopeKey identifies everything but the last user message, so semantic hits
+// only match requests with the same model, settings and prior conversation
+func (c *CachingClient) scopeKey(scope *llm.ChatRequest) string {
+	return c.Key(scope)
+}
+
+func (c *CachingClient) hash(kind string, v interface{}) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	prefix := "llmcache:"
+	if c.cfg.Namespace != "" {
+		prefix += c.cfg.Namespace + ":"
+	}
+	return prefix + kind + ":" + hex.EncodeToString(sum[:])
+}
+
+type normalized struct {
+	Model          string              `json:"model"`
+	SystemPrompt   string              `json:"system_prompt,omitempty"`
+	Messages       []llm.Message       `json:"messages"`
+	Temperature    *float64            `json:"temperature,omitempty"`
+	MaxTokens      *int                `json:"max_tokens,omitempty"`
+	TopP           *float64            `json:"top_p,omitempty"`
+	Stop           []string            `json:"stop,omitempty"`
+	Tools          []llm.Tool          `json:"tools,omitempty"`
+	ToolChoice     interface{}         `json:"tool_choice,omitempty"`
+	ResponseFormat *llm.ResponseFormat `json:"response_format,omitempty"`
+	Seed           *int                `json:"seed,omitempty"`
+}
+
+func normalizeMessages(msgs []llm.Message) []llm.Message {
+	out := make([]llm.Message, len(msgs))
+	for i, m := range msgs {
+		m.Role = strings.ToLower(strings.TrimSpace(m.Role))
+		m.Content = strings.TrimSpace(m.Content)
+		out[i] = m
+	}
+	return out
+}
+
+// semanticParts splits a request into the last user message and the rest
+func semanticParts(req *llm.ChatRequest) (string, *llm.ChatRequest) {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role != "user" {
+			continue
+		}
+		scope := *req
+		scope.Messages = append(append([]llm.Message(nil), req.Messages[:i]...), req.Messages[i+1:]...)
+		return strings.TrimSpace(req.Messages[i].Content), &scope
+	}
+	return "", nil
+}
+
+func mark(resp *llm.Response, how string) *llm.Response {
+	meta := make(map[string]string, len(resp.Meta)+1)
+	for k, v := range resp.Meta {
+		meta[k] = v
+	}
+	meta["cache"] = how
+	resp.Meta = meta
+	return resp
+}
+
+var _ llm.Client = (*CachingClient)(nil)
@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KamdynS/go-agents/llm"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+type countingClient struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingClient) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	last := req.Messages[len(req.Messages)-1].Content
+	return &llm.Response{Role: "assistant", Content: "answer to " + last, Model: "test"}, nil
+}
+func (c *countingClient) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return c.Chat(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: prompt}}})
+}
+func (c *countingClient) Stream(ctx context.Context, req *llm.ChatRequest, output chan<- *llm.Response) error {
+	defer close(output)
+	resp, _ := c.Chat(ctx, req)
+	for _, part := range strings.SplitAfter(resp.Content, " ") {
+		output <- &llm.Response{Content: part}
+	}
+	return nil
+}
+func (c *countingClient) Model() string          { return "test" }
+func (c *countingClient) Provider() llm.Provider { return llm.ProviderOpenAI }
+func (c *countingClient) Validate() error        { return nil }
+
+func ask(content string) *llm.ChatRequest {
+	return &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: content}}}
+}
+
+func TestCachingClient_ExactHit(t *testing.T) {
+	inner := &countingClient{}
+	c := NewCachingClient(inner, Config{})
+	ctx := context.Background()
+
+	first, err := c.Chat(ctx, ask("hello"))
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if first.Meta["cache"] != "" {
+		t.Fatalf("first call should miss, got %v", first.Meta)
+	}
+	second, _ := c.Chat(ctx, ask("hello"))
+	if inner.calls != 1 || second.Meta["cache"] != "hit" || second.Content != first.Content {
+		t.Fatalf("expected cached reply, calls=%d resp=%+v", inner.calls, second)
+	}
+	if _, _ = c.Chat(ctx, ask("other")); inner.calls != 2 {
+		t.Fatalf("different prompt should miss")
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestCachingClient_KeyNormalization(t *testing.T) {
+	c := NewCachingClient(&countingClient{}, Config{})
+	a := ask("  hello\n")
+	b := ask("hello")
+	b.User = "someone"
+	b.Model = "test"
+	if c.Key(a) != c.Key(b) {
+		t.Fatalf("whitespace, user and default model should not change the key")
+	}
+	temp := 0.5
+	b.Temperature = &temp
+	if c.Key(a) == c.Key(b) {
+		t.Fatalf("sampling settings must change the key")
+	}
+	ns := NewCachingClient(&countingClient{}, Config{Namespace: "tenant"})
+	if !strings.HasPrefix(ns.Key(a), "llmcache:tenant:") {
+		t.Fatalf("namespace missing from %s", ns.Key(a))
+	}
+}
+
+func TestCachingClient_TTL(t *testing.T) {
+	backend := NewMemoryBackend()
+	now := time.Unix(0, 0)
+	backend.now = func() time.Time { return now }
+	inner := &countingClient{}
+	c := NewCachingClient(inner, Config{Backend: backend, TTL: time.Minute})
+	ctx := context.Background()
+
+	_, _ = c.Chat(ctx, ask("hi"))
+	now = now.Add(30 * time.Second)
+	_, _ = c.Chat(ctx, ask("hi"))
+	if inner.calls != 1 {
+		t.Fatalf("entry should still be fresh")
+	}
+	now = now.Add(time.Minute)
+	_, _ = c.Chat(ctx, ask("hi"))
+	if inner.calls != 2 {
+		t.Fatalf("entry should have expired")
+	}
+}
+
+func TestMemoryBackend_BoundsAndSweeps(t *testing.T) {
+	ctx := context.Background()
+	b := newMemoryBackend(2)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+	_ = b.Set(ctx, "a", []byte("1"), 0)
+	_ = b.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = b.Get(ctx, "a") // b is now the least recently used
+	_ = b.Set(ctx, "c", []byte("3"), 0)
+	if _, ok, _ := b.Get(ctx, "b"); ok || b.Len() != 2 {
+		t.Fatalf("least recently used entry should be evicted, len %d", b.Len())
+	}
+
+	b = newMemoryBackend(10)
+	b.now = func() time.Time { return now }
+	_ = b.Set(ctx, "short", []byte("1"), time.Second)
+	now = now.Add(2 * sweepEvery)
+	_ = b.Set(ctx, "other", []byte("2"), 0)
+	if b.Len() != 1 {
+		t.Fatalf("expired entry should be swept without being read, len %d", b.Len())
+	}
+
+	v := newVectorIndex(2, time.Minute)
+	v.now = func() time.Time { return now }
+	_ = v.AddDocument(ctx, "x", "", []float64{1})
+	now = now.Add(2 * time.Minute)
+	_ = v.AddDocument(ctx, "y", "", []float64{1})
+	_ = v.AddDocument(ctx, "z", "", []float64{1})
+	_ = v.AddDocument(ctx, "w", "", []float64{1})
+	if docs, _ := v.QuerySimilar(ctx, []float64{1}, 0); len(docs) != 2 || len(v.docs) != 2 {
+		t.Fatalf("vector index should keep the 2 newest documents, got %d", len(docs))
+	}
+}
+
+// wordEmbedder maps texts to fixed vectors so similarity is predictable
+type wordEmbedder map[string][]float64
+
+func (w wordEmbedder) EmbedText(ctx context.Context, input string) ([]float64, error) {
+	return w[input], nil
+}
+
+func TestCachingClient_SemanticHit(t *testing.T) {
+	emb := wordEmbedder{
+		"what is the capital of france": {1, 0, 0},
+		"what's the capital of france?": {0.99, 0.05, 0},
+		"how tall is everest":           {0, 1, 0},
+	}
+	inner := &countingClient{}
+	c := NewCachingClient(inner, Config{Embedder: emb, Threshold: 0.9})
+	ctx := context.Background()
+
+	_, _ = c.Chat(ctx, ask("what is the capital of france"))
+	resp, _ := c.Chat(ctx, ask("what's the capital of france?"))
+	if inner.calls != 1 || resp.Meta["cache"] != "semantic" {
+		t.Fatalf("expected semantic hit, calls=%d meta=%v", inner.calls, resp.Meta)
+	}
+	_, _ = c.Chat(ctx, ask("how tall is everest"))
+	if inner.calls != 2 {
+		t.Fatalf("dissimilar prompt should miss")
+	}
+
+	// The same question in a different conversation must not match
+	req := ask("what's the capital of france?")
+	req.SystemPrompt = "answer in german"
+	_, _ = c.Chat(ctx, req)
+	if inner.calls != 3 {
+		t.Fatalf("semantic hit must respect the surrounding request")
+	}
+	if s := c.Stats(); s.SemanticHits != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestCachingClient_Stream(t *testing.T) {
+	inner := &countingClient{}
+	c := NewCachingClient(inner, Config{})
+	ctx := context.Background()
+
+	collect := func() string {
+		out := make(chan *llm.Response, 8)
+		if err := c.Stream(ctx, ask("stream me"), out); err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		var sb strings.Builder
+		for r := range out {
+			sb.WriteString(r.Content)
+		}
+		return sb.String()
+	}
+	first := collect()
+	second := collect()
+	if inner.calls != 1 || first != second || first != "answer to stream me" {
+		t.Fatalf("stream not cached: calls=%d %q %q", inner.calls, first, second)
+	}
+	// Streamed responses also serve Chat
+	if resp, _ := c.Chat(ctx, ask("stream me")); resp.Meta["cache"] != "hit" {
+		t.Fatalf("expected hit from streamed entry")
+	}
+}
+
+func TestCachingClient_Metrics(t *testing.T) {
+	m := obs.NewDefaultMetrics()
+	prev := obs.MetricsImpl
+	obs.MetricsImpl = m
+	defer func() { obs.MetricsImpl = prev }()
+
+	c := NewCachingClient(&countingClient{}, Config{})
+	_, _ = c.Chat(context.Background(), ask("x"))
+	_, _ = c.Chat(context.Background(), ask("x"))
+	counters := m.GetStats()["counters"].(map[string]int64)
+	if counters["llm_cache"] != 2 {
+		t.Fatalf("expected 2 cache lookups counted, got %v", counters)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build adapters_redis
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	rds "github.com/redis/go-redis/v9"
+)
+
+// RedisBackend stores cached responses in Redis using native key expiry
+type RedisBackend struct {
+	client *rds.Client
+}
+
+// NewRedisBackend creates a backend on an existing client
+func NewRedisBackend(client *rds.Client) *RedisBackend {
+	return &RedisBackend{client: client}
+}
+
+func (r *RedisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, rds.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (r *RedisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *RedisBackend) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+var _ Backend = (*RedisBackend)(nil)
@@ -0,0 +1,355 @@
+// Package llmtest provides a scripted llm.Client and tool recorders for writing
+// table-driven agent tests without hand-rolled fakes.
+//
+//	model := llmtest.NewClient(t)
+//	model.Expect().UserContains("weather").HasTools("weather").CallTool("weather", `{"input":"Paris"}`)
+//	model.Expect().ToolResult("sunny").Reply("It is sunny in Paris")
+//
+// Each model call consumes the next step, runs its assertions against the
+// request and returns its canned response. Unconsumed steps fail the test at cleanup.
+package llmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/KamdynS/go-agents/llm"
+)
+
+// ErrUnexpectedCall is returned when the model is called after the script is exhausted
+var ErrUnexpectedCall = errors.New("llmtest: unexpected model call")
+
+// TB is the subset of testing.TB used to report failures
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// Client is a scripted llm.Client. It is safe for concurrent use; steps are
+// consumed in call order.
+type Client struct {
+	t     TB
+	model string
+
+	mu       sync.Mutex
+	steps    []*Step
+	next     int
+	requests []llm.ChatRequest
+	callSeq  int
+}
+
+// NewClient returns an empty script; at test cleanup it reports steps that were never called
+func NewClient(t TB) *Client {
+	c := &Client{t: t, model: "llmtest"}
+	t.Cleanup(c.AssertDone)
+	return c
+}
+
+// WithModel sets the name returned by Model and set on responses
+func (c *Client) WithModel(name string) *Client {
+	c.model = name
+	return c
+}
+
+// Expect appends a step to the script
+func (c *Client) Expect() *Step {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &Step{client: c, index: len(c.steps) + 1}
+	c.steps = append(c.steps, s)
+	return s
+}
+
+// Requests returns every request received so far
+func (c *Client) Requests() []llm.ChatRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]llm.ChatRequest(nil), c.requests...)
+}
+
+// AssertDone reports steps that were scripted but never called
+func (c *Client) AssertDone() {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next < len(c.steps) {
+		c.t.Errorf("llmtest: %d of %d scripted model calls were not made", len(c.steps)-c.next, len(c.steps))
+	}
+}
+
+// take records req and returns the next step after checking its assertions
+func (c *Client) take(req *llm.ChatRequest) (*Step, error) {
+	c.t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, *req)
+	if c.next >= len(c.steps) {
+		c.t.Errorf("llmtest: model call %d was not scripted (last message: %q)", len(c.requests), lastContent(req))
+		return nil, ErrUnexpectedCall
+	}
+	s := c.steps[c.next]
+	c.next++
+	for _, chk := range s.checks {
+		if err := chk.fn(req); err != nil {
+			c.t.Errorf("llmtest: step %d: %s: %v", s.index, chk.name, err)
+		}
+	}
+	return s, nil
+}
+
+// toolCallID numbers tool calls across the whole script
+func (c *Client) toolCallID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callSeq++
+	return fmt.Sprintf("call_%d", c.callSeq)
+}
+
+func (c *Client) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	c.t.Helper()
+	s, err := c.take(req)
+	if err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.response(), nil
+}
+
+func (c *Client) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	c.t.Helper()
+	return c.Chat(ctx, &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: prompt}}})
+}
+
+// Stream sends the step's chunks (or its whole response as one chunk); tool
+// calls travel on the last chunk
+func (c *Client) Stream(ctx context.Context, req *llm.ChatRequest, output chan<- *llm.Response) error {
+	c.t.Helper()
+	defer close(output)
+	s, err := c.take(req)
+	if err != nil {
+		return err
+	}
+	if s.err != nil {
+		return s.err
+	}
+	full := s.response()
+	chunks := make([]*llm.Response, 0, len(s.chunks)+1)
+	if len(s.chunks) == 0 {
+		chunks = append(chunks, full)
+	} else {
+		for _, text := range s.chunks {
+			chunks = append(chunks, &llm.Response{Content: text, Role: "assistant", Model: full.Model, Provider: full.Provider})
+		}
+		last := chunks[len(chunks)-1]
+		last.ToolCalls, last.FinishReason, last.Usage = full.ToolCalls, full.FinishReason, full.Usage
+	}
+	for _, r := range chunks {
+		select {
+		case output <- r:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (c *Client) Model() string          { return c.model }
+func (c *Client) Provider() llm.Provider { return llm.Provider("llmtest") }
+func (c *Client) Validate() error        { return nil }
+
+var _ llm.Client = (*Client)(nil)
+
+// Step is one scripted model call: assertions on the request and a canned response
+type Step struct {
+	client *Client
+	index  int
+	checks []check
+
+	content   string
+	toolCalls []llm.ToolCall
+	chunks    []string
+	resp      *llm.Response
+	err       error
+}
+
+type check struct {
+	name string
+	fn   func(*llm.ChatRequest) error
+}
+
+// Match adds a custom assertion on the request
+func (s *Step) Match(name string, fn func(req *llm.ChatRequest) error) *Step {
+	s.checks = append(s.checks, check{name: name, fn: fn})
+	return s
+}
+
+// UserContains asserts the last user message contains substr
+func (s *Step) UserContains(substr string) *Step {
+	return s.Match("user message", func(req *llm.ChatRequest) error {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				return contains(req.Messages[i].Content, substr)
+			}
+		}
+		return errors.New("no user message")
+	})
+}
+
+// SystemContains asserts the system prompt (field or system message) contains substr
+func (s *Step) SystemContains(substr string) *Step {
+	return s.Match("system prompt", func(req *llm.ChatRequest) error {
+		system := req.SystemPrompt
+		for _, m := range req.Messages {
+			if m.Role == "system" {
+				system += "\n" + m.Content
+			}
+		}
+		return contains(system, substr)
+	})
+}
+
+// LastMessage asserts the role of the last message and that it contains substr
+func (s *Step) LastMessage(role, substr string) *Step {
+	return s.Match("last message", func(req *llm.ChatRequest) error {
+		if len(req.Messages) == 0 {
+			return errors.New("no messages")
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role != role {
+			return fmt.Errorf("role is %q, want %q", last.Role, role)
+		}
+		return contains(last.Content, substr)
+	})
+}
+
+// ToolResult asserts some tool message in the request contains substr
+func (s *Step) ToolResult(substr string) *Step {
+	return s.Match("tool result", func(req *llm.ChatRequest) error {
+		var seen []string
+		for _, m := range req.Messages {
+			if m.Role != "tool" {
+				continue
+			}
+			if strings.Contains(m.Content, substr) {
+				return nil
+			}
+			seen = append(seen, m.Content)
+		}
+		return fmt.Errorf("no tool result contains %q (got %q)", substr, seen)
+	})
+}
+
+// MessageCount asserts the number of messages in the request
+func (s *Step) MessageCount(n int) *Step {
+	return s.Match("message count", func(req *llm.ChatRequest) error {
+		if len(req.Messages) != n {
+			return fmt.Errorf("got %d messages, want %d", len(req.Messages), n)
+		}
+		return nil
+	})
+}
+
+// HasTools asserts the request offers at least the named tools
+func (s *Step) HasTools(names ...string) *Step {
+	return s.Match("tools", func(req *llm.ChatRequest) error {
+		offered := toolNames(req)
+		for _, n := range names {
+			if !offered[n] {
+				return fmt.Errorf("tool %q not offered", n)
+			}
+		}
+		return nil
+	})
+}
+
+// NoTools asserts the request offers no tools or disables them with ToolChoice "none"
+func (s *Step) NoTools() *Step {
+	return s.Match("no tools", func(req *llm.ChatRequest) error {
+		if len(req.Tools) == 0 || req.ToolChoice == "none" {
+			return nil
+		}
+		return fmt.Errorf("%d tools offered", len(req.Tools))
+	})
+}
+
+// Reply sets the response text
+func (s *Step) Reply(text string) *Step {
+	s.content = text
+	return s
+}
+
+// CallTool adds a tool call to the response; call it several times for parallel calls
+func (s *Step) CallTool(name, args string) *Step {
+	s.toolCalls = append(s.toolCalls, llm.ToolCall{
+		ID:       s.client.toolCallID(),
+		Type:     "function",
+		Function: llm.Function{Name: name, Arguments: args},
+	})
+	return s
+}
+
+// Stream splits the text response into chunks for Stream calls; Chat returns them joined
+func (s *Step) Stream(chunks ...string) *Step {
+	s.chunks = chunks
+	s.content = strings.Join(chunks, "")
+	return s
+}
+
+// Respond returns resp as is, overriding Reply and CallTool
+func (s *Step) Respond(resp *llm.Response) *Step {
+	s.resp = resp
+	return s
+}
+
+// Fail makes the call return err
+func (s *Step) Fail(err error) *Step {
+	s.err = err
+	return s
+}
+
+func (s *Step) response() *llm.Response {
+	if s.resp != nil {
+		cp := *s.resp
+		return &cp
+	}
+	finish := "stop"
+	if len(s.toolCalls) > 0 {
+		finish = "tool_calls"
+	}
+	return &llm.Response{
+		Content:      s.content,
+		Role:         "assistant",
+		Model:        s.client.model,
+		Provider:     s.client.Provider(),
+		FinishReason: finish,
+		ToolCalls:    append([]llm.ToolCall(nil), s.toolCalls...),
+	}
+}
+
+func contains(s, substr string) error {
+	if strings.Contains(s, substr) {
+		return nil
+	}
+	return fmt.Errorf("%q does not contain %q", s, substr)
+}
+
+func toolNames(req *llm.ChatRequest) map[string]bool {
+	out := make(map[string]bool, len(req.Tools))
+	for _, t := range req.Tools {
+		out[t.Function.Name] = true
+	}
+	return out
+}
+
+func lastContent(req *llm.ChatRequest) string {
+	if len(req.Messages) == 0 {
+		return ""
+	}
+	return req.Messages[len(req.Messages)-1].Content
+}
@@ -0,0 +1,146 @@
+package llmtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	core "github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/llm/llmtest"
+)
+
+// fakeTB records failures so the kit's own reporting can be tested
+type fakeTB struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) finish() {
+	for _, fn := range f.cleanups {
+		fn()
+	}
+}
+
+func TestAgentToolLoop_TableDriven(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		tools []llmtest.ToolCall
+	}{
+		{name: "direct answer", input: "hi", want: "hello"},
+		{name: "one tool", input: "weather in Paris", want: "sunny", tools: []llmtest.ToolCall{{Name: "weather", Input: "Paris"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := llmtest.NewClient(t)
+			step := model.Expect().UserContains(tt.input).SystemContains("helpful").HasTools("weather")
+			for _, c := range tt.tools {
+				step.CallTool(c.Name, `{"input":"`+c.Input+`"}`)
+			}
+			if len(tt.tools) > 0 {
+				model.Expect().ToolResult("sunny").Reply(tt.want)
+			} else {
+				step.Reply(tt.want)
+			}
+
+			rec := llmtest.NewRecorder()
+			agent := core.NewChatAgent(core.ChatConfig{
+				Model:  model,
+				Tools:  rec.Registry(llmtest.StaticTool("weather", "sunny")),
+				Config: core.AgentConfig{SystemPrompt: "You are helpful", MaxIterations: 3},
+			})
+			out, err := agent.Run(context.Background(), core.Message{Role: "user", Content: tt.input})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if out.Content != tt.want {
+				t.Fatalf("got %q, want %q", out.Content, tt.want)
+			}
+			rec.AssertCalls(t, tt.tools...)
+		})
+	}
+}
+
+func TestClient_ReportsFailures(t *testing.T) {
+	tb := &fakeTB{}
+	model := llmtest.NewClient(tb)
+	model.Expect().UserContains("weather").Reply("ok")
+	model.Expect().Reply("never called")
+
+	_, _ = model.Chat(context.Background(), &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hello"}}})
+	tb.finish()
+	if len(tb.errors) != 2 {
+		t.Fatalf("expected an assertion and an unconsumed-step failure, got %q", tb.errors)
+	}
+	if !strings.Contains(tb.errors[0], "does not contain") || !strings.Contains(tb.errors[1], "not made") {
+		t.Fatalf("unexpected messages %q", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	model = llmtest.NewClient(tb)
+	if _, err := model.Completion(context.Background(), "x"); !errors.Is(err, llmtest.ErrUnexpectedCall) {
+		t.Fatalf("expected ErrUnexpectedCall, got %v", err)
+	}
+	if len(tb.errors) != 1 {
+		t.Fatalf("unexpected call should be reported")
+	}
+}
+
+func TestClient_StreamAndErrors(t *testing.T) {
+	model := llmtest.NewClient(t)
+	model.Expect().Stream("a", "b", "c")
+	boom := errors.New("boom")
+	model.Expect().Fail(boom)
+
+	agent := core.NewChatAgent(core.ChatConfig{Model: model, Config: core.AgentConfig{SystemPrompt: "sys"}})
+	out := make(chan core.Message, 8)
+	if err := agent.RunStream(context.Background(), core.Message{Role: "user", Content: "x"}, out); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	var got []string
+	for m := range out {
+		got = append(got, m.Content)
+	}
+	if strings.Join(got, "|") != "a|b|c|abc" {
+		t.Fatalf("unexpected chunks %q", got)
+	}
+	if _, err := agent.Run(context.Background(), core.Message{Role: "user", Content: "y"}); !errors.Is(err, boom) {
+		t.Fatalf("expected scripted error, got %v", err)
+	}
+	if n := len(model.Requests()); n != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d", n)
+	}
+}
+
+func TestRecorder_Assertions(t *testing.T) {
+	rec := llmtest.NewRecorder()
+	tool := rec.Wrap(llmtest.StaticTool("search", "result"))
+	_, _ = tool.Execute(context.Background(), "go generics")
+
+	tb := &fakeTB{}
+	rec.AssertCalled(tb, "search", "go generics")
+	rec.AssertCalled(tb, "search", "")
+	rec.AssertNotCalled(tb, "calculator")
+	if len(tb.errors) != 0 {
+		t.Fatalf("unexpected failures %q", tb.errors)
+	}
+	rec.AssertCalled(tb, "search", "rust")
+	rec.AssertCalled(tb, "calculator", "")
+	rec.AssertNotCalled(tb, "search")
+	rec.AssertCalls(tb, llmtest.ToolCall{Name: "search", Input: "go"})
+	if len(tb.errors) != 4 {
+		t.Fatalf("expected 4 failures, got %q", tb.errors)
+	}
+	if c := rec.Calls(); len(c) != 1 || c[0].Output != "result" {
+		t.Fatalf("unexpected calls %+v", c)
+	}
+}
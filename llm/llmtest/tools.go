@@ -0,0 +1,147 @@
+package llmtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/KamdynS/go-agents/tools"
+)
+
+// ToolCall is a tool execution seen by a Recorder
+type ToolCall struct {
+	Name   string
+	Input  string
+	Output string
+	Err    error
+}
+
+// Recorder wraps tools and records every execution for assertions
+type Recorder struct {
+	mu    sync.Mutex
+	calls []ToolCall
+}
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder { return &Recorder{} }
+
+// Wrap returns tool with its executions recorded
+func (r *Recorder) Wrap(tool tools.Tool) tools.Tool {
+	return &recordedTool{Tool: tool, rec: r}
+}
+
+// Registry wraps the tools and registers them in a new registry
+func (r *Recorder) Registry(ts ...tools.Tool) *tools.DefaultRegistry {
+	reg := tools.NewRegistry()
+	for _, t := range ts {
+		if err := reg.Register(r.Wrap(t)); err != nil {
+			panic(fmt.Sprintf("llmtest: register %s: %v", t.Name(), err))
+		}
+	}
+	return reg
+}
+
+// Calls returns the recorded executions in order
+func (r *Recorder) Calls() []ToolCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ToolCall(nil), r.calls...)
+}
+
+// AssertCalled reports an error unless name was executed with input ("" matches any input)
+func (r *Recorder) AssertCalled(t TB, name, input string) {
+	t.Helper()
+	var inputs []string
+	for _, c := range r.Calls() {
+		if c.Name != name {
+			continue
+		}
+		if input == "" || c.Input == input {
+			return
+		}
+		inputs = append(inputs, c.Input)
+	}
+	if inputs == nil {
+		t.Errorf("llmtest: tool %q was not called (calls: %v)", name, r.names())
+		return
+	}
+	t.Errorf("llmtest: tool %q was called with %q, want %q", name, inputs, input)
+}
+
+// AssertNotCalled reports an error if name was executed
+func (r *Recorder) AssertNotCalled(t TB, name string) {
+	t.Helper()
+	for _, c := range r.Calls() {
+		if c.Name == name {
+			t.Errorf("llmtest: tool %q was called with %q", name, c.Input)
+			return
+		}
+	}
+}
+
+// AssertCalls reports an error unless the executions match want in order.
+// Only Name and Input are compared.
+func (r *Recorder) AssertCalls(t TB, want ...ToolCall) {
+	t.Helper()
+	got := r.Calls()
+	if len(got) != len(want) {
+		t.Errorf("llmtest: got %d tool calls %v, want %d", len(got), r.names(), len(want))
+		return
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Input != want[i].Input {
+			t.Errorf("llmtest: tool call %d is %s(%q), want %s(%q)", i+1, got[i].Name, got[i].Input, want[i].Name, want[i].Input)
+		}
+	}
+}
+
+func (r *Recorder) names() []string {
+	calls := r.Calls()
+	out := make([]string, len(calls))
+	for i, c := range calls {
+		out[i] = c.Name
+	}
+	return out
+}
+
+type recordedTool struct {
+	tools.Tool
+	rec *Recorder
+}
+
+func (t *recordedTool) Execute(ctx context.Context, input string) (string, error) {
+	out, err := t.Tool.Execute(ctx, input)
+	t.rec.mu.Lock()
+	t.rec.calls = append(t.rec.calls, ToolCall{Name: t.Name(), Input: input, Output: out, Err: err})
+	t.rec.mu.Unlock()
+	return out, err
+}
+
+// FuncTool is a tools.Tool backed by a function, for tests
+type FuncTool struct {
+	ToolName string
+	Desc     string
+	Fn       func(ctx context.Context, input string) (string, error)
+}
+
+// StaticTool returns a tool that always answers output
+func StaticTool(name, output string) *FuncTool {
+	return &FuncTool{ToolName: name, Fn: func(context.Context, string) (string, error) { return output, nil }}
+}
+
+func (f *FuncTool) Name() string        { return f.ToolName }
+func (f *FuncTool) Description() string { return f.Desc }
+func (f *FuncTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"input": map[string]interface{}{"type": "string"}},
+	}
+}
+func (f *FuncTool) Execute(ctx context.Context, input string) (string, error) {
+	return f.Fn(ctx, input)
+}
+
+var (
+	_ tools.Tool = (*recordedTool)(nil)
+	_ tools.Tool = (*FuncTool)(nil)
+)
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -400,3 +401,66 @@ func ParseStructured[T Structured](jsonStr string, template T) (*StructuredRespo
 		Validation: validationResult,
 	}, nil
 }
+
+// StructuredChat performs a structured-output chat against any Client. Provider packages
+// ship tuned variants; this one relies only on the Client interface so it works with
+// routers, wrappers and test doubles.
+func StructuredChat[T Structured](ctx context.Context, c Client, req StructuredRequest[T]) (*StructuredResponse[T], error) {
+	schema := req.Schema
+	if schema == nil {
+		schema = req.OutputType.JSONSchema()
+	}
+	systemPrompt := req.SystemPrompt
+	if systemPrompt != "" {
+		systemPrompt += "\n\n"
+	}
+	systemPrompt += "You must respond ONLY with a JSON object matching the provided schema. Do not add explanations."
+
+	// Copy messages so the caller's slice is not modified
+	messages := make([]Message, len(req.Messages))
+	copy(messages, req.Messages)
+	if len(messages) > 0 && messages[len(messages)-1].Role == "user" {
+		last := &messages[len(messages)-1]
+		if schemaBytes, err := json.MarshalIndent(schema, "", "  "); err == nil {
+			last.Content += fmt.Sprintf("\n\nRespond with valid JSON matching this schema:\n```json\n%s\n```", string(schemaBytes))
+		}
+	}
+
+	chatReq := &ChatRequest{
+		Messages:       messages,
+		SystemPrompt:   systemPrompt,
+		Model:          req.Model,
+		ResponseFormat: &ResponseFormat{Type: "json_object", JSONSchema: schema},
+	}
+	if req.Temperature != 0 {
+		chatReq.Temperature = &req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		chatReq.MaxTokens = &req.MaxTokens
+	}
+
+	resp, err := c.Chat(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	structuredResp, err := ParseStructured(ExtractJSON(resp.Content), req.OutputType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse structured output: %w", err)
+	}
+	structuredResp.RawResponse = resp
+	structuredResp.Usage = resp.Usage
+	return structuredResp, nil
+}
+
+// ExtractJSON strips Markdown code fences and surrounding prose from a model
+// reply, keeping the outermost JSON object or array
+func ExtractJSON(s string) string {
+	s = strings.TrimSpace(s)
+	start := strings.IndexAny(s, "{[")
+	end := strings.LastIndexAny(s, "}]")
+	if start < 0 || end < start {
+		return s
+	}
+	return s[start : end+1]
+}
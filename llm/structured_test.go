@@ -1,6 +1,9 @@
 package llm
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestStructuredValidationAndSchema(t *testing.T) {
 	// Sentiment schema has enum and bounds
@@ -25,3 +28,18 @@ func TestStructuredValidationAndSchema(t *testing.T) {
 		t.Fatalf("expected validation error")
 	}
 }
+
+func TestStructuredChat_GenericClient(t *testing.T) {
+	c := dummyClient{id: "```json\n{\"sentiment\":\"positive\",\"score\":0.9}\n```"}
+	msgs := []Message{{Role: "user", Content: "great product"}}
+	resp, err := StructuredChat(context.Background(), c, StructuredRequest[Sentiment]{Messages: msgs, OutputType: Sentiment{}})
+	if err != nil {
+		t.Fatalf("structured chat: %v", err)
+	}
+	if resp.Data.Sentiment != "positive" || resp.RawResponse == nil {
+		t.Fatalf("unexpected result: %+v", resp)
+	}
+	if msgs[0].Content != "great product" {
+		t.Fatalf("caller messages should not be modified")
+	}
+}
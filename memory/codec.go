@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec serializes values for backends that store bytes
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// gobCodec only decodes into concrete types, so use it through Get/RetrieveInto
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+var (
+	// JSONCodec is the default codec of byte-oriented backends
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec is a compact binary codec for Go types
+	GobCodec Codec = gobCodec{}
+
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{"json": JSONCodec, "gob": GobCodec}
+)
+
+// RegisterCodec makes a codec available by name (e.g. a msgpack implementation)
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// LookupCodec returns a registered codec
+func LookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// TypedStore is implemented by backends that can decode a stored value
+// directly into a typed destination instead of a generic interface{}
+type TypedStore interface {
+	Store
+	// RetrieveInto decodes the value at key into out, which must be a non-nil pointer
+	RetrieveInto(ctx context.Context, key string, out interface{}) error
+}
+
+// Get retrieves key and returns it as T. It works with every backend: typed
+// stores decode directly, others have their generic value converted.
+func Get[T any](ctx context.Context, s Store, key string) (T, error) {
+	var out T
+	if ts, ok := s.(TypedStore); ok {
+		err := ts.RetrieveInto(ctx, key, &out)
+		return out, err
+	}
+	raw, err := s.Retrieve(ctx, key)
+	if err != nil {
+		return out, err
+	}
+	err = Decode(raw, &out)
+	return out, err
+}
+
+// Put stores v under key; pair it with Get to read it back as T
+func Put[T any](ctx context.Context, s Store, key string, v T) error {
+	return s.Store(ctx, key, v)
+}
+
+// Decode converts a value returned by Retrieve into out, a non-nil pointer.
+// Values of the right type are assigned; []byte and JSON strings are decoded;
+// anything else (e.g. map[string]interface{} from a JSON backend) is
+// converted through JSON.
+func Decode(raw interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode: destination must be a non-nil pointer, got %T", out)
+	}
+	dst := rv.Elem()
+	if raw == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if v := reflect.ValueOf(raw); v.Type().AssignableTo(dst.Type()) {
+		dst.Set(v)
+		return nil
+	}
+	switch r := raw.(type) {
+	case []byte:
+		return json.Unmarshal(r, out)
+	case json.RawMessage:
+		return json.Unmarshal(r, out)
+	case string:
+		if err := json.Unmarshal([]byte(r), out); err != nil {
+			return fmt.Errorf("decode %T from string: %w", dst.Interface(), err)
+		}
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("decode %T into %T: %w", raw, dst.Interface(), err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package encrypted
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// sealedMessage is the private part of a message, sealed into Message.Content
+type sealedMessage struct {
+	Content   string            `json:"c,omitempty"`
+	Meta      map[string]string `json:"m,omitempty"`
+	ToolCalls []memory.ToolCall `json:"t,omitempty"`
+}
+
+// conversationStore seals message contents under their session ID
+type conversationStore struct {
+	*store
+	conv memory.ConversationStore
+}
+
+func (cs *conversationStore) sealMessage(ctx context.Context, sessionID string, msg memory.Message) (memory.Message, error) {
+	raw, err := json.Marshal(sealedMessage{Content: msg.Content, Meta: msg.Meta, ToolCalls: msg.ToolCalls})
+	if err != nil {
+		return msg, err
+	}
+	sealed, err := cs.sealer.seal(ctx, raw, sessionID)
+	if err != nil {
+		return msg, err
+	}
+	msg.Content, msg.Meta, msg.ToolCalls = sealed, nil, nil
+	return msg, nil
+}
+
+func (cs *conversationStore) openMessage(ctx context.Context, sessionID string, msg memory.Message) (memory.Message, error) {
+	if !isSealed(msg.Content) {
+		if !cs.allowPlaintext {
+			return msg, fmt.Errorf("session %s: %w", sessionID, ErrNotEncrypted)
+		}
+		return msg, nil
+	}
+	raw, err := cs.sealer.open(ctx, msg.Content, sessionID)
+	if err != nil {
+		return msg, fmt.Errorf("session %s: %w", sessionID, err)
+	}
+	var p sealedMessage
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return msg, fmt.Errorf("session %s: %w", sessionID, err)
+	}
+	msg.Content, msg.Meta, msg.ToolCalls = p.Content, p.Meta, p.ToolCalls
+	return msg, nil
+}
+
+// AppendMessage implements memory.ConversationStore
+func (cs *conversationStore) AppendMessage(ctx context.Context, sessionID string, role, content string) error {
+	msg, err := cs.sealMessage(ctx, sessionID, memory.Message{Content: content})
+	if err != nil {
+		return err
+	}
+	return cs.conv.AppendMessage(ctx, sessionID, role, msg.Content)
+}
+
+// GetMessages implements memory.ConversationStore
+func (cs *conversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
+	msgs, err := cs.conv.GetMessages(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		if msgs[i], err = cs.openMessage(ctx, sessionID, msgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return msgs, nil
+}
+
+func (cs *conversationStore) ClearSession(ctx context.Context, sessionID string) error {
+	return cs.conv.ClearSession(ctx, sessionID)
+}
+
+// Sessions implements memory.SessionLister from the inner store, which only
+// needs session IDs, timestamps and message counts
+func (cs *conversationStore) Sessions(ctx context.Context) ([]memory.SessionInfo, error) {
+	return memory.ListSessions(ctx, cs.conv)
+}
+
+// branchingStore adds the tree operations of a branching inner store
+type branchingStore struct {
+	*conversationStore
+	branching memory.BranchingStore
+}
+
+// AddMessage implements memory.BranchingStore
+func (bs *branchingStore) AddMessage(ctx context.Context, sessionID, parentID string, msg memory.Message) (string, error) {
+	sealed, err := bs.sealMessage(ctx, sessionID, msg)
+	if err != nil {
+		return "", err
+	}
+	return bs.branching.AddMessage(ctx, sessionID, parentID, sealed)
+}
+
+// Append implements memory.MessageAppender; without an appending inner store
+// it adds after the head, which is not atomic
+func (bs *branchingStore) Append(ctx context.Context, sessionID string, msg memory.Message) (string, error) {
+	sealed, err := bs.sealMessage(ctx, sessionID, msg)
+	if err != nil {
+		return "", err
+	}
+	if ap, ok := bs.branching.(memory.MessageAppender); ok {
+		return ap.Append(ctx, sessionID, sealed)
+	}
+	head, err := bs.branching.Head(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return bs.branching.AddMessage(ctx, sessionID, head, sealed)
+}
+
+func (bs *branchingStore) Head(ctx context.Context, sessionID string) (string, error) {
+	return bs.branching.Head(ctx, sessionID)
+}
+
+// Path implements memory.BranchingStore
+func (bs *branchingStore) Path(ctx context.Context, sessionID, messageID string) ([]memory.Node, error) {
+	nodes, err := bs.branching.Path(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		if nodes[i].Message, err = bs.openMessage(ctx, sessionID, nodes[i].Message); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+func (bs *branchingStore) Fork(ctx context.Context, sessionID, messageID string) error {
+	return bs.branching.Fork(ctx, sessionID, messageID)
+}
+
+func (bs *branchingStore) Branches(ctx context.Context, sessionID string) ([]memory.Branch, error) {
+	return bs.branching.Branches(ctx, sessionID)
+}
+
+func (bs *branchingStore) SwitchBranch(ctx context.Context, sessionID, leafID string) error {
+	return bs.branching.SwitchBranch(ctx, sessionID, leafID)
+}
+
+var (
+	_ memory.ConversationStore = (*conversationStore)(nil)
+	_ memory.SessionLister     = (*conversationStore)(nil)
+	_ memory.BranchingStore    = (*branchingStore)(nil)
+	_ memory.MessageAppender   = (*branchingStore)(nil)
+)
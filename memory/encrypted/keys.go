@@ -0,0 +1,121 @@
+package encrypted
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownKey is returned when a ciphertext names a key the provider does not have
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeyProvider wraps the per-value data keys with key-encryption keys (KEKs).
+// WrapKey uses the current KEK and returns its ID, which is stored with the
+// ciphertext; UnwrapKey must keep accepting the IDs of retired KEKs so old
+// values stay readable after a rotation. Implementations backed by a KMS can
+// cache unwrapped keys to save round trips.
+type KeyProvider interface {
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyring is a KeyProvider holding AES-256 KEKs in process memory (e.g.
+// loaded from a secret manager at startup). Rotate adds a new current key;
+// older keys stay available for reads.
+type Keyring struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring returns a keyring whose current key is keys[current]. Keys must be 32 bytes.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for id, k := range keys {
+		if err := kr.add(id, k); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := kr.keys[current]; !ok {
+		return nil, fmt.Errorf("keyring: current key %q: %w", current, ErrUnknownKey)
+	}
+	kr.current = current
+	return kr, nil
+}
+
+func (kr *Keyring) add(id string, key []byte) error {
+	if id == "" || len(id) > 255 {
+		return fmt.Errorf("keyring: key ID must be 1-255 bytes, got %q", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("keyring: key %q must be 32 bytes, got %d", id, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	kr.keys[id] = gcm
+	return nil
+}
+
+// Rotate adds key under id and makes it current. Existing values keep their
+// old key ID and remain readable as long as that key stays in the ring.
+func (kr *Keyring) Rotate(id string, key []byte) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.keys[id]; ok {
+		return fmt.Errorf("keyring: key %q already exists", id)
+	}
+	if err := kr.add(id, key); err != nil {
+		return err
+	}
+	kr.current = id
+	return nil
+}
+
+// Current returns the ID of the key used for new values
+func (kr *Keyring) Current() string {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.current
+}
+
+// WrapKey implements KeyProvider; the key ID is authenticated with the wrapped key
+func (kr *Keyring) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	kr.mu.RLock()
+	id, gcm := kr.current, kr.keys[kr.current]
+	kr.mu.RUnlock()
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(dataKey)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return id, gcm.Seal(nonce, nonce, dataKey, []byte(id)), nil
+}
+
+// UnwrapKey implements KeyProvider
+func (kr *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	kr.mu.RLock()
+	gcm, ok := kr.keys[keyID]
+	kr.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("keyring: %q: %w", keyID, ErrUnknownKey)
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	dataKey, err := gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return dataKey, nil
+}
+
+var _ KeyProvider = (*Keyring)(nil)
@@ -0,0 +1,162 @@
+package encrypted
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrDecrypt is returned when a ciphertext is malformed, was tampered with,
+// or was moved to another key or session
+var ErrDecrypt = errors.New("decryption failed")
+
+// prefix marks sealed values, so plaintext written before encryption was
+// enabled can be told apart (see Config.AllowPlaintext)
+const prefix = "enc:v1:"
+
+const (
+	formatVersion  = 1
+	flagCompressed = 1 << 0
+	// minCompress is the smallest plaintext worth compressing
+	minCompress = 256
+	dataKeySize = 32
+)
+
+// sealer encrypts values with a fresh AES-256-GCM data key each, wrapped by
+// the key provider (envelope encryption). A sealed value is prefix followed by
+// base64 of:
+//
+//	version(1) flags(1) len(keyID)(1) keyID len(wrapped)(2) wrapped nonce(12) ciphertext
+//
+// Everything before the nonce, plus the caller's context (store key or
+// session ID), is authenticated as additional data.
+type sealer struct {
+	keys     KeyProvider
+	compress bool
+}
+
+func (s *sealer) seal(ctx context.Context, plaintext []byte, aad string) (string, error) {
+	var flags byte
+	if s.compress && len(plaintext) >= minCompress {
+		if c, err := deflate(plaintext); err == nil && len(c) < len(plaintext) {
+			plaintext, flags = c, flagCompressed
+		}
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	keyID, wrapped, err := s.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key: %w", err)
+	}
+	if len(keyID) == 0 || len(keyID) > 255 || len(wrapped) > 0xffff {
+		return "", fmt.Errorf("wrap data key: key ID or wrapped key too long")
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 0, 5+len(keyID)+len(wrapped)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	buf = append(buf, formatVersion, flags, byte(len(keyID)))
+	buf = append(buf, keyID...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(wrapped)))
+	buf = append(buf, wrapped...)
+	header := len(buf)
+	buf = buf[:header+gcm.NonceSize()]
+	if _, err := rand.Read(buf[header:]); err != nil {
+		return "", err
+	}
+	buf = gcm.Seal(buf, buf[header:], plaintext, additionalData(buf[:header], aad))
+	return prefix + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func (s *sealer) open(ctx context.Context, sealed string, aad string) ([]byte, error) {
+	buf, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, prefix))
+	if err != nil || len(buf) < 3 || buf[0] != formatVersion {
+		return nil, ErrDecrypt
+	}
+	flags, idLen := buf[1], int(buf[2])
+	if len(buf) < 3+idLen+2 {
+		return nil, ErrDecrypt
+	}
+	keyID := string(buf[3 : 3+idLen])
+	off := 3 + idLen
+	wrappedLen := int(binary.BigEndian.Uint16(buf[off:]))
+	off += 2
+	if len(buf) < off+wrappedLen {
+		return nil, ErrDecrypt
+	}
+	wrapped := buf[off : off+wrappedLen]
+	header := off + wrappedLen
+
+	dataKey, err := s.keys.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if len(buf) < header+gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce := buf[header : header+gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, buf[header+gcm.NonceSize():], additionalData(buf[:header], aad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if flags&flagCompressed != 0 {
+		return inflate(plaintext)
+	}
+	return plaintext, nil
+}
+
+// isSealed reports whether v is a value written by seal
+func isSealed(v string) bool { return strings.HasPrefix(v, prefix) }
+
+func additionalData(header []byte, aad string) []byte {
+	out := make([]byte, 0, len(header)+len(aad))
+	return append(append(out, header...), aad...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func deflate(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func inflate(b []byte) ([]byte, error) {
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return out, nil
+}
@@ -0,0 +1,197 @@
+// Package encrypted provides memory store decorators that encrypt values and
+// message contents at rest with AES-256-GCM and per-value data keys wrapped by
+// a KeyProvider. Keys, session IDs, roles and timestamps stay in plaintext so
+// backends can index and list them.
+package encrypted
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// ErrNotEncrypted is returned when a stored value is not a sealed value and
+// Config.AllowPlaintext is off
+var ErrNotEncrypted = errors.New("value is not encrypted")
+
+// Config configures the encrypting decorators
+type Config struct {
+	// Keys wraps data keys (required); see Keyring
+	Keys KeyProvider
+	// Compress deflates values of 256 bytes or more before encryption
+	Compress bool
+	// AllowPlaintext returns values written before encryption was enabled as
+	// they are instead of failing with ErrNotEncrypted. New writes are always
+	// encrypted, so turn it off once old data has been rewritten or expired.
+	AllowPlaintext bool
+}
+
+// NewStore wraps inner so values are stored encrypted. Values are JSON encoded
+// before sealing, so Retrieve returns generic JSON values (maps, slices,
+// float64); use memory.Get to read the stored type back. The result
+// implements memory.TypedStore, and memory.VersionedStore when inner does.
+func NewStore(inner memory.Store, cfg Config) (memory.Store, error) {
+	s, err := newStore(inner, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if vs, ok := inner.(memory.VersionedStore); ok {
+		return &versionedStore{store: s, versioned: versioned{s, vs}}, nil
+	}
+	return s, nil
+}
+
+// NewConversationStore wraps inner so values and message contents (content,
+// meta and tool calls) are stored encrypted; roles, timestamps and tool call
+// IDs stay readable. The result implements memory.BranchingStore,
+// memory.MessageAppender and memory.VersionedStore when inner does, and
+// memory.SessionLister.
+func NewConversationStore(inner memory.ConversationStore, cfg Config) (memory.ConversationStore, error) {
+	s, err := newStore(inner, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cs := &conversationStore{store: s, conv: inner}
+	vs, isVersioned := inner.(memory.VersionedStore)
+	if bs, ok := inner.(memory.BranchingStore); ok {
+		b := &branchingStore{conversationStore: cs, branching: bs}
+		if isVersioned {
+			return &versionedBranchingStore{branchingStore: b, versioned: versioned{s, vs}}, nil
+		}
+		return b, nil
+	}
+	if isVersioned {
+		return &versionedConversationStore{conversationStore: cs, versioned: versioned{s, vs}}, nil
+	}
+	return cs, nil
+}
+
+func newStore(inner memory.Store, cfg Config) (*store, error) {
+	if inner == nil {
+		return nil, errors.New("encrypted: nil inner store")
+	}
+	if cfg.Keys == nil {
+		return nil, errors.New("encrypted: Config.Keys is required")
+	}
+	return &store{inner: inner, sealer: &sealer{keys: cfg.Keys, compress: cfg.Compress}, allowPlaintext: cfg.AllowPlaintext}, nil
+}
+
+// store seals values under their key, so a ciphertext copied to another key fails to open
+type store struct {
+	inner          memory.Store
+	sealer         *sealer
+	allowPlaintext bool
+}
+
+func (s *store) sealValue(ctx context.Context, key string, value interface{}) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return s.sealer.seal(ctx, raw, key)
+}
+
+// decode opens a raw value read from the inner store into out
+func (s *store) decode(ctx context.Context, key string, raw interface{}, out interface{}) error {
+	sealed, ok := raw.(string)
+	if !ok || !isSealed(sealed) {
+		if !s.allowPlaintext {
+			return fmt.Errorf("key %s: %w", key, ErrNotEncrypted)
+		}
+		return memory.Decode(raw, out)
+	}
+	plaintext, err := s.sealer.open(ctx, sealed, key)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", key, err)
+	}
+	return json.Unmarshal(plaintext, out)
+}
+
+// Store implements memory.Store
+func (s *store) Store(ctx context.Context, key string, value interface{}) error {
+	sealed, err := s.sealValue(ctx, key, value)
+	if err != nil {
+		return err
+	}
+	return s.inner.Store(ctx, key, sealed)
+}
+
+// Retrieve implements memory.Store
+func (s *store) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	var out interface{}
+	if err := s.RetrieveInto(ctx, key, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RetrieveInto implements memory.TypedStore
+func (s *store) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	raw, err := s.inner.Retrieve(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.decode(ctx, key, raw, out)
+}
+
+func (s *store) Delete(ctx context.Context, key string) error { return s.inner.Delete(ctx, key) }
+
+func (s *store) List(ctx context.Context) ([]string, error) { return s.inner.List(ctx) }
+
+func (s *store) Clear(ctx context.Context) error { return s.inner.Clear(ctx) }
+
+// versioned passes compare-and-swap through to a versioned inner store,
+// sealing values like s
+type versioned struct {
+	s     *store
+	inner memory.VersionedStore
+}
+
+// RetrieveVersion implements memory.VersionedStore
+func (v versioned) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	var raw interface{}
+	version, err := v.inner.RetrieveVersion(ctx, key, &raw)
+	if err != nil || version == 0 {
+		return version, err
+	}
+	return version, v.s.decode(ctx, key, raw, out)
+}
+
+// CompareAndSwap implements memory.VersionedStore
+func (v versioned) CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error) {
+	sealed, err := v.s.sealValue(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+	return v.inner.CompareAndSwap(ctx, key, version, sealed)
+}
+
+// versionedStore is a store over a versioned inner store
+type versionedStore struct {
+	*store
+	versioned
+}
+
+// versionedConversationStore and versionedBranchingStore keep the inner
+// conversation store's versioning
+type versionedConversationStore struct {
+	*conversationStore
+	versioned
+}
+
+type versionedBranchingStore struct {
+	*branchingStore
+	versioned
+}
+
+var (
+	_ memory.TypedStore     = (*store)(nil)
+	_ memory.VersionedStore = (*versionedStore)(nil)
+	_ memory.VersionedStore = (*versionedConversationStore)(nil)
+	_ memory.VersionedStore = (*versionedBranchingStore)(nil)
+	_ memory.BranchingStore = (*versionedBranchingStore)(nil)
+	_ memory.SessionLister  = (*versionedBranchingStore)(nil)
+)
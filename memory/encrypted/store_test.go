@@ -0,0 +1,192 @@
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/file"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+func key(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+func newKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring("k1", map[string][]byte{"k1": key(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestStore_CiphertextAtRest(t *testing.T) {
+	ctx := context.Background()
+	inner := inmemory.NewStore()
+	s, _ := NewStore(inner, Config{Keys: newKeyring(t)})
+
+	_ = s.Store(ctx, "ssn", map[string]string{"number": "123-45-6789"})
+	raw, _ := inner.Retrieve(ctx, "ssn")
+	if str, ok := raw.(string); !ok || !isSealed(str) || strings.Contains(str, "6789") {
+		t.Fatalf("inner store holds plaintext: %v", raw)
+	}
+	got, err := memory.Get[map[string]string](ctx, s, "ssn")
+	if err != nil || got["number"] != "123-45-6789" {
+		t.Fatalf("round trip: %v (%v)", got, err)
+	}
+
+	// Ciphertexts are bound to their key
+	_ = inner.Store(ctx, "other", raw)
+	if _, err := s.Retrieve(ctx, "other"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("moved ciphertext should not open, got %v", err)
+	}
+	tampered := raw.(string)
+	tampered = tampered[:len(tampered)-4] + "AAA="
+	_ = inner.Store(ctx, "ssn", tampered)
+	if _, err := s.Retrieve(ctx, "ssn"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("tampered ciphertext should not open, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	kr := newKeyring(t)
+	inner := inmemory.NewStore()
+	s, _ := NewStore(inner, Config{Keys: kr})
+
+	_ = s.Store(ctx, "old", "before rotation")
+	if err := kr.Rotate("k2", key(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.Rotate("k2", key(3)); err == nil {
+		t.Fatal("rotating to an existing key ID should fail")
+	}
+	_ = s.Store(ctx, "new", "after rotation")
+
+	for k, want := range map[string]string{"old": "before rotation", "new": "after rotation"} {
+		if got, err := memory.Get[string](ctx, s, k); err != nil || got != want {
+			t.Fatalf("%s: %q (%v)", k, got, err)
+		}
+	}
+
+	// A reader without the retired key can only open new values
+	k2Only, _ := NewKeyring("k2", map[string][]byte{"k2": key(2)})
+	reader, _ := NewStore(inner, Config{Keys: k2Only})
+	if _, err := reader.Retrieve(ctx, "new"); err != nil {
+		t.Fatalf("new value: %v", err)
+	}
+	if _, err := reader.Retrieve(ctx, "old"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("want ErrUnknownKey for a retired key, got %v", err)
+	}
+}
+
+func TestPlaintextMigration(t *testing.T) {
+	ctx := context.Background()
+	inner := inmemory.NewConversationStore()
+	_ = inner.Store(ctx, "legacy", "plain")
+	_ = inner.AppendMessage(ctx, "s", "user", "written before encryption")
+
+	strict, _ := NewConversationStore(inner, Config{Keys: newKeyring(t)})
+	if _, err := strict.Retrieve(ctx, "legacy"); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("want ErrNotEncrypted, got %v", err)
+	}
+	if _, err := strict.GetMessages(ctx, "s"); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("want ErrNotEncrypted for messages, got %v", err)
+	}
+
+	lenient, _ := NewConversationStore(inner, Config{Keys: newKeyring(t), AllowPlaintext: true})
+	if v, err := lenient.Retrieve(ctx, "legacy"); err != nil || v != "plain" {
+		t.Fatalf("legacy value: %v (%v)", v, err)
+	}
+	_ = lenient.AppendMessage(ctx, "s", "assistant", "written after")
+	msgs, err := lenient.GetMessages(ctx, "s")
+	if err != nil || len(msgs) != 2 || msgs[0].Content != "written before encryption" || msgs[1].Content != "written after" {
+		t.Fatalf("mixed history: %+v (%v)", msgs, err)
+	}
+	if raw, _ := inner.GetMessages(ctx, "s"); !isSealed(raw[1].Content) {
+		t.Fatalf("new messages must be encrypted: %+v", raw[1])
+	}
+}
+
+func TestConversationStore_SealsMessageFields(t *testing.T) {
+	ctx := context.Background()
+	inner := inmemory.NewConversationStore()
+	cs, _ := NewConversationStore(inner, Config{Keys: newKeyring(t), Compress: true})
+	ap := cs.(memory.MessageAppender)
+
+	long := strings.Repeat("my card is 4111 1111 1111 1111. ", 40)
+	_, _ = ap.Append(ctx, "s", memory.Message{Role: "user", Content: long, Meta: map[string]string{"email": "a@example.com"}, Timestamp: 42})
+	_, _ = ap.Append(ctx, "s", memory.Message{Role: "assistant", ToolCalls: []memory.ToolCall{{ID: "c1", Name: "charge", Arguments: `{"card":"4111"}`}}})
+
+	raw, _ := inner.GetMessages(ctx, "s")
+	if raw[0].Role != "user" || raw[0].Timestamp != 42 || raw[0].Meta != nil || raw[1].ToolCalls != nil {
+		t.Fatalf("only content, meta and tool calls should be sealed: %+v", raw)
+	}
+	if len(raw[0].Content) >= len(long) {
+		t.Fatalf("compressible content should shrink, got %d bytes for %d", len(raw[0].Content), len(long))
+	}
+
+	msgs, err := cs.GetMessages(ctx, "s")
+	if err != nil || msgs[0].Content != long || msgs[0].Meta["email"] != "a@example.com" || msgs[1].ToolCalls[0].Arguments != `{"card":"4111"}` {
+		t.Fatalf("round trip: %+v (%v)", msgs, err)
+	}
+
+	// Messages are bound to their session
+	_, _ = inner.AddMessage(ctx, "other", "", raw[0])
+	if _, err := cs.GetMessages(ctx, "other"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("message copied to another session should not open, got %v", err)
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	if _, err := NewStore(inmemory.NewStore(), Config{}); err == nil {
+		t.Fatal("missing Keys should fail")
+	}
+	if _, err := NewKeyring("k1", map[string][]byte{"k1": []byte("short")}); err == nil {
+		t.Fatal("short key should fail")
+	}
+	if _, err := NewKeyring("missing", map[string][]byte{"k1": key(1)}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("unknown current key: %v", err)
+	}
+	s, _ := NewStore(inmemory.NewStore(), Config{Keys: newKeyring(t)})
+	if _, ok := s.(memory.VersionedStore); !ok {
+		t.Fatal("versioned inner store should stay versioned")
+	}
+	cs, _ := NewConversationStore(inmemory.NewConversationStore(), Config{Keys: newKeyring(t)})
+	if _, ok := cs.(memory.BranchingStore); !ok {
+		t.Fatal("branching inner store should stay branching")
+	}
+}
+
+func TestConversationStore_KeepsVersioning(t *testing.T) {
+	ctx := context.Background()
+	inner, err := file.NewConversationStore(file.Config{Dir: t.TempDir(), Sync: file.SyncNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inner.Close()
+	cs, _ := NewConversationStore(inner, Config{Keys: newKeyring(t)})
+	vs, ok := cs.(memory.VersionedStore)
+	if _, branching := cs.(memory.BranchingStore); !ok || !branching {
+		t.Fatalf("versioned branching inner store should stay both, got %T", cs)
+	}
+	if err := memory.Update(ctx, cs, "profile", func(cur map[string]string, _ bool) (map[string]string, error) {
+		return map[string]string{"name": "ada"}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	v, err := vs.RetrieveVersion(ctx, "profile", &got)
+	if err != nil || v == 0 || got["name"] != "ada" {
+		t.Fatalf("versioned read: %v %v (%v)", v, got, err)
+	}
+	if raw, _ := inner.Retrieve(ctx, "profile"); !isSealed(raw.(string)) {
+		t.Fatalf("CAS wrote plaintext: %v", raw)
+	}
+	if _, err := vs.CompareAndSwap(ctx, "profile", v+1, got); !errors.Is(err, memory.ErrVersionConflict) {
+		t.Fatalf("stale version should conflict, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package file
+
+import "os"
+
+// lockFile only creates the lock file: other platforms are not locked
+func lockFile(path string, exclusive bool) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o644)
+}
@@ -0,0 +1,403 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by operations on a closed store
+var ErrClosed = errors.New("file store closed")
+
+// ErrLocked is returned when another process has the log open for writing,
+// or holds it open while a writer tries to open it
+var ErrLocked = errors.New("file store is in use by another process")
+
+// ErrReadOnly is returned by writes to a store opened with Config.ReadOnly
+var ErrReadOnly = errors.New("file store is read-only")
+
+// SyncPolicy controls when writes are flushed to disk with fsync
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every write: nothing acknowledged is lost on a crash
+	SyncAlways SyncPolicy = iota
+	// SyncPeriodic fsyncs every Config.SyncInterval; a crash loses at most that window
+	SyncPeriodic
+	// SyncNone leaves flushing to the operating system
+	SyncNone
+)
+
+// Config configures a file store
+type Config struct {
+	// Dir holds the log files; it is created if missing
+	Dir string
+	// Sync is the fsync policy (SyncAlways by default)
+	Sync SyncPolicy
+	// SyncInterval is the flush period of SyncPeriodic (default 1s)
+	SyncInterval time.Duration
+	// CompactAfter rewrites the log once it holds this many records and at
+	// least twice as many as there are live keys (default 1000, <0 disables)
+	CompactAfter int
+	// CompactAfterBytes also rewrites the log once it is this large and has
+	// doubled since it was opened or last compacted (default 16 MiB, <0
+	// disables). Conversation stores log a whole session per write, so their
+	// log grows by size rather than record count.
+	CompactAfterBytes int64
+	// ReadOnly opens the log for reading: it is never truncated, compacted or
+	// written, and several readers may share it while no writer has it open
+	ReadOnly bool
+}
+
+// record is one log entry. On disk each record is a line holding the hex
+// CRC-32 of the JSON payload, a space and the payload.
+type record struct {
+	Op      string          `json:"op"` // set, del, clear, seq
+	Key     string          `json:"k,omitempty"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	Version uint64          `json:"ver,omitempty"`
+}
+
+type entry struct {
+	raw     json.RawMessage
+	version uint64
+}
+
+// db is an append-only log replayed into a map on open. Writes append one
+// record; compaction rewrites the live state to a new file and renames it
+// over the log, so a crash at any point leaves either the old or the new log.
+type db struct {
+	mu      sync.RWMutex
+	cfg     Config
+	path    string
+	f       *os.File // nil for a read-only store without a log
+	lock    *os.File
+	data    map[string]entry
+	seq     uint64 // last version handed out; never decreases
+	records int    // records in the current log
+	size    int64  // bytes of intact records in the current log
+	base    int64  // size when the log was opened or last compacted
+	failed  error  // set when a failed write could not be undone
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func openDB(cfg Config, name string) (*db, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("file store: Dir is required")
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = time.Second
+	}
+	if cfg.CompactAfter == 0 {
+		cfg.CompactAfter = 1000
+	}
+	if cfg.CompactAfterBytes == 0 {
+		cfg.CompactAfterBytes = 16 << 20
+	}
+	if !cfg.ReadOnly {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	d := &db{cfg: cfg, path: filepath.Join(cfg.Dir, name), data: map[string]entry{}}
+	lock, err := lockFile(d.path+".lock", !cfg.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("file store %s: %w", d.path, err)
+	}
+	d.lock = lock
+	if err := d.open(); err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+	if cfg.Sync == SyncPeriodic && !cfg.ReadOnly {
+		d.stop, d.done = make(chan struct{}), make(chan struct{})
+		go d.syncLoop()
+	}
+	return d, nil
+}
+
+// open opens and replays the log. Caller holds the lock file.
+func (d *db) open() error {
+	if d.cfg.ReadOnly {
+		f, err := os.Open(d.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // nothing written yet
+		}
+		if err != nil {
+			return err
+		}
+		d.f = f
+		return d.recover()
+	}
+	// A leftover compaction file never replaced the log, so it is stale
+	_ = os.Remove(d.path + ".compact")
+	f, err := os.OpenFile(d.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	d.f = f
+	if err := d.recover(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return nil
+}
+
+// recover replays the log. A torn or corrupt tail (a crash mid-write) is
+// truncated so later appends start from the last intact record; read-only
+// stores only skip it.
+func (d *db) recover() error {
+	r := bufio.NewReader(d.f)
+	var good int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break // a partial line without newline is a torn write
+		}
+		if err != nil {
+			return err
+		}
+		rec, ok := decodeRecord(line)
+		if !ok {
+			break
+		}
+		d.apply(rec)
+		d.records++
+		good += int64(len(line))
+	}
+	d.size, d.base = good, good
+	if d.cfg.ReadOnly {
+		return nil
+	}
+	if err := d.f.Truncate(good); err != nil {
+		return err
+	}
+	_, err := d.f.Seek(good, io.SeekStart)
+	return err
+}
+
+func encodeRecord(buf *bytes.Buffer, rec record) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	var sum [4]byte
+	c := crc32.ChecksumIEEE(payload)
+	sum[0], sum[1], sum[2], sum[3] = byte(c>>24), byte(c>>16), byte(c>>8), byte(c)
+	buf.WriteString(hex.EncodeToString(sum[:]))
+	buf.WriteByte(' ')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return nil
+}
+
+func decodeRecord(line []byte) (record, bool) {
+	var rec record
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < 10 || line[8] != ' ' {
+		return rec, false
+	}
+	sum, err := hex.DecodeString(string(line[:8]))
+	if err != nil {
+		return rec, false
+	}
+	payload := line[9:]
+	c := crc32.ChecksumIEEE(payload)
+	if sum[0] != byte(c>>24) || sum[1] != byte(c>>16) || sum[2] != byte(c>>8) || sum[3] != byte(c) {
+		return rec, false
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, false
+	}
+	return rec, true
+}
+
+// apply updates the in-memory state. Caller holds mu (or is recovering).
+func (d *db) apply(rec record) {
+	if rec.Version > d.seq {
+		d.seq = rec.Version
+	}
+	switch rec.Op {
+	case "set":
+		d.data[rec.Key] = entry{raw: rec.Value, version: rec.Version}
+	case "del":
+		delete(d.data, rec.Key)
+	case "clear":
+		d.data = map[string]entry{}
+	}
+}
+
+// write appends and applies records. Caller holds mu.
+func (d *db) write(recs ...record) error {
+	if d.closed {
+		return ErrClosed
+	}
+	if d.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+	if d.failed != nil {
+		return d.failed
+	}
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		if err := encodeRecord(&buf, rec); err != nil {
+			return err
+		}
+	}
+	if err := d.append(buf.Bytes()); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		d.apply(rec)
+	}
+	d.records += len(recs)
+	if d.cfg.CompactAfter > 0 && d.records >= d.cfg.CompactAfter && d.records >= 2*len(d.data) {
+		return d.compact()
+	}
+	if d.cfg.CompactAfterBytes > 0 && d.size >= d.cfg.CompactAfterBytes && d.size >= 2*d.base {
+		return d.compact()
+	}
+	return nil
+}
+
+// append writes b at the end of the log. A failed or short write is cut off
+// again, so a torn record cannot hide the records written after it on
+// recover; if that fails too, the db refuses further writes. Caller holds mu.
+func (d *db) append(b []byte) error {
+	_, err := d.f.Write(b)
+	if err == nil && d.cfg.Sync == SyncAlways {
+		err = d.f.Sync()
+	}
+	if err == nil {
+		d.size += int64(len(b))
+		return nil
+	}
+	if terr := d.f.Truncate(d.size); terr != nil {
+		d.failed = fmt.Errorf("file store %s: undo failed write: %w", d.path, terr)
+	} else if _, serr := d.f.Seek(d.size, io.SeekStart); serr != nil {
+		d.failed = fmt.Errorf("file store %s: undo failed write: %w", d.path, serr)
+	}
+	return err
+}
+
+// set writes raw under key with a new version. Caller holds mu.
+func (d *db) set(key string, raw json.RawMessage) (uint64, error) {
+	v := d.seq + 1
+	if err := d.write(record{Op: "set", Key: key, Value: raw, Version: v}); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// compact rewrites the log with one record per live key. Caller holds mu.
+func (d *db) compact() error {
+	if d.closed {
+		return ErrClosed
+	}
+	if d.cfg.ReadOnly {
+		return ErrReadOnly
+	}
+	tmp := d.path + ".compact"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(d.data))
+	for k := range d.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	// Keep the version counter even if its latest key was deleted
+	_ = encodeRecord(&buf, record{Op: "seq", Version: d.seq})
+	for _, k := range keys {
+		e := d.data[k]
+		if err := encodeRecord(&buf, record{Op: "set", Key: k, Value: e.raw, Version: e.version}); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := os.Rename(tmp, d.path); err != nil {
+		_ = f.Close()
+		return err
+	}
+	syncDir(filepath.Dir(d.path))
+	_ = d.f.Close()
+	d.f = f
+	d.records = len(keys) + 1
+	d.size = int64(buf.Len())
+	d.base = d.size
+	d.failed = nil // the new log is intact
+	return nil
+}
+
+// syncDir makes a rename durable; not all platforms support it, so errors are ignored
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		_ = f.Sync()
+		_ = f.Close()
+	}
+}
+
+func (d *db) syncLoop() {
+	defer close(d.done)
+	t := time.NewTicker(d.cfg.SyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-t.C:
+			d.mu.Lock()
+			if !d.closed {
+				_ = d.f.Sync()
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+func (d *db) close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	var err error
+	if d.f != nil {
+		if !d.cfg.ReadOnly {
+			err = d.f.Sync()
+		}
+		if cerr := d.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	_ = d.lock.Close()
+	d.mu.Unlock()
+	if d.stop != nil {
+		close(d.stop)
+		<-d.done
+	}
+	return err
+}
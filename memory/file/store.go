@@ -0,0 +1,322 @@
+// Package file implements persistent memory stores on a local directory for
+// single-node deployments and CLI tools. Each store is an append-only log of
+// checksummed records that is replayed on open and compacted as it grows.
+// A log is locked while open: one process writes it at a time, or any
+// number read it with Config.ReadOnly.
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// kv holds the key/value methods shared by Store and ConversationStore
+type kv struct{ db *db }
+
+// Store implements memory.Store on a log file (store.log) in Config.Dir.
+// Values are kept as JSON: Retrieve returns generic values, use memory.Get
+// or RetrieveInto to get the stored type back.
+type Store struct{ *kv }
+
+// NewStore opens (or creates) the store in cfg.Dir and replays its log
+func NewStore(cfg Config) (*Store, error) {
+	d, err := openDB(cfg, "store.log")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{&kv{db: d}}, nil
+}
+
+// Store implements memory.Store interface
+func (s *kv) Store(ctx context.Context, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	_, err = s.db.set(key, raw)
+	return err
+}
+
+// Retrieve implements memory.Store interface
+func (s *kv) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	var out interface{}
+	if err := s.RetrieveInto(ctx, key, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RetrieveInto implements memory.TypedStore interface
+func (s *kv) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	s.db.mu.RLock()
+	e, ok := s.db.data[key]
+	closed := s.db.closed
+	s.db.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+	if !ok {
+		return fmt.Errorf("key %s %w", key, memory.ErrNotFound)
+	}
+	return json.Unmarshal(e.raw, out)
+}
+
+// RetrieveVersion implements memory.VersionedStore interface
+func (s *kv) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	s.db.mu.RLock()
+	e, ok := s.db.data[key]
+	closed := s.db.closed
+	s.db.mu.RUnlock()
+	if closed {
+		return 0, ErrClosed
+	}
+	if !ok {
+		return 0, nil
+	}
+	return e.version, json.Unmarshal(e.raw, out)
+}
+
+// CompareAndSwap implements memory.VersionedStore interface
+func (s *kv) CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if s.db.data[key].version != version {
+		return 0, fmt.Errorf("%w: key %s", memory.ErrVersionConflict, key)
+	}
+	return s.db.set(key, raw)
+}
+
+// Delete implements memory.Store interface
+func (s *kv) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if _, ok := s.db.data[key]; !ok {
+		return nil
+	}
+	return s.db.write(record{Op: "del", Key: key})
+}
+
+// List implements memory.Store interface
+func (s *kv) List(ctx context.Context) ([]string, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+	if s.db.closed {
+		return nil, ErrClosed
+	}
+	keys := make([]string, 0, len(s.db.data))
+	for k := range s.db.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Clear implements memory.Store interface
+func (s *kv) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.db.write(record{Op: "clear"})
+}
+
+// Compact rewrites the log with only the live keys. It also runs
+// automatically once the log grows past Config.CompactAfter records.
+func (s *kv) Compact() error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.db.compact()
+}
+
+// Close flushes and closes the log
+func (s *kv) Close() error { return s.db.close() }
+
+// ConversationStore implements memory.BranchingStore on a log file
+// (conversations.log) in Config.Dir. Each session is one tree record, so
+// appends are atomic and survive restarts.
+type ConversationStore struct{ *kv }
+
+// NewConversationStore opens (or creates) the conversation store in cfg.Dir
+func NewConversationStore(cfg Config) (*ConversationStore, error) {
+	d, err := openDB(cfg, "conversations.log")
+	if err != nil {
+		return nil, err
+	}
+	return &ConversationStore{&kv{db: d}}, nil
+}
+
+// AppendMessage implements memory.ConversationStore interface.
+// The message is added after the head of the active branch.
+func (cs *ConversationStore) AppendMessage(ctx context.Context, sessionID string, role, content string) error {
+	_, err := cs.Append(ctx, sessionID, memory.Message{Role: role, Content: content})
+	return err
+}
+
+// Append implements memory.MessageAppender interface
+func (cs *ConversationStore) Append(ctx context.Context, sessionID string, msg memory.Message) (string, error) {
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().Unix()
+	}
+	var id string
+	err := cs.update(ctx, sessionID, func(t *memory.Tree) error {
+		var err error
+		id, err = t.Add(t.Head, msg)
+		return err
+	})
+	return id, err
+}
+
+// GetMessages implements memory.ConversationStore interface and returns the active branch
+func (cs *ConversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
+	t, err := cs.view(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Messages(), nil
+}
+
+// ClearSession implements memory.ConversationStore interface
+func (cs *ConversationStore) ClearSession(ctx context.Context, sessionID string) error {
+	return cs.Delete(ctx, convKey(sessionID))
+}
+
+// AddMessage implements memory.BranchingStore interface
+func (cs *ConversationStore) AddMessage(ctx context.Context, sessionID, parentID string, msg memory.Message) (string, error) {
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().Unix()
+	}
+	var id string
+	err := cs.update(ctx, sessionID, func(t *memory.Tree) error {
+		var err error
+		id, err = t.Add(parentID, msg)
+		return err
+	})
+	return id, err
+}
+
+// Head implements memory.BranchingStore interface
+func (cs *ConversationStore) Head(ctx context.Context, sessionID string) (string, error) {
+	t, err := cs.view(sessionID)
+	if err != nil {
+		return "", err
+	}
+	return t.Head, nil
+}
+
+// Path implements memory.BranchingStore interface
+func (cs *ConversationStore) Path(ctx context.Context, sessionID, messageID string) ([]memory.Node, error) {
+	t, err := cs.view(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Path(messageID)
+}
+
+// Fork implements memory.BranchingStore interface
+func (cs *ConversationStore) Fork(ctx context.Context, sessionID, messageID string) error {
+	return cs.update(ctx, sessionID, func(t *memory.Tree) error { return t.Checkout(messageID) })
+}
+
+// Branches implements memory.BranchingStore interface
+func (cs *ConversationStore) Branches(ctx context.Context, sessionID string) ([]memory.Branch, error) {
+	t, err := cs.view(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Branches(), nil
+}
+
+// SwitchBranch implements memory.BranchingStore interface
+func (cs *ConversationStore) SwitchBranch(ctx context.Context, sessionID, leafID string) error {
+	return cs.update(ctx, sessionID, func(t *memory.Tree) error {
+		if !t.IsLeaf(leafID) {
+			return fmt.Errorf("%w: no branch ends at %s", memory.ErrMessageNotFound, leafID)
+		}
+		return t.Checkout(leafID)
+	})
+}
+
+// view decodes the session tree for reading
+func (cs *ConversationStore) view(sessionID string) (*memory.Tree, error) {
+	cs.db.mu.RLock()
+	defer cs.db.mu.RUnlock()
+	if cs.db.closed {
+		return nil, ErrClosed
+	}
+	return cs.tree(sessionID)
+}
+
+// update applies fn to the session tree and logs the result. The lock is held
+// throughout, so concurrent updates of a session are serialized.
+func (cs *ConversationStore) update(ctx context.Context, sessionID string, fn func(*memory.Tree) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cs.db.mu.Lock()
+	defer cs.db.mu.Unlock()
+	t, err := cs.tree(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := fn(t); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = cs.db.set(convKey(sessionID), raw)
+	return err
+}
+
+// tree decodes the session, reading a flat []Message written through Store as
+// a single branch. Caller holds mu.
+func (cs *ConversationStore) tree(sessionID string) (*memory.Tree, error) {
+	t := &memory.Tree{}
+	e, ok := cs.db.data[convKey(sessionID)]
+	if !ok {
+		return t, nil
+	}
+	if len(e.raw) > 0 && e.raw[0] == '[' {
+		var msgs []memory.Message
+		if err := json.Unmarshal(e.raw, &msgs); err != nil {
+			return nil, fmt.Errorf("decode conversation %s: %w", sessionID, err)
+		}
+		for _, m := range msgs {
+			_, _ = t.Add(t.Head, m)
+		}
+		return t, nil
+	}
+	if err := json.Unmarshal(e.raw, t); err != nil {
+		return nil, fmt.Errorf("decode conversation %s: %w", sessionID, err)
+	}
+	return t, nil
+}
+
+func convKey(sessionID string) string { return fmt.Sprintf("conversation:%s", sessionID) }
+
+// Ensure implementations satisfy interfaces
+var _ memory.TypedStore = (*Store)(nil)
+var _ memory.VersionedStore = (*Store)(nil)
+var _ memory.BranchingStore = (*ConversationStore)(nil)
+var _ memory.MessageAppender = (*ConversationStore)(nil)
+var _ memory.TypedStore = (*ConversationStore)(nil)
@@ -0,0 +1,297 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+func TestStore_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type prefs struct{ Theme string }
+	_ = memory.Put(ctx, s, "prefs", prefs{Theme: "dark"})
+	_ = s.Store(ctx, "gone", 1)
+	_ = s.Delete(ctx, "gone")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Store(ctx, "x", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+
+	s, err = NewStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if p, err := memory.Get[prefs](ctx, s, "prefs"); err != nil || p.Theme != "dark" {
+		t.Fatalf("prefs not restored: %+v (%v)", p, err)
+	}
+	if _, err := s.Retrieve(ctx, "gone"); err == nil {
+		t.Fatal("deleted key came back")
+	}
+}
+
+func TestConversationStore_PersistsBranches(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cs, err := NewConversationStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = cs.AppendMessage(ctx, "s", "user", "q")
+	_, _ = cs.Append(ctx, "s", memory.Message{Role: "assistant", Content: "a1", Meta: map[string]string{"k": "v"}})
+	_ = cs.Fork(ctx, "s", "m1")
+	_ = cs.AppendMessage(ctx, "s", "assistant", "a2")
+	_ = cs.Close()
+
+	cs, err = NewConversationStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	msgs, _ := cs.GetMessages(ctx, "s")
+	if len(msgs) != 2 || msgs[1].Content != "a2" {
+		t.Fatalf("active branch not restored: %+v", msgs)
+	}
+	if branches, _ := cs.Branches(ctx, "s"); len(branches) != 2 {
+		t.Fatalf("want 2 branches, got %+v", branches)
+	}
+	path, _ := cs.Path(ctx, "s", "m2")
+	if len(path) != 2 || path[1].Meta["k"] != "v" {
+		t.Fatalf("meta not restored: %+v", path)
+	}
+}
+
+func TestStore_RecoversFromTornWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir})
+	_ = s.Store(ctx, "a", "1")
+	_ = s.Store(ctx, "b", "2")
+	_ = s.Close()
+
+	// Simulate a crash in the middle of appending a record
+	path := filepath.Join(dir, "store.log")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString(`0badc0de {"op":"set","k":"c","v":"3"`)
+	_ = f.Close()
+
+	s, err := NewStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("open after torn write: %v", err)
+	}
+	keys, _ := s.List(ctx)
+	if len(keys) != 2 {
+		t.Fatalf("want the 2 intact keys, got %v", keys)
+	}
+	// The torn tail is dropped, so new records are not glued to it
+	_ = s.Store(ctx, "c", "3")
+	_ = s.Close()
+	s, _ = NewStore(Config{Dir: dir})
+	defer s.Close()
+	if v, err := s.Retrieve(ctx, "c"); err != nil || v != "3" {
+		t.Fatalf("write after recovery lost: %v (%v)", v, err)
+	}
+}
+
+func TestStore_FailedWriteIsNotAcknowledged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir})
+	_ = s.Store(ctx, "a", "1")
+
+	// A handle that cannot be written (or truncated) makes the append fail
+	good := s.db.f
+	ro, err := os.Open(filepath.Join(dir, "store.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.db.f = ro
+	if err := s.Store(ctx, "b", "2"); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	s.db.f = good
+	_ = ro.Close()
+	if _, err := s.Retrieve(ctx, "b"); !errors.Is(err, memory.ErrNotFound) {
+		t.Fatalf("failed write must not be applied, got %v", err)
+	}
+	// The failed write could not be undone, so later writes are refused
+	// rather than risk landing behind a torn record
+	if err := s.Store(ctx, "c", "3"); err == nil {
+		t.Fatal("expected writes to be refused after an unrecoverable failure")
+	}
+	_ = s.Close()
+	s, _ = NewStore(Config{Dir: dir})
+	defer s.Close()
+	if v, err := s.Retrieve(ctx, "a"); err != nil || v != "1" {
+		t.Fatalf("acknowledged write lost: %v (%v)", v, err)
+	}
+}
+
+func TestStore_StopsAtCorruptRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir})
+	_ = s.Store(ctx, "a", "1")
+	_ = s.Store(ctx, "b", "2")
+	_ = s.Close()
+
+	path := filepath.Join(dir, "store.log")
+	b, _ := os.ReadFile(path)
+	lines := strings.SplitAfter(string(b), "\n")
+	lines[1] = strings.Replace(lines[1], `"2"`, `"9"`, 1) // checksum no longer matches
+	_ = os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644)
+
+	s, _ = NewStore(Config{Dir: dir})
+	defer s.Close()
+	if _, err := s.Retrieve(ctx, "b"); err == nil {
+		t.Fatal("corrupt record should not be replayed")
+	}
+	if v, _ := s.Retrieve(ctx, "a"); v != "1" {
+		t.Fatalf("records before the corruption should survive, got %v", v)
+	}
+}
+
+func TestStore_CompactsLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir, Sync: SyncNone, CompactAfter: 50})
+	for i := 0; i < 200; i++ {
+		_ = s.Store(ctx, "counter", i)
+	}
+	_ = s.Store(ctx, "last", "x")
+	var n int
+	v, _ := s.RetrieveVersion(ctx, "last", &n)
+	_ = s.Delete(ctx, "last")
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	b, _ := os.ReadFile(filepath.Join(dir, "store.log"))
+	if lines := strings.Count(string(b), "\n"); lines != 2 {
+		t.Fatalf("compacted log should hold the version counter and 1 key, got %d records", lines)
+	}
+	s, _ = NewStore(Config{Dir: dir})
+	defer s.Close()
+	if got, err := memory.Get[int](ctx, s, "counter"); err != nil || got != 199 {
+		t.Fatalf("latest value lost by compaction: %d (%v)", got, err)
+	}
+	// Versions keep growing after a restart, even past deleted keys
+	nv, err := s.CompareAndSwap(ctx, "last", 0, "y")
+	if err != nil || nv <= v {
+		t.Fatalf("version reused after compaction: %d <= %d (%v)", nv, v, err)
+	}
+}
+
+func TestConversationStore_CompactsBySize(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cs, _ := NewConversationStore(Config{Dir: dir, Sync: SyncNone, CompactAfter: -1, CompactAfterBytes: 4096})
+	defer cs.Close()
+	for i := 0; i < 200; i++ {
+		if err := cs.AppendMessage(ctx, "s", "user", "a message of a few dozen bytes"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Uncompacted, 200 whole-session records would take about 1.5 MB
+	fi, _ := os.Stat(filepath.Join(dir, "conversations.log"))
+	if fi.Size() > 100<<10 {
+		t.Fatalf("log should be compacted by size, is %d bytes", fi.Size())
+	}
+	if msgs, _ := cs.GetMessages(ctx, "s"); len(msgs) != 200 {
+		t.Fatalf("messages lost by compaction: %d", len(msgs))
+	}
+}
+
+func TestStore_PeriodicSync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewStore(Config{Dir: dir, Sync: SyncPeriodic, SyncInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Store(ctx, "k", "v")
+	time.Sleep(5 * time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = NewStore(Config{Dir: dir})
+	defer s.Close()
+	if v, _ := s.Retrieve(ctx, "k"); v != "v" {
+		t.Fatalf("value not persisted: %v", v)
+	}
+}
+
+func TestStore_LocksDirectory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Store(ctx, "a", "1")
+	if _, err := NewStore(Config{Dir: dir}); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second writer: expected ErrLocked, got %v", err)
+	}
+	if _, err := NewStore(Config{Dir: dir, ReadOnly: true}); !errors.Is(err, ErrLocked) {
+		t.Fatalf("reader during a write: expected ErrLocked, got %v", err)
+	}
+	// Another log in the same directory is locked separately
+	cs, err := NewConversationStore(Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("conversation store next to the store: %v", err)
+	}
+	_ = cs.Close()
+	_ = s.Close()
+
+	// Readers share the log and leave a torn tail in place
+	path := filepath.Join(dir, "store.log")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString(`0badc0de {"op":"set"`)
+	_ = f.Close()
+	before, _ := os.Stat(path)
+	r1, err := NewStore(Config{Dir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := NewStore(Config{Dir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("second reader: %v", err)
+	}
+	if v, err := r2.Retrieve(ctx, "a"); err != nil || v != "1" {
+		t.Fatalf("reader: %v (%v)", v, err)
+	}
+	if err := r1.Store(ctx, "b", "2"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := NewStore(Config{Dir: dir}); !errors.Is(err, ErrLocked) {
+		t.Fatalf("writer during a read: expected ErrLocked, got %v", err)
+	}
+	_ = r1.Close()
+	_ = r2.Close()
+	if after, _ := os.Stat(path); after.Size() != before.Size() {
+		t.Fatalf("read-only open changed the log: %d -> %d bytes", before.Size(), after.Size())
+	}
+
+	empty, err := NewConversationStore(Config{Dir: t.TempDir(), ReadOnly: true})
+	if err != nil {
+		t.Fatalf("read-only open without a log: %v", err)
+	}
+	if keys, _ := empty.List(ctx); len(keys) != 0 {
+		t.Fatalf("expected no keys, got %v", keys)
+	}
+	_ = empty.Close()
+}
@@ -0,0 +1,223 @@
+package inmemory
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// Config bounds the memory used by the in-memory stores. The zero value keeps
+// everything forever, like NewStore and NewConversationStore.
+type Config struct {
+	// TTL expires a key this long after its last write (0 keeps keys).
+	// Each session of a ConversationStore is one key.
+	TTL time.Duration
+	// MaxKeys caps the number of keys; the least recently used are evicted (0 is unlimited)
+	MaxKeys int
+	// MaxMessages caps the active branch of each ConversationStore session;
+	// the oldest messages are dropped (0 is unlimited)
+	MaxMessages int
+	// JanitorInterval runs a background sweep of expired keys; stop it with
+	// Close. Without a janitor, expired keys are hidden from reads and
+	// removed by a sweep that writes run once per as many writes as there
+	// are keys with a TTL, so the cost stays constant per write.
+	JanitorInterval time.Duration
+}
+
+// limits tracks expiry and recency of the keys of one store. Expiry fields
+// are guarded by the store's lock; the LRU list has its own lock so reads
+// holding only the store's read lock can mark keys as used.
+type limits struct {
+	cfg   Config
+	store string // metrics label
+	now   func() time.Time
+
+	expires map[string]time.Time
+	ttls    map[string]time.Duration // per-key TTLs overriding cfg.TTL
+	writes  int                      // since the last sweep
+
+	mu      sync.Mutex
+	lru     *list.List // front is the most recently used
+	elems   map[string]*list.Element
+	pending map[string]int // evictions by reason, not yet reported
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimits(cfg Config, store string) *limits {
+	return &limits{
+		cfg:     cfg,
+		store:   store,
+		now:     time.Now,
+		expires: make(map[string]time.Time),
+		ttls:    make(map[string]time.Duration),
+		lru:     list.New(),
+		elems:   make(map[string]*list.Element),
+		pending: make(map[string]int),
+	}
+}
+
+// written refreshes the expiry and recency of key. Caller holds the store's write lock.
+func (l *limits) written(key string) {
+	ttl, ok := l.ttls[key]
+	if !ok {
+		ttl = l.cfg.TTL
+	}
+	if ttl > 0 {
+		l.expires[key] = l.now().Add(ttl)
+	} else {
+		delete(l.expires, key)
+	}
+	l.touch(key)
+}
+
+// setTTL gives key its own TTL (0 keeps it forever). Caller holds the store's write lock.
+func (l *limits) setTTL(key string, ttl time.Duration) {
+	l.ttls[key] = ttl
+}
+
+// touch marks key as most recently used
+func (l *limits) touch(key string) {
+	if l.cfg.MaxKeys <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elems[key]; ok {
+		l.lru.MoveToFront(e)
+		return
+	}
+	l.elems[key] = l.lru.PushFront(key)
+}
+
+// expired reports whether key is past its TTL. Caller holds the store's lock.
+func (l *limits) expired(key string) bool {
+	exp, ok := l.expires[key]
+	return ok && !l.now().Before(exp)
+}
+
+// forget drops the bookkeeping of a deleted key. Caller holds the store's write lock.
+func (l *limits) forget(key string) {
+	delete(l.expires, key)
+	delete(l.ttls, key)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elems[key]; ok {
+		l.lru.Remove(e)
+		delete(l.elems, key)
+	}
+}
+
+func (l *limits) reset() {
+	l.expires = make(map[string]time.Time)
+	l.ttls = make(map[string]time.Duration)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lru.Init()
+	l.elems = make(map[string]*list.Element)
+}
+
+// sweep deletes expired keys through remove and returns how many it removed.
+// Caller holds the store's write lock.
+func (l *limits) sweep(remove func(key string)) int {
+	l.writes = 0
+	n := 0
+	for key := range l.expires {
+		if l.expired(key) {
+			remove(key)
+			l.forget(key)
+			l.evicted("ttl", 1)
+			n++
+		}
+	}
+	return n
+}
+
+// enforce runs after every write. It sweeps expired keys once per
+// len(expires) writes, and when more than MaxKeys remain it sweeps, then
+// removes the least recently used keys. Caller holds the store's write lock.
+func (l *limits) enforce(size func() int, remove func(key string)) {
+	if n := len(l.expires); n > 0 {
+		l.writes++
+		if l.writes >= n {
+			l.sweep(remove)
+		}
+	}
+	if l.cfg.MaxKeys <= 0 || size() <= l.cfg.MaxKeys {
+		return
+	}
+	l.sweep(remove)
+	for size() > l.cfg.MaxKeys {
+		l.mu.Lock()
+		e := l.lru.Back()
+		l.mu.Unlock()
+		if e == nil {
+			return
+		}
+		key := e.Value.(string)
+		remove(key)
+		l.forget(key)
+		l.evicted("lru", 1)
+	}
+}
+
+// evicted records n evictions for the next report
+func (l *limits) evicted(reason string, n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending[reason] += n
+}
+
+// report adds the recorded evictions to the memory_evictions counter. Stores
+// call it after releasing their lock, so metrics backends never run under it.
+func (l *limits) report() {
+	l.mu.Lock()
+	pending := l.pending
+	if len(pending) == 0 {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = make(map[string]int)
+	l.mu.Unlock()
+	for reason, n := range pending {
+		obs.AddCounter("memory_evictions", n, map[string]string{"store": l.store, "reason": reason})
+	}
+}
+
+// startJanitor runs sweep every JanitorInterval until stopJanitor
+func (l *limits) startJanitor(sweep func()) {
+	if l.cfg.JanitorInterval <= 0 {
+		return
+	}
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(l.done)
+		t := time.NewTicker(l.cfg.JanitorInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-t.C:
+				sweep()
+			}
+		}
+	}()
+}
+
+// stopJanitor stops the janitor and waits for it to exit; it is safe to call more than once
+func (l *limits) stopJanitor() {
+	l.closeOnce.Do(func() {
+		if l.stop != nil {
+			close(l.stop)
+			<-l.done
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// fakeClock replaces the limits clock so expiry is deterministic
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func withMetrics(t *testing.T) *obs.DefaultMetrics {
+	t.Helper()
+	prev := obs.MetricsImpl
+	m := obs.NewDefaultMetrics()
+	obs.MetricsImpl = m
+	t.Cleanup(func() { obs.MetricsImpl = prev })
+	return m
+}
+
+func evictions(m *obs.DefaultMetrics) int64 {
+	return m.GetStats()["counters"].(map[string]int64)["memory_evictions"]
+}
+
+func TestStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	metrics := withMetrics(t)
+	s := NewStoreWithConfig(Config{TTL: time.Minute})
+	clock := &fakeClock{t: time.Now()}
+	s.lim.now = clock.now
+
+	_ = s.Store(ctx, "short", "a")
+	_ = s.StoreWithTTL(ctx, "long", "b", time.Hour)
+	_ = s.StoreWithTTL(ctx, "forever", "c", 0)
+	clock.advance(2 * time.Minute)
+
+	if _, err := s.Retrieve(ctx, "short"); err == nil {
+		t.Fatal("expired key is still readable")
+	}
+	if keys, _ := s.List(ctx); len(keys) != 2 {
+		t.Fatalf("expired key listed: %v", keys)
+	}
+	var out string
+	if v, _ := s.RetrieveVersion(ctx, "short", &out); v != 0 {
+		t.Fatalf("expired key should be absent for CAS, got version %d", v)
+	}
+	if _, err := s.CompareAndSwap(ctx, "short", 0, "new"); err != nil {
+		t.Fatalf("create over an expired key: %v", err)
+	}
+
+	clock.advance(2 * time.Hour)
+	s.sweep()
+	if len(s.data) != 1 || s.data["forever"] != "c" {
+		t.Fatalf("sweep should leave only the key without TTL: %v", s.data)
+	}
+	if n := evictions(metrics); n != 2 {
+		t.Fatalf("want 2 ttl evictions reported, got %d", n)
+	}
+}
+
+func TestStore_TTLSweptOnWrites(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreWithConfig(Config{TTL: time.Minute})
+	clock := &fakeClock{t: time.Now()}
+	s.lim.now = clock.now
+
+	for _, k := range []string{"a", "b", "c"} {
+		_ = s.Store(ctx, k, k)
+	}
+	clock.advance(2 * time.Minute)
+	// Without a janitor or MaxKeys, writes alone free the expired keys
+	for i := 0; i < 3; i++ {
+		_ = s.Store(ctx, "live", i)
+	}
+	if len(s.data) != 1 || len(s.lim.expires) != 1 {
+		t.Fatalf("expired keys not freed by writes: %v", s.data)
+	}
+}
+
+func TestStore_LRUEviction(t *testing.T) {
+	ctx := context.Background()
+	metrics := withMetrics(t)
+	s := NewStoreWithConfig(Config{MaxKeys: 2})
+
+	_ = s.Store(ctx, "a", 1)
+	_ = s.Store(ctx, "b", 2)
+	_, _ = s.Retrieve(ctx, "a") // b is now least recently used
+	_ = s.Store(ctx, "c", 3)
+
+	if _, err := s.Retrieve(ctx, "b"); err == nil {
+		t.Fatal("least recently used key should be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, err := s.Retrieve(ctx, k); err != nil {
+			t.Fatalf("%s evicted: %v", k, err)
+		}
+	}
+	if n := evictions(metrics); n != 1 {
+		t.Fatalf("want 1 lru eviction reported, got %d", n)
+	}
+	_ = s.Delete(ctx, "a")
+	_ = s.Store(ctx, "d", 4)
+	if keys, _ := s.List(ctx); len(keys) != 2 {
+		t.Fatalf("deleted keys should free their slot: %v", keys)
+	}
+}
+
+func TestConversationStore_Limits(t *testing.T) {
+	ctx := context.Background()
+	withMetrics(t)
+	cs := NewConversationStoreWithConfig(Config{TTL: time.Minute, MaxKeys: 2, MaxMessages: 3})
+	clock := &fakeClock{t: time.Now()}
+	cs.lim.now = clock.now
+
+	for _, c := range []string{"1", "2", "3", "4", "5"} {
+		_ = cs.AppendMessage(ctx, "s1", "user", c)
+	}
+	msgs, _ := cs.GetMessages(ctx, "s1")
+	if len(msgs) != 3 || msgs[0].Content != "3" || msgs[2].Content != "5" {
+		t.Fatalf("message cap should keep the newest 3: %+v", msgs)
+	}
+	if path, _ := cs.Path(ctx, "s1", "m5"); len(path) != 3 || path[0].ParentID != "" {
+		t.Fatalf("trimmed branch should start at a new root: %+v", path)
+	}
+
+	_ = cs.AppendMessage(ctx, "s2", "user", "x")
+	_, _ = cs.GetMessages(ctx, "s1")
+	_ = cs.AppendMessage(ctx, "s3", "user", "y") // evicts s2
+	if msgs, _ := cs.GetMessages(ctx, "s2"); len(msgs) != 0 {
+		t.Fatalf("least recently used session should be evicted: %+v", msgs)
+	}
+	if head, _ := cs.Head(ctx, "missing"); head != "" || len(cs.data) != 2 {
+		t.Fatalf("reads must not create sessions: %d keys", len(cs.data))
+	}
+
+	_ = cs.ExpireSession(ctx, "s1", time.Hour)
+	clock.advance(2 * time.Minute)
+	if msgs, _ := cs.GetMessages(ctx, "s3"); len(msgs) != 0 {
+		t.Fatalf("expired session still readable: %+v", msgs)
+	}
+	if msgs, _ := cs.GetMessages(ctx, "s1"); len(msgs) != 3 {
+		t.Fatalf("session with its own TTL expired early: %+v", msgs)
+	}
+	_ = cs.AppendMessage(ctx, "s3", "user", "again")
+	if msgs, _ := cs.GetMessages(ctx, "s3"); len(msgs) != 1 || msgs[0].Content != "again" {
+		t.Fatalf("expired session should start over: %+v", msgs)
+	}
+}
+
+func TestJanitor_SweepsAndStops(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreWithConfig(Config{TTL: time.Millisecond, JanitorInterval: time.Millisecond})
+	_ = s.Store(ctx, "k", "v")
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.RLock()
+		n := len(s.data)
+		s.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("janitor did not remove the expired key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close() // idempotent
+
+	cs := NewConversationStoreWithConfig(Config{JanitorInterval: time.Millisecond})
+	_ = cs.Close()
+}
+
+func TestTreeTrim(t *testing.T) {
+	tr := &memory.Tree{}
+	q, _ := tr.Add("", memory.Message{Content: "q"})
+	a1, _ := tr.Add(q, memory.Message{Content: "a1"})
+	_, _ = tr.Add(q, memory.Message{Content: "a2"}) // sibling branch off q
+	_ = tr.Checkout(a1)
+	_, _ = tr.Add(a1, memory.Message{Content: "f"})
+
+	if n := tr.Trim(2); n != 2 {
+		t.Fatalf("want q and its other branch dropped, removed %d", n)
+	}
+	if msgs := tr.Messages(); len(msgs) != 2 || msgs[0].Content != "a1" {
+		t.Fatalf("unexpected branch after trim: %+v", msgs)
+	}
+	if n := tr.Trim(5); n != 0 {
+		t.Fatalf("short branch should not be trimmed, removed %d", n)
+	}
+}
+
+// lockProbe records AddCounter calls and whether the store was unlocked then
+type lockProbe struct {
+	obs.NoOpMetrics
+	s     *Store
+	calls []int
+	held  bool
+}
+
+func (p *lockProbe) IncrementCounter(name string, labels map[string]string) {
+	p.AddCounter(name, 1, labels)
+}
+func (p *lockProbe) AddCounter(name string, n int, labels map[string]string) {
+	p.calls = append(p.calls, n)
+	if p.s.mu.TryLock() {
+		p.s.mu.Unlock()
+	} else {
+		p.held = true
+	}
+}
+
+func TestStore_ReportsEvictionsOnceAfterUnlock(t *testing.T) {
+	ctx := context.Background()
+	s := NewStoreWithConfig(Config{TTL: time.Minute})
+	clock := &fakeClock{t: time.Now()}
+	s.lim.now = clock.now
+	probe := &lockProbe{s: s}
+	prev := obs.MetricsImpl
+	obs.MetricsImpl = probe
+	t.Cleanup(func() { obs.MetricsImpl = prev })
+
+	for _, k := range []string{"a", "b", "c"} {
+		_ = s.Store(ctx, k, 1)
+	}
+	clock.advance(2 * time.Minute)
+	s.sweep()
+	if len(probe.calls) != 1 || probe.calls[0] != 3 || probe.held {
+		t.Fatalf("want one report of 3 outside the lock, got %v (held=%v)", probe.calls, probe.held)
+	}
+}
@@ -13,43 +13,149 @@ import (
 type Store struct {
 	mu   sync.RWMutex
 	data map[string]interface{}
+	// versions holds the version of every key; seq is never reset so a
+	// deleted and rewritten key cannot reuse an old version
+	versions map[string]uint64
+	seq      uint64
+	lim      *limits
 }
 
 // NewStore creates a new in-memory store
 func NewStore() *Store {
-	return &Store{
-		data: make(map[string]interface{}),
+	return NewStoreWithConfig(Config{})
+}
+
+// NewStoreWithConfig creates an in-memory store with TTL and size limits.
+// Call Close to stop its janitor.
+func NewStoreWithConfig(cfg Config) *Store {
+	s := &Store{
+		data:     make(map[string]interface{}),
+		versions: make(map[string]uint64),
+		lim:      newLimits(cfg, "store"),
 	}
+	s.lim.startJanitor(s.sweep)
+	return s
 }
 
 // Store implements memory.Store interface
 func (s *Store) Store(ctx context.Context, key string, value interface{}) error {
+	defer s.lim.report()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
+	s.put(key, value)
+	return nil
+}
+
+// StoreWithTTL stores value with its own TTL, used instead of Config.TTL on
+// this and later writes of key (0 keeps it forever)
+func (s *Store) StoreWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	defer s.lim.report()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lim.setTTL(key, ttl)
+	s.put(key, value)
+	return nil
+}
+
+// put writes value, bumps its version and applies the limits. Caller holds mu.
+func (s *Store) put(key string, value interface{}) uint64 {
+	s.seq++
 	s.data[key] = value
+	s.versions[key] = s.seq
+	s.lim.written(key)
+	s.lim.enforce(func() int { return len(s.data) }, s.remove)
+	return s.seq
+}
+
+// remove deletes key. Caller holds mu.
+func (s *Store) remove(key string) {
+	delete(s.data, key)
+	delete(s.versions, key)
+}
+
+// get returns the live value of key, counting the read for LRU eviction. Caller holds mu.
+func (s *Store) get(key string) (interface{}, bool) {
+	value, exists := s.data[key]
+	if !exists || s.lim.expired(key) {
+		return nil, false
+	}
+	s.lim.touch(key)
+	return value, true
+}
+
+// sweep removes expired keys; run by the janitor
+func (s *Store) sweep() {
+	defer s.lim.report()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lim.sweep(s.remove)
+}
+
+// Close stops the janitor. The store stays usable.
+func (s *Store) Close() error {
+	s.lim.stopJanitor()
 	return nil
 }
 
+// RetrieveVersion implements memory.VersionedStore interface
+func (s *Store) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.get(key)
+	if !exists {
+		return 0, nil
+	}
+	return s.versions[key], memory.Decode(value, out)
+}
+
+// CompareAndSwap implements memory.VersionedStore interface
+func (s *Store) CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error) {
+	defer s.lim.report()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.versions[key]
+	if s.lim.expired(key) {
+		current = 0
+	}
+	if current != version {
+		return 0, fmt.Errorf("%w: key %s", memory.ErrVersionConflict, key)
+	}
+	return s.put(key, value), nil
+}
+
 // Retrieve implements memory.Store interface
 func (s *Store) Retrieve(ctx context.Context, key string) (interface{}, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
-	value, exists := s.data[key]
+	value, exists := s.get(key)
 	if !exists {
-		return nil, fmt.Errorf("key %s not found", key)
+		return nil, fmt.Errorf("key %s %w", key, memory.ErrNotFound)
 	}
 	
 	return value, nil
 }
 
+// RetrieveInto implements memory.TypedStore interface
+func (s *Store) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	value, err := s.Retrieve(ctx, key)
+	if err != nil {
+		return err
+	}
+	return memory.Decode(value, out)
+}
+
 // Delete implements memory.Store interface
 func (s *Store) Delete(ctx context.Context, key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	delete(s.data, key)
+	s.remove(key)
+	s.lim.forget(key)
 	return nil
 }
 
@@ -60,7 +166,9 @@ func (s *Store) List(ctx context.Context) ([]string, error) {
 	
 	keys := make([]string, 0, len(s.data))
 	for key := range s.data {
-		keys = append(keys, key)
+		if !s.lim.expired(key) {
+			keys = append(keys, key)
+		}
 	}
 	
 	return keys, nil
@@ -72,6 +180,8 @@ func (s *Store) Clear(ctx context.Context) error {
 	defer s.mu.Unlock()
 	
 	s.data = make(map[string]interface{})
+	s.versions = make(map[string]uint64)
+	s.lim.reset()
 	return nil
 }
 
@@ -79,21 +189,60 @@ func (s *Store) Clear(ctx context.Context) error {
 type ConversationStore struct {
 	mu   sync.RWMutex
 	data map[string]interface{}
+	lim  *limits
 }
 
 // NewConversationStore creates a new in-memory conversation store
 func NewConversationStore() *ConversationStore {
-	return &ConversationStore{
+	return NewConversationStoreWithConfig(Config{})
+}
+
+// NewConversationStoreWithConfig creates a conversation store with TTL, size
+// and per-session message limits. Call Close to stop its janitor.
+func NewConversationStoreWithConfig(cfg Config) *ConversationStore {
+	cs := &ConversationStore{
 		data: make(map[string]interface{}),
+		lim:  newLimits(cfg, "conversation"),
 	}
+	cs.lim.startJanitor(cs.sweep)
+	return cs
 }
 
 // Store implements memory.Store interface
 func (cs *ConversationStore) Store(ctx context.Context, key string, value interface{}) error {
+	defer cs.lim.report()
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 	
 	cs.data[key] = value
+	cs.saved(key)
+	return nil
+}
+
+// StoreWithTTL stores value with its own TTL, used instead of Config.TTL on
+// this and later writes of key (0 keeps it forever)
+func (cs *ConversationStore) StoreWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.lim.setTTL(key, ttl)
+	cs.data[key] = value
+	cs.saved(key)
+	return nil
+}
+
+// ExpireSession gives a session its own TTL, counted from now and from each
+// later write (0 keeps it forever)
+func (cs *ConversationStore) ExpireSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	key := convKey(sessionID)
+	cs.lim.setTTL(key, ttl)
+	if _, exists := cs.data[key]; exists && !cs.lim.expired(key) {
+		cs.lim.written(key)
+	}
 	return nil
 }
 
@@ -103,19 +252,30 @@ func (cs *ConversationStore) Retrieve(ctx context.Context, key string) (interfac
 	defer cs.mu.RUnlock()
 	
 	value, exists := cs.data[key]
-	if !exists {
-		return nil, fmt.Errorf("key %s not found", key)
+	if !exists || cs.lim.expired(key) {
+		return nil, fmt.Errorf("key %s %w", key, memory.ErrNotFound)
 	}
+	cs.lim.touch(key)
 	
 	return value, nil
 }
 
+// RetrieveInto implements memory.TypedStore interface
+func (cs *ConversationStore) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	value, err := cs.Retrieve(ctx, key)
+	if err != nil {
+		return err
+	}
+	return memory.Decode(value, out)
+}
+
 // Delete implements memory.Store interface
 func (cs *ConversationStore) Delete(ctx context.Context, key string) error {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 	
 	delete(cs.data, key)
+	cs.lim.forget(key)
 	return nil
 }
 
@@ -126,7 +286,9 @@ func (cs *ConversationStore) List(ctx context.Context) ([]string, error) {
 	
 	keys := make([]string, 0, len(cs.data))
 	for k := range cs.data {
-		keys = append(keys, k)
+		if !cs.lim.expired(k) {
+			keys = append(keys, k)
+		}
 	}
 	
 	return keys, nil
@@ -138,60 +300,183 @@ func (cs *ConversationStore) Clear(ctx context.Context) error {
 	defer cs.mu.Unlock()
 	
 	cs.data = make(map[string]interface{})
+	cs.lim.reset()
 	return nil
 }
 
-// AppendMessage implements memory.ConversationStore interface
+// Close stops the janitor. The store stays usable.
+func (cs *ConversationStore) Close() error {
+	cs.lim.stopJanitor()
+	return nil
+}
+
+// AppendMessage implements memory.ConversationStore interface.
+// The message is added after the head of the active branch.
 func (cs *ConversationStore) AppendMessage(ctx context.Context, sessionID string, role, content string) error {
+	_, err := cs.Append(ctx, sessionID, memory.Message{Role: role, Content: content})
+	return err
+}
+
+// Append implements memory.MessageAppender interface
+func (cs *ConversationStore) Append(ctx context.Context, sessionID string, msg memory.Message) (string, error) {
+	defer cs.lim.report()
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-	
-	key := fmt.Sprintf("conversation:%s", sessionID)
-	
-	var messages []memory.Message
-	if existing, exists := cs.data[key]; exists {
-		if msgs, ok := existing.([]memory.Message); ok {
-			messages = msgs
-		}
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().Unix()
 	}
-	
-	message := memory.Message{
-		Role:      role,
-		Content:   content,
-		Timestamp: time.Now().Unix(),
-	}
-	
-	messages = append(messages, message)
-	cs.data[key] = messages
-	
-	return nil
+	t := cs.tree(sessionID, true)
+	id, err := t.Add(t.Head, msg)
+	cs.saved(convKey(sessionID))
+	return id, err
 }
 
-// GetMessages implements memory.ConversationStore interface
+// GetMessages implements memory.ConversationStore interface and returns the active branch
 func (cs *ConversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
-	
-	key := fmt.Sprintf("conversation:%s", sessionID)
-	
+
+	key := convKey(sessionID)
 	value, exists := cs.data[key]
-	if !exists {
+	if !exists || cs.lim.expired(key) {
 		return []memory.Message{}, nil
 	}
-	
-	if messages, ok := value.([]memory.Message); ok {
-		return messages, nil
+	cs.lim.touch(key)
+	switch v := value.(type) {
+	case *memory.Tree:
+		return v.Messages(), nil
+	case []memory.Message: // written directly through Store
+		return v, nil
 	}
-	
 	return nil, fmt.Errorf("invalid message format for session %s", sessionID)
 }
 
 // ClearSession implements memory.ConversationStore interface
 func (cs *ConversationStore) ClearSession(ctx context.Context, sessionID string) error {
-	key := fmt.Sprintf("conversation:%s", sessionID)
-	return cs.Delete(ctx, key)
+	return cs.Delete(ctx, convKey(sessionID))
+}
+
+// AddMessage implements memory.BranchingStore interface
+func (cs *ConversationStore) AddMessage(ctx context.Context, sessionID, parentID string, msg memory.Message) (string, error) {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().Unix()
+	}
+	id, err := cs.tree(sessionID, true).Add(parentID, msg)
+	cs.saved(convKey(sessionID))
+	return id, err
+}
+
+// Head implements memory.BranchingStore interface
+func (cs *ConversationStore) Head(ctx context.Context, sessionID string) (string, error) {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.tree(sessionID, false).Head, nil
+}
+
+// Path implements memory.BranchingStore interface
+func (cs *ConversationStore) Path(ctx context.Context, sessionID, messageID string) ([]memory.Node, error) {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.tree(sessionID, false).Path(messageID)
 }
 
+// Fork implements memory.BranchingStore interface
+func (cs *ConversationStore) Fork(ctx context.Context, sessionID, messageID string) error {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if err := cs.tree(sessionID, true).Checkout(messageID); err != nil {
+		return err
+	}
+	cs.saved(convKey(sessionID))
+	return nil
+}
+
+// Branches implements memory.BranchingStore interface
+func (cs *ConversationStore) Branches(ctx context.Context, sessionID string) ([]memory.Branch, error) {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.tree(sessionID, false).Branches(), nil
+}
+
+// SwitchBranch implements memory.BranchingStore interface
+func (cs *ConversationStore) SwitchBranch(ctx context.Context, sessionID, leafID string) error {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	t := cs.tree(sessionID, true)
+	if !t.IsLeaf(leafID) {
+		return fmt.Errorf("%w: no branch ends at %s", memory.ErrMessageNotFound, leafID)
+	}
+	if err := t.Checkout(leafID); err != nil {
+		return err
+	}
+	cs.saved(convKey(sessionID))
+	return nil
+}
+
+// tree returns the session tree, converting a flat history if needed. Expired
+// sessions start over. Missing sessions are only added to the store with
+// create; otherwise an empty tree is returned. Caller holds mu.
+func (cs *ConversationStore) tree(sessionID string, create bool) *memory.Tree {
+	key := convKey(sessionID)
+	if cs.lim.expired(key) {
+		cs.remove(key)
+		cs.lim.forget(key)
+		cs.lim.evicted("ttl", 1)
+	}
+	switch v := cs.data[key].(type) {
+	case *memory.Tree:
+		cs.lim.touch(key)
+		return v
+	case []memory.Message:
+		t := &memory.Tree{}
+		for _, m := range v {
+			_, _ = t.Add(t.Head, m)
+		}
+		cs.data[key] = t
+		cs.lim.touch(key)
+		return t
+	}
+	t := &memory.Tree{}
+	if create {
+		cs.data[key] = t
+	}
+	return t
+}
+
+// saved applies the limits after key was written: the message cap, the TTL
+// and the key count. Caller holds mu.
+func (cs *ConversationStore) saved(key string) {
+	if t, ok := cs.data[key].(*memory.Tree); ok && cs.lim.cfg.MaxMessages > 0 {
+		cs.lim.evicted("message_cap", t.Trim(cs.lim.cfg.MaxMessages))
+	}
+	cs.lim.written(key)
+	cs.lim.enforce(func() int { return len(cs.data) }, cs.remove)
+}
+
+// remove deletes key. Caller holds mu.
+func (cs *ConversationStore) remove(key string) { delete(cs.data, key) }
+
+// sweep removes expired keys; run by the janitor
+func (cs *ConversationStore) sweep() {
+	defer cs.lim.report()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.lim.sweep(cs.remove)
+}
+
+func convKey(sessionID string) string { return fmt.Sprintf("conversation:%s", sessionID) }
+
 // Ensure implementations satisfy interfaces
-var _ memory.Store = (*Store)(nil)
-var _ memory.ConversationStore = (*ConversationStore)(nil)
\ No newline at end of file
+var _ memory.TypedStore = (*Store)(nil)
+var _ memory.VersionedStore = (*Store)(nil)
+var _ memory.MessageAppender = (*ConversationStore)(nil)
+var _ memory.BranchingStore = (*ConversationStore)(nil)
+var _ memory.TypedStore = (*ConversationStore)(nil)
@@ -96,15 +96,9 @@ func TestConversationStore_AppendMessage(t *testing.T) {
 		t.Errorf("AppendMessage() error = %v", err)
 	}
 
-	key := "conversation:" + sessionID
-	value, exists := store.data[key]
-	if !exists {
-		t.Fatal("Messages were not stored")
-	}
-
-	messages, ok := value.([]memory.Message)
-	if !ok {
-		t.Fatal("Stored value is not []memory.Message")
+	messages, err := store.GetMessages(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
 	}
 
 	if len(messages) != 2 {
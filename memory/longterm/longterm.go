@@ -0,0 +1,509 @@
+// Package longterm remembers durable facts about users across sessions.
+//
+// Facts are extracted from completed runs by an LLM, embedded with a rag.Embedder
+// and stored in a memory.VectorStore scoped by user. Used as a core.Middleware,
+// relevant facts are recalled for each new input and injected as a system message.
+package longterm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/rag"
+)
+
+// ErrFactNotFound is returned when editing or forgetting an unknown fact
+var ErrFactNotFound = errors.New("fact not found")
+
+// Fact is a durable piece of information remembered about a user
+type Fact struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Score is the similarity score when returned from Recall
+	Score float64 `json:"score,omitempty"`
+}
+
+// Config wires the components used by LongTermMemory
+type Config struct {
+	// Model extracts facts from completed runs
+	Model llm.Client
+	// Embedder embeds facts and queries
+	Embedder rag.Embedder
+	// Vectors stores fact embeddings; document IDs are prefixed with the user ID.
+	// Stores implementing memory.FilteredVectorStore are searched with a user filter.
+	Vectors memory.VectorStore
+	// Index keeps the per-user fact list used by List, Update and Forget
+	Index memory.Store
+	// TopK facts injected per input (default 5)
+	TopK int
+	// MinScore drops recalled facts scoring below this similarity
+	MinScore float64
+	// ExtractPrompt overrides the default extraction instructions
+	ExtractPrompt string
+}
+
+const defaultExtractPrompt = "You extract durable facts about the user from a conversation turn: " +
+	"preferences, biographical details, goals and standing instructions. " +
+	"Ignore small talk and anything only relevant to this turn. " +
+	"Write each fact as a short third-person sentence. Return an empty list if there is nothing worth remembering."
+
+// LongTermMemory extracts, stores and recalls user facts. It implements core.Middleware.
+type LongTermMemory struct {
+	cfg Config
+	mu  sync.Mutex // guards index read-modify-write
+}
+
+// New creates a LongTermMemory
+func New(cfg Config) *LongTermMemory {
+	if cfg.TopK <= 0 {
+		cfg.TopK = 5
+	}
+	if cfg.ExtractPrompt == "" {
+		cfg.ExtractPrompt = defaultExtractPrompt
+	}
+	return &LongTermMemory{cfg: cfg}
+}
+
+// ----- user scoping -----
+
+type scopeKey struct{}
+
+// scope carries per-run state between middleware hooks. A context made by
+// WithUserID may serve several runs, so RewriteInput resets the state when a
+// run starts and AfterRun clears it; mu guards it.
+type scope struct {
+	userID string
+
+	mu       sync.Mutex
+	input    string
+	recalled []Fact
+	loaded   bool
+}
+
+// WithUserID scopes a run to a user. Without it the middleware hooks are no-ops.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{userID: userID})
+}
+
+// UserIDFromContext returns the user set by WithUserID
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok || s.userID == "" {
+		return "", false
+	}
+	return s.userID, true
+}
+
+// ----- management API -----
+
+// Remember stores a fact for the user
+func (m *LongTermMemory) Remember(ctx context.Context, userID, content string) (Fact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts, err := m.load(ctx, userID)
+	if err != nil {
+		return Fact{}, err
+	}
+	now := time.Now().UTC()
+	f := Fact{ID: newID(), UserID: userID, Content: strings.TrimSpace(content), CreatedAt: now, UpdatedAt: now}
+	if err := m.embed(ctx, f); err != nil {
+		return Fact{}, err
+	}
+	if err := m.save(ctx, userID, append(facts, f)); err != nil {
+		return Fact{}, err
+	}
+	return f, nil
+}
+
+// List returns all facts remembered for the user, oldest first
+func (m *LongTermMemory) List(ctx context.Context, userID string) ([]Fact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load(ctx, userID)
+}
+
+// Update replaces a fact's content and re-embeds it
+func (m *LongTermMemory) Update(ctx context.Context, userID, id, content string) (Fact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts, err := m.load(ctx, userID)
+	if err != nil {
+		return Fact{}, err
+	}
+	for i := range facts {
+		if facts[i].ID != id {
+			continue
+		}
+		facts[i].Content = strings.TrimSpace(content)
+		facts[i].UpdatedAt = time.Now().UTC()
+		if err := m.embed(ctx, facts[i]); err != nil {
+			return Fact{}, err
+		}
+		if err := m.save(ctx, userID, facts); err != nil {
+			return Fact{}, err
+		}
+		return facts[i], nil
+	}
+	return Fact{}, fmt.Errorf("%w: %s", ErrFactNotFound, id)
+}
+
+// Forget removes a single fact
+func (m *LongTermMemory) Forget(ctx context.Context, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts, err := m.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range facts {
+		if facts[i].ID != id {
+			continue
+		}
+		if err := m.cfg.Vectors.DeleteDocument(ctx, vectorID(userID, id)); err != nil {
+			return fmt.Errorf("delete embedding: %w", err)
+		}
+		return m.save(ctx, userID, append(facts[:i], facts[i+1:]...))
+	}
+	return fmt.Errorf("%w: %s", ErrFactNotFound, id)
+}
+
+// ForgetAll removes every fact for the user (e.g. for privacy requests)
+func (m *LongTermMemory) ForgetAll(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts, err := m.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, f := range facts {
+		if err := m.cfg.Vectors.DeleteDocument(ctx, vectorID(userID, f.ID)); err != nil {
+			return fmt.Errorf("delete embedding: %w", err)
+		}
+	}
+	return m.cfg.Index.Delete(ctx, indexKey(userID))
+}
+
+// Recall returns the user's facts most relevant to query. The search only
+// ranks the user's own facts: through a metadata filter on stores
+// implementing memory.FilteredVectorStore, and by scoring the user's fact
+// embeddings directly otherwise.
+func (m *LongTermMemory) Recall(ctx context.Context, userID, query string) ([]Fact, error) {
+	vec, err := m.cfg.Embedder.EmbedText(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	m.mu.Lock()
+	facts, err := m.load(ctx, userID)
+	m.mu.Unlock()
+	if err != nil || len(facts) == 0 {
+		return nil, err
+	}
+	var docs []memory.Document
+	if fs, ok := m.cfg.Vectors.(memory.FilteredVectorStore); ok && m.tagged() {
+		docs, err = fs.QuerySimilarFiltered(ctx, vec, m.cfg.TopK, map[string]string{userMetaKey: userID})
+	} else {
+		docs, err = m.scoreFacts(ctx, userID, facts, vec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Fact, len(facts))
+	for _, f := range facts {
+		byID[vectorID(userID, f.ID)] = f
+	}
+	out := make([]Fact, 0, m.cfg.TopK)
+	for _, d := range docs {
+		f, ok := byID[d.ID]
+		if !ok || d.Score < m.cfg.MinScore {
+			continue
+		}
+		f.Score = d.Score
+		out = append(out, f)
+		if len(out) == m.cfg.TopK {
+			break
+		}
+	}
+	return out, nil
+}
+
+// scoreFacts ranks the user's facts by cosine similarity to vec using the
+// stored embeddings
+func (m *LongTermMemory) scoreFacts(ctx context.Context, userID string, facts []Fact, vec []float64) ([]memory.Document, error) {
+	docs := make([]memory.Document, 0, len(facts))
+	for _, f := range facts {
+		d, err := m.cfg.Vectors.GetDocument(ctx, vectorID(userID, f.ID))
+		if err != nil {
+			return nil, fmt.Errorf("load embedding: %w", err)
+		}
+		if len(d.Embedding) == 0 {
+			return nil, errors.New("vector store returns no embeddings; it must implement memory.FilteredVectorStore")
+		}
+		d.Score = cosine(vec, d.Embedding)
+		docs = append(docs, *d)
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Score > docs[j].Score })
+	return docs, nil
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// extraction is the structured output requested from the model
+type extraction struct {
+	llm.BaseStructured
+	Facts []string `json:"facts" description:"Durable facts about the user"`
+}
+
+func (e extraction) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"facts": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Durable facts about the user",
+			},
+		},
+		"required": []string{"facts"},
+	}
+}
+
+// Extract asks the model for durable facts in a completed turn and stores new ones
+func (m *LongTermMemory) Extract(ctx context.Context, userID, input, output string) ([]Fact, error) {
+	if m.cfg.Model == nil {
+		return nil, errors.New("no extraction model configured")
+	}
+	prompt := fmt.Sprintf("User said:\n%s\n\nAssistant replied:\n%s", input, output)
+	resp, err := llm.StructuredChat(ctx, m.cfg.Model, llm.StructuredRequest[extraction]{
+		SystemPrompt: m.cfg.ExtractPrompt,
+		Messages:     []llm.Message{{Role: "user", Content: prompt}},
+		OutputType:   extraction{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("extract facts: %w", err)
+	}
+
+	existing, err := m.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, f := range existing {
+		seen[strings.ToLower(f.Content)] = true
+	}
+	var stored []Fact
+	for _, c := range resp.Data.Facts {
+		c = strings.TrimSpace(c)
+		if c == "" || seen[strings.ToLower(c)] {
+			continue
+		}
+		seen[strings.ToLower(c)] = true
+		f, err := m.Remember(ctx, userID, c)
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, f)
+	}
+	return stored, nil
+}
+
+// ----- core.Middleware -----
+
+// RewriteInput starts the run's state from its input, which is left unchanged
+func (m *LongTermMemory) RewriteInput(ctx context.Context, input core.Message) (core.Message, error) {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.mu.Lock()
+		s.input, s.recalled, s.loaded = input.Content, nil, false
+		s.mu.Unlock()
+	}
+	return input, nil
+}
+
+// BeforeLLMCall injects recalled facts as a system message after the agent's system prompt
+func (m *LongTermMemory) BeforeLLMCall(ctx context.Context, req *llm.ChatRequest) error {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok || s.userID == "" || req == nil {
+		return nil
+	}
+	recalled, err := m.recalled(ctx, s, req)
+	if err != nil || len(recalled) == 0 {
+		return err
+	}
+	msg := llm.Message{Role: "system", Content: formatFacts(recalled)}
+	at := 0
+	if len(req.Messages) > 0 && req.Messages[0].Role == "system" {
+		at = 1
+	}
+	msgs := make([]llm.Message, 0, len(req.Messages)+1)
+	msgs = append(msgs, req.Messages[:at]...)
+	msgs = append(msgs, msg)
+	msgs = append(msgs, req.Messages[at:]...)
+	req.Messages = msgs
+	return nil
+}
+
+// recalled returns the facts for the run, recalling them on its first model
+// call. Without RewriteInput (callers other than core.ChatAgent) the input is
+// the last user message of the request.
+func (m *LongTermMemory) recalled(ctx context.Context, s *scope, req *llm.ChatRequest) ([]Fact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return s.recalled, nil
+	}
+	s.loaded = true
+	if s.input == "" {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				s.input = req.Messages[i].Content
+				break
+			}
+		}
+	}
+	if s.input == "" {
+		return nil, nil
+	}
+	facts, err := m.Recall(ctx, s.userID, s.input)
+	if err != nil {
+		return nil, fmt.Errorf("recall memories: %w", err)
+	}
+	s.recalled = facts
+	return facts, nil
+}
+
+func (m *LongTermMemory) AfterLLMResponse(ctx context.Context, resp *llm.Response) error {
+	return nil
+}
+func (m *LongTermMemory) BeforeToolExecute(ctx context.Context, toolName string, input string) error {
+	return nil
+}
+func (m *LongTermMemory) AfterToolExecute(ctx context.Context, toolName string, result string, execErr error) error {
+	return nil
+}
+
+// AfterRun extracts and stores durable facts from the completed turn
+func (m *LongTermMemory) AfterRun(ctx context.Context, final core.Message) error {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok || s.userID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	input := s.input
+	s.input, s.recalled, s.loaded = "", nil, false
+	s.mu.Unlock()
+	if input == "" {
+		return nil
+	}
+	_, err := m.Extract(ctx, s.userID, input, final.Content)
+	return err
+}
+
+// ----- helpers -----
+
+func formatFacts(facts []Fact) string {
+	sorted := append([]Fact(nil), facts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+	var b strings.Builder
+	b.WriteString("Known facts about the user from earlier conversations:\n")
+	for _, f := range sorted {
+		fmt.Fprintf(&b, "- %s\n", f.Content)
+	}
+	return b.String()
+}
+
+func (m *LongTermMemory) embed(ctx context.Context, f Fact) error {
+	vec, err := m.cfg.Embedder.EmbedText(ctx, f.Content)
+	if err != nil {
+		return fmt.Errorf("embed fact: %w", err)
+	}
+	if m.tagged() {
+		err = m.cfg.Vectors.(memory.DocumentWriter).AddDocuments(ctx, []memory.Document{{
+			ID: vectorID(f.UserID, f.ID), Content: f.Content, Embedding: vec,
+			Meta: map[string]string{userMetaKey: f.UserID},
+		}})
+	} else {
+		err = m.cfg.Vectors.AddDocument(ctx, vectorID(f.UserID, f.ID), f.Content, vec)
+	}
+	if err != nil {
+		return fmt.Errorf("store embedding: %w", err)
+	}
+	return nil
+}
+
+// userMetaKey tags fact embeddings with their user so Recall can filter on it
+const userMetaKey = "ltm_user"
+
+// tagged reports whether embeddings are written with userMetaKey, which
+// needs a store that keeps Meta
+func (m *LongTermMemory) tagged() bool {
+	_, ok := m.cfg.Vectors.(memory.DocumentWriter)
+	return ok
+}
+
+// load reads the user's fact index. The index is stored as a JSON string so it
+// round-trips through any memory.Store backend.
+func (m *LongTermMemory) load(ctx context.Context, userID string) ([]Fact, error) {
+	v, err := m.cfg.Index.Retrieve(ctx, indexKey(userID))
+	if errors.Is(err, memory.ErrNotFound) {
+		return []Fact{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load fact index: %w", err)
+	}
+	raw, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid fact index for user %s", userID)
+	}
+	var facts []Fact
+	if err := json.Unmarshal([]byte(raw), &facts); err != nil {
+		return nil, fmt.Errorf("decode fact index: %w", err)
+	}
+	return facts, nil
+}
+
+func (m *LongTermMemory) save(ctx context.Context, userID string, facts []Fact) error {
+	b, err := json.Marshal(facts)
+	if err != nil {
+		return err
+	}
+	return m.cfg.Index.Store(ctx, indexKey(userID), string(b))
+}
+
+func indexKey(userID string) string         { return "ltm:" + userID }
+func vectorID(userID, factID string) string { return "ltm:" + userID + ":" + factID }
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+var _ core.InputRewriteMiddleware = (*LongTermMemory)(nil)
@@ -0,0 +1,291 @@
+package longterm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+// bagEmb embeds text as a tiny bag-of-letters vector
+type bagEmb struct{}
+
+func (bagEmb) EmbedText(ctx context.Context, input string) ([]float64, error) {
+	v := make([]float64, 26)
+	for _, r := range strings.ToLower(input) {
+		if r >= 'a' && r <= 'z' {
+			v[r-'a']++
+		}
+	}
+	return v, nil
+}
+
+type fakeVS struct{ docs map[string]memory.Document }
+
+func (f *fakeVS) AddDocument(ctx context.Context, id, content string, vec []float64) error {
+	f.docs[id] = memory.Document{ID: id, Content: content, Embedding: vec}
+	return nil
+}
+func (f *fakeVS) QuerySimilar(ctx context.Context, q []float64, limit int) ([]memory.Document, error) {
+	out := make([]memory.Document, 0, len(f.docs))
+	for _, d := range f.docs {
+		d.Score = cosine(q, d.Embedding)
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+func (f *fakeVS) DeleteDocument(ctx context.Context, id string) error { delete(f.docs, id); return nil }
+func (f *fakeVS) GetDocument(ctx context.Context, id string) (*memory.Document, error) {
+	d, ok := f.docs[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &d, nil
+}
+
+// scriptLLM returns extraction JSON for extraction prompts and echoes otherwise
+type scriptLLM struct {
+	facts string
+	reqs  []*llm.ChatRequest
+}
+
+func (s *scriptLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	s.reqs = append(s.reqs, req)
+	if req.ResponseFormat != nil {
+		return &llm.Response{Content: s.facts}, nil
+	}
+	return &llm.Response{Content: "sure"}, nil
+}
+func (s *scriptLLM) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return &llm.Response{Content: "c"}, nil
+}
+func (s *scriptLLM) Stream(ctx context.Context, req *llm.ChatRequest, out chan<- *llm.Response) error {
+	close(out)
+	return nil
+}
+func (s *scriptLLM) Model() string          { return "mock" }
+func (s *scriptLLM) Provider() llm.Provider { return llm.ProviderOpenAI }
+func (s *scriptLLM) Validate() error        { return nil }
+
+func newLTM(model llm.Client) (*LongTermMemory, *fakeVS) {
+	vs := &fakeVS{docs: map[string]memory.Document{}}
+	return New(Config{Model: model, Embedder: bagEmb{}, Vectors: vs, Index: inmemory.NewStore(), TopK: 2}), vs
+}
+
+func TestManagementAPI(t *testing.T) {
+	ctx := context.Background()
+	m, vs := newLTM(nil)
+	f1, err := m.Remember(ctx, "u1", "Prefers metric units")
+	if err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	if _, err := m.Remember(ctx, "u2", "Lives in Oslo"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	facts, _ := m.List(ctx, "u1")
+	if len(facts) != 1 || facts[0].Content != "Prefers metric units" {
+		t.Fatalf("unexpected list: %+v", facts)
+	}
+
+	if _, err := m.Update(ctx, "u1", f1.ID, "Prefers imperial units"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if vs.docs[vectorID("u1", f1.ID)].Content != "Prefers imperial units" {
+		t.Fatalf("update should re-embed")
+	}
+
+	// Recall is scoped to the user
+	got, err := m.Recall(ctx, "u1", "Lives in Oslo")
+	if err != nil {
+		t.Fatalf("recall: %v", err)
+	}
+	for _, f := range got {
+		if f.UserID != "u1" {
+			t.Fatalf("recall leaked another user's fact: %+v", f)
+		}
+	}
+
+	if err := m.Forget(ctx, "u1", f1.ID); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if err := m.Forget(ctx, "u1", f1.ID); !errors.Is(err, ErrFactNotFound) {
+		t.Fatalf("expected ErrFactNotFound, got %v", err)
+	}
+	if err := m.ForgetAll(ctx, "u2"); err != nil {
+		t.Fatalf("forget all: %v", err)
+	}
+	if len(vs.docs) != 0 {
+		t.Fatalf("expected all embeddings removed, got %d", len(vs.docs))
+	}
+}
+
+func TestMiddleware_ExtractsAndInjects(t *testing.T) {
+	model := &scriptLLM{facts: `{"facts":["The user is vegetarian"]}`}
+	m, _ := newLTM(model)
+	agent := core.NewChatAgent(core.ChatConfig{
+		Model:      model,
+		Config:     core.AgentConfig{SystemPrompt: "sys"},
+		Middleware: []core.Middleware{m},
+	})
+
+	ctx := WithUserID(context.Background(), "u1")
+	if _, err := agent.Run(ctx, core.Message{Role: "user", Content: "I am vegetarian"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	facts, _ := m.List(context.Background(), "u1")
+	if len(facts) != 1 || facts[0].Content != "The user is vegetarian" {
+		t.Fatalf("expected extracted fact, got %+v", facts)
+	}
+
+	// A second run in a new session should see the fact injected after the system prompt
+	ctx = WithUserID(context.Background(), "u1")
+	if _, err := agent.Run(ctx, core.Message{Role: "user", Content: "suggest a dinner, vegetarian please"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var chat *llm.ChatRequest
+	for _, r := range model.reqs {
+		if r.ResponseFormat == nil {
+			chat = r
+		}
+	}
+	if len(chat.Messages) < 2 || chat.Messages[1].Role != "system" || !strings.Contains(chat.Messages[1].Content, "vegetarian") {
+		t.Fatalf("expected injected memory message, got %+v", chat.Messages)
+	}
+
+	// Duplicate facts are not stored twice
+	if facts, _ := m.List(context.Background(), "u1"); len(facts) != 1 {
+		t.Fatalf("expected dedupe, got %d facts", len(facts))
+	}
+}
+
+func TestMiddleware_ContextReusedAcrossRuns(t *testing.T) {
+	model := &scriptLLM{facts: `{"facts":[]}`}
+	m, _ := newLTM(model)
+	agent := core.NewChatAgent(core.ChatConfig{
+		Model:      model,
+		Config:     core.AgentConfig{SystemPrompt: "sys"},
+		Middleware: []core.Middleware{m},
+	})
+	ctx := WithUserID(context.Background(), "u1")
+	for _, in := range []string{"first turn", "second turn"} {
+		if _, err := agent.Run(ctx, core.Message{Role: "user", Content: in}); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	// Each run extracts from its own input, not the first run's
+	last := model.reqs[len(model.reqs)-1]
+	if last.ResponseFormat == nil || !strings.Contains(last.Messages[len(last.Messages)-1].Content, "second turn") {
+		t.Fatalf("second run should extract from its own input, got %+v", last.Messages)
+	}
+}
+
+func TestMiddleware_NoUserIsNoop(t *testing.T) {
+	model := &scriptLLM{facts: `{"facts":["x"]}`}
+	m, _ := newLTM(model)
+	req := &llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}}
+	if err := m.BeforeLLMCall(context.Background(), req); err != nil || len(req.Messages) != 1 {
+		t.Fatalf("expected no-op without user, got %v %+v", err, req.Messages)
+	}
+	if err := m.AfterRun(context.Background(), core.Message{Content: "ok"}); err != nil || len(model.reqs) != 0 {
+		t.Fatalf("expected no extraction without user")
+	}
+}
+
+// kv lets flakyIndex embed memory.Store, whose name clashes with its Store method
+type kv = memory.Store
+
+// flakyIndex fails reads while down is set
+type flakyIndex struct {
+	kv
+	down bool
+}
+
+func (f *flakyIndex) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	if f.down {
+		return nil, errors.New("connection reset")
+	}
+	return f.kv.Retrieve(ctx, key)
+}
+
+func TestRemember_IndexErrorKeepsFacts(t *testing.T) {
+	ctx := context.Background()
+	idx := &flakyIndex{kv: inmemory.NewStore()}
+	m := New(Config{Embedder: bagEmb{}, Vectors: &fakeVS{docs: map[string]memory.Document{}}, Index: idx})
+	if _, err := m.Remember(ctx, "u1", "Likes tea"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	idx.down = true
+	if _, err := m.Remember(ctx, "u1", "Likes coffee"); err == nil {
+		t.Fatal("expected the index error to be returned")
+	}
+	idx.down = false
+	facts, _ := m.List(ctx, "u1")
+	if len(facts) != 1 || facts[0].Content != "Likes tea" {
+		t.Fatalf("a failed read must not overwrite the index: %+v", facts)
+	}
+}
+
+// metaVS keeps Meta and supports filtered queries
+type metaVS struct {
+	fakeVS
+	filtered int
+}
+
+func (f *metaVS) AddDocuments(ctx context.Context, docs []memory.Document) error {
+	for _, d := range docs {
+		f.docs[d.ID] = d
+	}
+	return nil
+}
+func (f *metaVS) QuerySimilarFiltered(ctx context.Context, q []float64, limit int, filter map[string]string) ([]memory.Document, error) {
+	f.filtered++
+	all, _ := f.QuerySimilar(ctx, q, len(f.docs))
+	var out []memory.Document
+	for _, d := range all {
+		match := true
+		for k, v := range filter {
+			match = match && d.Meta[k] == v
+		}
+		if match && len(out) < limit {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func TestRecall_NotCrowdedOutByOtherUsers(t *testing.T) {
+	ctx := context.Background()
+	for name, vs := range map[string]memory.VectorStore{
+		"filtered": &metaVS{fakeVS: fakeVS{docs: map[string]memory.Document{}}},
+		"fallback": &fakeVS{docs: map[string]memory.Document{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := New(Config{Embedder: bagEmb{}, Vectors: vs, Index: inmemory.NewStore(), TopK: 2})
+			for i := 0; i < 20; i++ {
+				if _, err := m.Remember(ctx, "other", "Loves hiking in the mountains"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := m.Remember(ctx, "u1", "Allergic to peanuts"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := m.Recall(ctx, "u1", "Loves hiking in the mountains")
+			if err != nil || len(got) != 1 || got[0].UserID != "u1" {
+				t.Fatalf("recall: %+v (%v)", got, err)
+			}
+			if mv, ok := vs.(*metaVS); ok && mv.filtered == 0 {
+				t.Fatal("filtered query not used")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+//go:build adapters_postgres
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// migrations are applied in order; each entry is one schema version. Append
+// new versions, never edit released ones. %[1]s is the table prefix.
+var migrations = [][]string{
+	{ // 1: sessions, messages and key/value state
+		`CREATE TABLE IF NOT EXISTS %[1]ssessions (
+			id text PRIMARY KEY,
+			user_id text NOT NULL DEFAULT '',
+			head text NOT NULL DEFAULT '',
+			seq bigint NOT NULL DEFAULT 0,
+			meta jsonb,
+			created_at timestamptz NOT NULL DEFAULT now(),
+			updated_at timestamptz NOT NULL DEFAULT now(),
+			expires_at timestamptz
+		)`,
+		`CREATE INDEX IF NOT EXISTS %[1]ssessions_user_idx ON %[1]ssessions (user_id, updated_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS %[1]ssessions_expires_idx ON %[1]ssessions (expires_at) WHERE expires_at IS NOT NULL`,
+		`CREATE TABLE IF NOT EXISTS %[1]smessages (
+			session_id text NOT NULL REFERENCES %[1]ssessions (id) ON DELETE CASCADE,
+			id text NOT NULL,
+			parent_id text NOT NULL DEFAULT '',
+			seq bigint NOT NULL,
+			depth int NOT NULL,
+			role text NOT NULL,
+			content text NOT NULL,
+			tool_call_id text NOT NULL DEFAULT '',
+			tool_calls jsonb,
+			meta jsonb,
+			created_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (session_id, id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS %[1]smessages_parent_idx ON %[1]smessages (session_id, parent_id)`,
+		`CREATE SEQUENCE IF NOT EXISTS %[1]skv_version`,
+		`CREATE TABLE IF NOT EXISTS %[1]skv (
+			key text PRIMARY KEY,
+			value jsonb NOT NULL,
+			version bigint NOT NULL,
+			updated_at timestamptz NOT NULL DEFAULT now(),
+			expires_at timestamptz
+		)`,
+	},
+}
+
+var validPrefix = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Migrate brings the schema for prefix up to date. It records applied
+// versions in <prefix>schema_migrations and holds an advisory lock, so
+// concurrent starts are safe.
+func Migrate(ctx context.Context, db DB, prefix string) error {
+	if prefix != "" && !validPrefix.MatchString(prefix) {
+		return fmt.Errorf("postgres: invalid table prefix %q", prefix)
+	}
+	return pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "go-agents/memory/postgres:"+prefix); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %sschema_migrations (
+			version int PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`, prefix)); err != nil {
+			return err
+		}
+		var current int
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %sschema_migrations`, prefix)).Scan(&current); err != nil {
+			return err
+		}
+		for v := current + 1; v <= len(migrations); v++ {
+			for _, stmt := range migrations[v-1] {
+				if _, err := tx.Exec(ctx, fmt.Sprintf(stmt, prefix)); err != nil {
+					return fmt.Errorf("postgres: migration %d: %w", v, err)
+				}
+			}
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %sschema_migrations (version) VALUES ($1)`, prefix), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+//go:build adapters_postgres
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/jackc/pgx/v5"
+)
+
+// Session describes a stored conversation
+type Session struct {
+	ID     string            `json:"id"`
+	UserID string            `json:"user_id,omitempty"`
+	Meta   map[string]string `json:"meta,omitempty"`
+	// Messages counts every message of the session, across branches
+	Messages  int       `json:"messages"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ExpiresAt is zero for sessions without a TTL
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// SetSession assigns a session to a user and replaces its metadata, creating
+// the session if needed
+func (cs *ConversationStore) SetSession(ctx context.Context, sessionID, userID string, meta map[string]string) error {
+	raw, err := jsonOrNil(len(meta) > 0, meta)
+	if err != nil {
+		return err
+	}
+	return cs.withSession(ctx, sessionID, func(tx pgx.Tx, _ *session) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET user_id = $2, meta = $3 WHERE id = $1`, cs.sessions), sessionID, userID, raw)
+		return err
+	})
+}
+
+// ListSessions returns the live sessions of a user, most recently updated
+// first. limit defaults to 50; offset skips that many sessions.
+func (cs *ConversationStore) ListSessions(ctx context.Context, userID string, limit, offset int) ([]Session, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := cs.db.Query(ctx, fmt.Sprintf(`SELECT s.id, s.user_id, s.meta, s.created_at, s.updated_at, s.expires_at,
+			(SELECT count(*) FROM %[2]s m WHERE m.session_id = s.id)
+		FROM %[1]s s
+		WHERE s.user_id = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+		ORDER BY s.updated_at DESC, s.id
+		LIMIT $2 OFFSET $3`, cs.sessions, cs.messages), userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Session, error) {
+		var s Session
+		var meta []byte
+		var expires *time.Time
+		if err := row.Scan(&s.ID, &s.UserID, &meta, &s.CreatedAt, &s.UpdatedAt, &expires, &s.Messages); err != nil {
+			return s, err
+		}
+		if expires != nil {
+			s.ExpiresAt = *expires
+		}
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &s.Meta); err != nil {
+				return s, err
+			}
+		}
+		return s, nil
+	})
+}
+
+// Cleanup deletes expired sessions (with their messages) and keys. Expired
+// rows are already invisible to reads; run it periodically to reclaim space.
+// It returns the number of sessions and keys removed.
+func (cs *ConversationStore) Cleanup(ctx context.Context) (int64, error) {
+	return cs.deleteWhere(ctx, `expires_at <= now()`)
+}
+
+// DeleteBefore enforces a retention period: it deletes sessions and keys not
+// written since t, whatever their TTL
+func (cs *ConversationStore) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	return cs.deleteWhere(ctx, `updated_at < $1`, t)
+}
+
+func (cs *ConversationStore) deleteWhere(ctx context.Context, cond string, args ...any) (int64, error) {
+	var n int64
+	err := pgx.BeginFunc(ctx, cs.db, func(tx pgx.Tx) error {
+		for _, table := range []string{cs.sessions, cs.kv} {
+			tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, cond), args...)
+			if err != nil {
+				return err
+			}
+			n += tag.RowsAffected()
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Sessions implements memory.SessionLister over all live sessions
+func (cs *ConversationStore) Sessions(ctx context.Context) ([]memory.SessionInfo, error) {
+	rows, err := cs.db.Query(ctx, fmt.Sprintf(`SELECT s.id, s.created_at, s.updated_at,
+			(SELECT count(*) FROM %[2]s m WHERE m.session_id = s.id)
+		FROM %[1]s s
+		WHERE %[3]s
+		ORDER BY s.updated_at DESC, s.id`, cs.sessions, cs.messages, live))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.SessionInfo, error) {
+		var s memory.SessionInfo
+		err := row.Scan(&s.ID, &s.CreatedAt, &s.LastActivity, &s.Messages)
+		return s, err
+	})
+}
+
+// SearchMessages implements memory.MessageSearcher with a case-insensitive
+// match of every word of query against message contents of live sessions.
+// limit defaults to 50, as in memory.Search.
+func (cs *ConversationStore) SearchMessages(ctx context.Context, query string, limit int) ([]memory.SearchHit, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search: empty query")
+	}
+	conds := make([]string, len(terms))
+	args := []any{limit}
+	for i, t := range terms {
+		conds[i] = fmt.Sprintf(`m.content ILIKE $%d`, i+2)
+		args = append(args, "%"+likeEscaper.Replace(t)+"%")
+	}
+	rows, err := cs.db.Query(ctx, fmt.Sprintf(`SELECT m.session_id, m.id, m.role, m.content, m.created_at
+		FROM %[1]s m JOIN %[2]s s ON s.id = m.session_id
+		WHERE (s.expires_at IS NULL OR s.expires_at > now()) AND %[3]s
+		ORDER BY m.created_at DESC, m.seq DESC
+		LIMIT $1`, cs.messages, cs.sessions, strings.Join(conds, " AND ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.SearchHit, error) {
+		var h memory.SearchHit
+		var created time.Time
+		err := row.Scan(&h.SessionID, &h.MessageID, &h.Role, &h.Content, &created)
+		h.Timestamp = created.Unix()
+		return h, err
+	})
+}
+
+// likeEscaper escapes LIKE wildcards so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var (
+	_ memory.SessionLister   = (*ConversationStore)(nil)
+	_ memory.MessageSearcher = (*ConversationStore)(nil)
+)
@@ -0,0 +1,524 @@
+//go:build adapters_postgres
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DB is the part of pgx the store uses; *pgx.Conn and *pgxpool.Pool satisfy it
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Config configures a ConversationStore
+type Config struct {
+	// Prefix is prepended to table names (e.g. "agent_" for agent_sessions)
+	Prefix string
+	// TTL expires sessions and keys this long after their last write (0 keeps them)
+	TTL time.Duration
+	// SkipMigrate leaves the schema to an external migration tool
+	SkipMigrate bool
+}
+
+// ConversationStore implements memory.BranchingStore on Postgres. Sessions
+// and messages live in their own tables (one row per message, tree linked by
+// parent_id); the generic Store methods use a key/value table.
+type ConversationStore struct {
+	db  DB
+	cfg Config
+
+	sessions, messages, kv string
+}
+
+// New returns a store on db, applying schema migrations unless cfg.SkipMigrate is set
+func New(ctx context.Context, db DB, cfg Config) (*ConversationStore, error) {
+	if !cfg.SkipMigrate {
+		if err := Migrate(ctx, db, cfg.Prefix); err != nil {
+			return nil, err
+		}
+	} else if cfg.Prefix != "" && !validPrefix.MatchString(cfg.Prefix) {
+		return nil, fmt.Errorf("postgres: invalid table prefix %q", cfg.Prefix)
+	}
+	return &ConversationStore{
+		db:       db,
+		cfg:      cfg,
+		sessions: cfg.Prefix + "sessions",
+		messages: cfg.Prefix + "messages",
+		kv:       cfg.Prefix + "kv",
+	}, nil
+}
+
+// live is the SQL condition for rows that have not expired
+const live = `(expires_at IS NULL OR expires_at > now())`
+
+// expiresAt returns the expiry of a row written now (nil: never)
+func (cs *ConversationStore) expiresAt() *time.Time {
+	if cs.cfg.TTL <= 0 {
+		return nil
+	}
+	t := time.Now().Add(cs.cfg.TTL)
+	return &t
+}
+
+// Store implements memory.Store on the key/value table
+func (cs *ConversationStore) Store(ctx context.Context, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = cs.db.Exec(ctx, fmt.Sprintf(`INSERT INTO %[1]s (key, value, version, expires_at)
+		VALUES ($1, $2, nextval('%[1]s_version'), $3)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, version = excluded.version,
+			updated_at = now(), expires_at = excluded.expires_at`, cs.kv), key, raw, cs.expiresAt())
+	return err
+}
+
+// Retrieve decodes into generic values (maps, slices, float64); use memory.Get
+// or RetrieveInto to get the stored type back
+func (cs *ConversationStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	var out interface{}
+	if err := cs.RetrieveInto(ctx, key, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RetrieveInto implements memory.TypedStore
+func (cs *ConversationStore) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	v, err := cs.RetrieveVersion(ctx, key, out)
+	if err == nil && v == 0 {
+		return fmt.Errorf("key %s %w", key, memory.ErrNotFound)
+	}
+	return err
+}
+
+// RetrieveVersion implements memory.VersionedStore
+func (cs *ConversationStore) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	var raw []byte
+	var version int64
+	err := cs.db.QueryRow(ctx, fmt.Sprintf(`SELECT value, version FROM %s WHERE key = $1 AND %s`, cs.kv, live), key).Scan(&raw, &version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint64(version), json.Unmarshal(raw, out)
+}
+
+// CompareAndSwap implements memory.VersionedStore with a conditional write
+func (cs *ConversationStore) CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	var q string
+	args := []any{key, raw, cs.expiresAt()}
+	if version == 0 {
+		// Create, or take over a key that has expired but not been cleaned up
+		q = fmt.Sprintf(`INSERT INTO %[1]s (key, value, version, expires_at)
+			VALUES ($1, $2, nextval('%[1]s_version'), $3)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, version = excluded.version,
+				updated_at = now(), expires_at = excluded.expires_at
+			WHERE %[1]s.expires_at IS NOT NULL AND %[1]s.expires_at <= now()
+			RETURNING version`, cs.kv)
+	} else {
+		q = fmt.Sprintf(`UPDATE %[1]s SET value = $2, version = nextval('%[1]s_version'),
+				updated_at = now(), expires_at = $3
+			WHERE key = $1 AND version = $4 AND %[2]s
+			RETURNING version`, cs.kv, live)
+		args = append(args, int64(version))
+	}
+	var next int64
+	err = cs.db.QueryRow(ctx, q, args...).Scan(&next)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%w: key %s", memory.ErrVersionConflict, key)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint64(next), nil
+}
+
+// Delete implements memory.Store
+func (cs *ConversationStore) Delete(ctx context.Context, key string) error {
+	_, err := cs.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, cs.kv), key)
+	return err
+}
+
+// List returns the live keys of the key/value table; sessions are listed with ListSessions
+func (cs *ConversationStore) List(ctx context.Context) ([]string, error) {
+	rows, err := cs.db.Query(ctx, fmt.Sprintf(`SELECT key FROM %s WHERE %s ORDER BY key`, cs.kv, live))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// Clear removes all keys and sessions
+func (cs *ConversationStore) Clear(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, cs.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s`, cs.kv)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s`, cs.sessions))
+		return err
+	})
+}
+
+// AppendMessage adds a message after the head of the session's active branch
+func (cs *ConversationStore) AppendMessage(ctx context.Context, sessionID string, role, content string) error {
+	_, err := cs.Append(ctx, sessionID, memory.Message{Role: role, Content: content})
+	return err
+}
+
+// Append implements memory.MessageAppender; the session row is locked while
+// the message is added, so concurrent appends are serialized
+func (cs *ConversationStore) Append(ctx context.Context, sessionID string, msg memory.Message) (string, error) {
+	return cs.add(ctx, sessionID, nil, msg)
+}
+
+// AddMessage implements memory.BranchingStore
+func (cs *ConversationStore) AddMessage(ctx context.Context, sessionID, parentID string, msg memory.Message) (string, error) {
+	return cs.add(ctx, sessionID, &parentID, msg)
+}
+
+// add inserts msg under parent (nil: the current head) and moves the head to it
+func (cs *ConversationStore) add(ctx context.Context, sessionID string, parent *string, msg memory.Message) (string, error) {
+	var id string
+	err := cs.withSession(ctx, sessionID, func(tx pgx.Tx, s *session) error {
+		parentID := s.head
+		if parent != nil {
+			parentID = *parent
+		}
+		depth := 1
+		if parentID != "" {
+			err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT depth + 1 FROM %s WHERE session_id = $1 AND id = $2`, cs.messages), sessionID, parentID).Scan(&depth)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: %s", memory.ErrMessageNotFound, parentID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		s.seq++
+		id = "m" + strconv.FormatInt(s.seq, 10)
+		created := time.Now()
+		if msg.Timestamp != 0 {
+			created = time.Unix(msg.Timestamp, 0)
+		}
+		meta, err := jsonOrNil(len(msg.Meta) > 0, msg.Meta)
+		if err != nil {
+			return err
+		}
+		calls, err := jsonOrNil(len(msg.ToolCalls) > 0, msg.ToolCalls)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s
+			(session_id, id, parent_id, seq, depth, role, content, tool_call_id, tool_calls, meta, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`, cs.messages),
+			sessionID, id, parentID, s.seq, depth, msg.Role, msg.Content, msg.ToolCallID, calls, meta, created)
+		s.head = id
+		return err
+	})
+	return id, err
+}
+
+// GetMessages returns the whole active branch; use GetMessagesPage for long sessions
+func (cs *ConversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
+	nodes, err := cs.queryNodes(ctx, cs.activePath()+` SELECT * FROM path ORDER BY seq`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]memory.Message, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.Message
+	}
+	return out, nil
+}
+
+// Page selects a window of the active branch
+type Page struct {
+	// Cursor continues from a previous MessagePage.Next ("" starts at an end)
+	Cursor string
+	// Limit is the page size (default 50)
+	Limit int
+	// Newest pages backwards from the latest message instead of forwards from the
+	// first. Newest pages read only Limit+1 rows; forward pages walk the branch
+	// from the head back to the cursor, so prefer Newest for long sessions.
+	Newest bool
+}
+
+// MessagePage is one page of the active branch, always in chronological order
+type MessagePage struct {
+	Messages []memory.Node
+	// Next is the cursor of the following page ("" when there are no more messages)
+	Next string
+}
+
+// GetMessagesPage returns one page of the active branch. The walk up the
+// tree stops at the page boundary instead of building the whole branch.
+func (cs *ConversationStore) GetMessagesPage(ctx context.Context, sessionID string, p Page) (MessagePage, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	from := ""
+	if p.Cursor != "" {
+		seq, err := strconv.ParseInt(p.Cursor, 10, 64)
+		if err != nil || seq <= 0 {
+			return MessagePage{}, fmt.Errorf("postgres: invalid cursor %q", p.Cursor)
+		}
+		from = "m" + strconv.FormatInt(seq, 10)
+	}
+	q := cs.forwardPage()
+	if p.Newest {
+		q = cs.newestPage()
+	}
+	nodes, err := cs.queryNodes(ctx, q, sessionID, from, p.Limit+1)
+	if err != nil {
+		return MessagePage{}, err
+	}
+	var page MessagePage
+	if len(nodes) > p.Limit {
+		nodes = nodes[:p.Limit]
+		page.Next = strconv.FormatInt(seqOf(nodes[len(nodes)-1].ID), 10)
+	}
+	if p.Newest {
+		for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+			nodes[i], nodes[j] = nodes[j], nodes[i]
+		}
+	}
+	page.Messages = nodes
+	return page, nil
+}
+
+// newestPage walks up from the head, or from the cursor $2 (which is skipped),
+// and stops after $3 messages, newest first
+func (cs *ConversationStore) newestPage() string {
+	return fmt.Sprintf(`WITH RECURSIVE path AS (
+			SELECT %[1]s, CASE WHEN $2::text = '' THEN 1 ELSE 0 END AS n
+			FROM %[2]s m JOIN %[3]s s ON s.id = m.session_id AND m.id = COALESCE(NULLIF($2::text, ''), s.head)
+			WHERE m.session_id = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+			UNION ALL
+			SELECT %[1]s, p.n + 1 FROM %[2]s m JOIN path p ON m.session_id = $1 AND m.id = p.parent_id
+			WHERE p.n < $3
+		) SELECT %[4]s FROM path WHERE n > 0 ORDER BY seq DESC`, nodeColumns, cs.messages, cs.sessions, pathColumns)
+}
+
+// forwardPage walks up from the head only as far as the cursor $2 (or the
+// root) and returns the first $3 messages after it
+func (cs *ConversationStore) forwardPage() string {
+	return fmt.Sprintf(`WITH RECURSIVE bound AS (
+			SELECT CASE WHEN $2::text = '' THEN 0
+				ELSE (SELECT depth FROM %[2]s WHERE session_id = $1 AND id = $2::text) END AS depth
+		), path AS (
+			SELECT %[1]s, m.depth FROM %[2]s m JOIN %[3]s s ON s.id = m.session_id AND s.head = m.id
+			WHERE m.session_id = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+			UNION ALL
+			SELECT %[1]s, m.depth FROM %[2]s m JOIN path p ON m.session_id = $1 AND m.id = p.parent_id
+			WHERE p.depth > (SELECT depth FROM bound) + 1
+		) SELECT %[4]s FROM path WHERE depth > (SELECT depth FROM bound) ORDER BY seq LIMIT $3`, nodeColumns, cs.messages, cs.sessions, pathColumns)
+}
+
+// seqOf recovers the sequence number from a message ID ("m12" -> 12)
+func seqOf(id string) int64 {
+	n, _ := strconv.ParseInt(id[1:], 10, 64)
+	return n
+}
+
+// ClearSession implements memory.ConversationStore; messages are removed by cascade
+func (cs *ConversationStore) ClearSession(ctx context.Context, sessionID string) error {
+	_, err := cs.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, cs.sessions), sessionID)
+	return err
+}
+
+// Head implements memory.BranchingStore
+func (cs *ConversationStore) Head(ctx context.Context, sessionID string) (string, error) {
+	var head string
+	err := cs.db.QueryRow(ctx, fmt.Sprintf(`SELECT head FROM %s WHERE id = $1 AND %s`, cs.sessions, live), sessionID).Scan(&head)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return head, err
+}
+
+// Path implements memory.BranchingStore
+func (cs *ConversationStore) Path(ctx context.Context, sessionID, messageID string) ([]memory.Node, error) {
+	q := fmt.Sprintf(`WITH RECURSIVE path AS (
+			SELECT %[1]s FROM %[2]s m JOIN %[3]s s ON s.id = m.session_id
+			WHERE m.session_id = $1 AND m.id = $2 AND (s.expires_at IS NULL OR s.expires_at > now())
+			UNION ALL
+			SELECT %[1]s FROM %[2]s m JOIN path p ON m.session_id = $1 AND m.id = p.parent_id
+		) SELECT * FROM path ORDER BY seq`, nodeColumns, cs.messages, cs.sessions)
+	nodes, err := cs.queryNodes(ctx, q, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 && messageID != "" {
+		return nil, fmt.Errorf("%w: %s", memory.ErrMessageNotFound, messageID)
+	}
+	return nodes, nil
+}
+
+// Fork implements memory.BranchingStore
+func (cs *ConversationStore) Fork(ctx context.Context, sessionID, messageID string) error {
+	return cs.withSession(ctx, sessionID, func(tx pgx.Tx, s *session) error {
+		if err := cs.checkMessage(ctx, tx, sessionID, messageID, false); err != nil {
+			return err
+		}
+		s.head = messageID
+		return nil
+	})
+}
+
+// Branches implements memory.BranchingStore
+func (cs *ConversationStore) Branches(ctx context.Context, sessionID string) ([]memory.Branch, error) {
+	rows, err := cs.db.Query(ctx, fmt.Sprintf(`SELECT m.id, m.depth, m.created_at, m.id = s.head
+		FROM %[1]s m JOIN %[2]s s ON s.id = m.session_id
+		WHERE m.session_id = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+			AND NOT EXISTS (SELECT 1 FROM %[1]s c WHERE c.session_id = m.session_id AND c.parent_id = m.id)
+		ORDER BY m.seq`, cs.messages, cs.sessions), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.Branch, error) {
+		var b memory.Branch
+		var created time.Time
+		err := row.Scan(&b.LeafID, &b.Length, &created, &b.Active)
+		b.Timestamp = created.Unix()
+		return b, err
+	})
+}
+
+// SwitchBranch implements memory.BranchingStore
+func (cs *ConversationStore) SwitchBranch(ctx context.Context, sessionID, leafID string) error {
+	return cs.withSession(ctx, sessionID, func(tx pgx.Tx, s *session) error {
+		if err := cs.checkMessage(ctx, tx, sessionID, leafID, true); err != nil {
+			return err
+		}
+		s.head = leafID
+		return nil
+	})
+}
+
+// checkMessage reports ErrMessageNotFound unless id exists ("" always does)
+// and, with leaf set, has no children
+func (cs *ConversationStore) checkMessage(ctx context.Context, tx pgx.Tx, sessionID, id string, leaf bool) error {
+	if id == "" && !leaf {
+		return nil
+	}
+	var ok bool
+	err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %[1]s m WHERE m.session_id = $1 AND m.id = $2
+		AND (NOT $3::boolean OR NOT EXISTS (SELECT 1 FROM %[1]s c WHERE c.session_id = m.session_id AND c.parent_id = m.id)))`, cs.messages),
+		sessionID, id, leaf).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok && leaf {
+		return fmt.Errorf("%w: no branch ends at %s", memory.ErrMessageNotFound, id)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", memory.ErrMessageNotFound, id)
+	}
+	return nil
+}
+
+// session is the locked state of a session row inside withSession
+type session struct {
+	head string
+	seq  int64
+}
+
+// withSession runs fn in a transaction holding the session row lock, creating
+// the session (or replacing an expired one) first, then saves head and seq
+func (cs *ConversationStore) withSession(ctx context.Context, sessionID string, fn func(pgx.Tx, *session) error) error {
+	return pgx.BeginFunc(ctx, cs.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND expires_at <= now()`, cs.sessions), sessionID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, expires_at) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, cs.sessions), sessionID, cs.expiresAt()); err != nil {
+			return err
+		}
+		var s session
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT head, seq FROM %s WHERE id = $1 FOR UPDATE`, cs.sessions), sessionID).Scan(&s.head, &s.seq); err != nil {
+			return err
+		}
+		if err := fn(tx, &s); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET head = $2, seq = $3, updated_at = now(), expires_at = $4 WHERE id = $1`, cs.sessions),
+			sessionID, s.head, s.seq, cs.expiresAt())
+		return err
+	})
+}
+
+// nodeColumns are the message columns read into a memory.Node
+const nodeColumns = `m.id, m.parent_id, m.seq, m.role, m.content, m.tool_call_id, m.tool_calls, m.meta, m.created_at`
+
+// pathColumns are nodeColumns as selected from a "path" CTE
+const pathColumns = `id, parent_id, seq, role, content, tool_call_id, tool_calls, meta, created_at`
+
+// activePath starts a query over the active branch of session $1 as "path"
+func (cs *ConversationStore) activePath() string {
+	return fmt.Sprintf(`WITH RECURSIVE path AS (
+			SELECT %[1]s FROM %[2]s m JOIN %[3]s s ON s.id = m.session_id AND s.head = m.id
+			WHERE m.session_id = $1 AND (s.expires_at IS NULL OR s.expires_at > now())
+			UNION ALL
+			SELECT %[1]s FROM %[2]s m JOIN path p ON m.session_id = $1 AND m.id = p.parent_id
+		)`, nodeColumns, cs.messages, cs.sessions)
+}
+
+func (cs *ConversationStore) queryNodes(ctx context.Context, q string, args ...any) ([]memory.Node, error) {
+	rows, err := cs.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.Node, error) {
+		var n memory.Node
+		var seq int64
+		var calls, meta []byte
+		var created time.Time
+		if err := row.Scan(&n.ID, &n.ParentID, &seq, &n.Role, &n.Content, &n.ToolCallID, &calls, &meta, &created); err != nil {
+			return n, err
+		}
+		n.Timestamp = created.Unix()
+		if len(calls) > 0 {
+			if err := json.Unmarshal(calls, &n.ToolCalls); err != nil {
+				return n, err
+			}
+		}
+		if len(meta) > 0 {
+			if err := json.Unmarshal(meta, &n.Meta); err != nil {
+				return n, err
+			}
+		}
+		return n, nil
+	})
+}
+
+// jsonOrNil encodes v for a jsonb column, or returns nil (SQL NULL) when unset
+func jsonOrNil(set bool, v interface{}) ([]byte, error) {
+	if !set {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+var _ memory.BranchingStore = (*ConversationStore)(nil)
+var _ memory.MessageAppender = (*ConversationStore)(nil)
+var _ memory.VersionedStore = (*ConversationStore)(nil)
+var _ memory.TypedStore = (*ConversationStore)(nil)
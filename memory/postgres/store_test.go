@@ -0,0 +1,168 @@
+//go:build adapters_postgres
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func openTestStore(t *testing.T, ttl time.Duration) *ConversationStore {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Skipf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	cs, err := New(ctx, pool, Config{Prefix: "pgtest_", TTL: ttl})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := cs.Clear(ctx); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	return cs
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	cs := openTestStore(t, 0)
+	if err := Migrate(context.Background(), cs.db, "pgtest_"); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	if err := Migrate(context.Background(), cs.db, "bad-prefix;"); err == nil {
+		t.Fatal("expected invalid prefix error")
+	}
+}
+
+func TestGetMessagesPage(t *testing.T) {
+	ctx := context.Background()
+	cs := openTestStore(t, 0)
+	for i := 1; i <= 7; i++ {
+		_ = cs.AppendMessage(ctx, "long", "user", fmt.Sprintf("m%d", i))
+	}
+
+	var got []string
+	page := Page{Limit: 3}
+	for {
+		p, err := cs.GetMessagesPage(ctx, "long", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range p.Messages {
+			got = append(got, n.Content)
+		}
+		if p.Next == "" {
+			break
+		}
+		page.Cursor = p.Next
+	}
+	if fmt.Sprint(got) != "[m1 m2 m3 m4 m5 m6 m7]" {
+		t.Fatalf("forward pages: %v", got)
+	}
+
+	p, err := cs.GetMessagesPage(ctx, "long", Page{Limit: 2, Newest: true})
+	if err != nil || len(p.Messages) != 2 || p.Messages[0].Content != "m6" || p.Messages[1].Content != "m7" {
+		t.Fatalf("newest page: %+v (%v)", p, err)
+	}
+	p, _ = cs.GetMessagesPage(ctx, "long", Page{Limit: 2, Newest: true, Cursor: p.Next})
+	if len(p.Messages) != 2 || p.Messages[0].Content != "m4" {
+		t.Fatalf("second newest page: %+v", p)
+	}
+
+	// Pages follow the active branch after a fork
+	_ = cs.Fork(ctx, "long", "m3")
+	_ = cs.AppendMessage(ctx, "long", "user", "b4")
+	p, _ = cs.GetMessagesPage(ctx, "long", Page{Limit: 2, Newest: true})
+	if len(p.Messages) != 2 || p.Messages[0].Content != "m3" || p.Messages[1].Content != "b4" {
+		t.Fatalf("newest page after fork: %+v", p)
+	}
+	p, _ = cs.GetMessagesPage(ctx, "long", Page{Limit: 3, Cursor: "2"})
+	if len(p.Messages) != 2 || p.Messages[0].Content != "m3" || p.Messages[1].Content != "b4" || p.Next != "" {
+		t.Fatalf("forward page after fork: %+v", p)
+	}
+}
+
+func TestSearchMessages_DefaultLimit(t *testing.T) {
+	ctx := context.Background()
+	cs := openTestStore(t, 0)
+	_ = cs.AppendMessage(ctx, "s", "user", "find me")
+	hits, err := cs.SearchMessages(ctx, "find", 0)
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("zero limit should use the default: %+v (%v)", hits, err)
+	}
+}
+
+func TestToolCallFieldsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cs := openTestStore(t, 0)
+	call := memory.ToolCall{ID: "call_1", Name: "search", Arguments: `{"q":"go"}`}
+	_, _ = cs.Append(ctx, "tools", memory.Message{Role: "assistant", ToolCalls: []memory.ToolCall{call}, Meta: map[string]string{"k": "v"}})
+	_, _ = cs.Append(ctx, "tools", memory.Message{Role: "tool", Content: "result", ToolCallID: "call_1"})
+	msgs, err := cs.GetMessages(ctx, "tools")
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("get: %+v (%v)", msgs, err)
+	}
+	if len(msgs[0].ToolCalls) != 1 || msgs[0].ToolCalls[0] != call || msgs[0].Meta["k"] != "v" || msgs[1].ToolCallID != "call_1" {
+		t.Fatalf("tool fields lost: %+v", msgs)
+	}
+}
+
+func TestListSessionsByUser(t *testing.T) {
+	ctx := context.Background()
+	cs := openTestStore(t, 0)
+	_ = cs.AppendMessage(ctx, "a", "user", "hi")
+	_ = cs.SetSession(ctx, "a", "ada", map[string]string{"title": "first"})
+	_ = cs.SetSession(ctx, "b", "ada", nil)
+	_ = cs.AppendMessage(ctx, "b", "user", "hello")
+	_ = cs.SetSession(ctx, "c", "bob", nil)
+
+	sessions, err := cs.ListSessions(ctx, "ada", 10, 0)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("list: %+v (%v)", sessions, err)
+	}
+	if sessions[0].ID != "b" || sessions[1].Meta["title"] != "first" || sessions[1].Messages != 1 {
+		t.Fatalf("want most recent first with meta: %+v", sessions)
+	}
+	if page, _ := cs.ListSessions(ctx, "ada", 1, 1); len(page) != 1 || page[0].ID != "a" {
+		t.Fatalf("offset: %+v", page)
+	}
+}
+
+func TestTTLAndCleanup(t *testing.T) {
+	ctx := context.Background()
+	cs := openTestStore(t, 50*time.Millisecond)
+	_ = cs.AppendMessage(ctx, "short", "user", "hi")
+	_ = cs.Store(ctx, "k", "v")
+	time.Sleep(100 * time.Millisecond)
+
+	if msgs, _ := cs.GetMessages(ctx, "short"); len(msgs) != 0 {
+		t.Fatalf("expired session still readable: %+v", msgs)
+	}
+	if _, err := cs.Retrieve(ctx, "k"); err == nil {
+		t.Fatal("expired key still readable")
+	}
+	n, err := cs.Cleanup(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("cleanup removed %d (%v), want 2", n, err)
+	}
+
+	// Writing to an expired session starts it over
+	_ = cs.AppendMessage(ctx, "short", "user", "again")
+	if msgs, _ := cs.GetMessages(ctx, "short"); len(msgs) != 1 || msgs[0].Content != "again" {
+		t.Fatalf("restarted session: %+v", msgs)
+	}
+	if n, _ := cs.DeleteBefore(ctx, time.Now().Add(time.Minute)); n != 1 {
+		t.Fatalf("retention should delete the session, removed %d", n)
+	}
+}
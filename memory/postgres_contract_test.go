@@ -0,0 +1,49 @@
+//go:build adapters_postgres
+
+package memory_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	mem "github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// openPostgres returns an empty store in the DATABASE_URL database, using a
+// test-only table prefix
+func openPostgres(t *testing.T) *postgres.ConversationStore {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Skipf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	cs, err := postgres.New(ctx, pool, postgres.Config{Prefix: "contract_"})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := cs.Clear(ctx); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	return cs
+}
+
+func TestStoreContract_Postgres(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) mem.Store { return openPostgres(t) })
+	runTypedContract(t, func(t *testing.T) mem.Store { return openPostgres(t) })
+	runConcurrencyContract(t, func(t *testing.T) mem.Store { return openPostgres(t) })
+}
+
+func TestConversationContract_Postgres(t *testing.T) {
+	runConversationContract(t, func(t *testing.T) mem.ConversationStore { return openPostgres(t) })
+	runBranchingContract(t, func(t *testing.T) mem.BranchingStore { return openPostgres(t) })
+	runAppendConcurrency(t, func(t *testing.T) mem.ConversationStore { return openPostgres(t) })
+}
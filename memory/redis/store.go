@@ -7,6 +7,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/KamdynS/go-agents/memory"
@@ -17,50 +20,211 @@ type Store struct {
 	client *rds.Client
 	ttl    time.Duration
 	prefix string
+	codec  memory.Codec
 }
 
 func NewStore(client *rds.Client, ttl time.Duration, prefix string) *Store {
-	return &Store{client: client, ttl: ttl, prefix: prefix}
+	return &Store{client: client, ttl: ttl, prefix: prefix, codec: memory.JSONCodec}
 }
 
+// WithCodec sets the value codec (JSON by default). Codecs that cannot decode
+// into interface{} (gob) require reading through memory.Get or RetrieveInto.
+func (s *Store) WithCodec(c memory.Codec) *Store {
+	s.codec = c
+	return s
+}
+
+// key wraps k in a hash tag, so the value and its version key land in the
+// same Redis Cluster slot
 func (s *Store) key(k string) string {
+	if s.prefix == "" {
+		return "{" + k + "}"
+	}
+	return s.prefix + ":{" + k + "}"
+}
+
+// legacyKey is the untagged name values had before key wrapped them in a hash
+// tag. Reads fall back to it and writes move its value to key.
+func (s *Store) legacyKey(k string) string {
 	if s.prefix == "" {
 		return k
 	}
 	return s.prefix + ":" + k
 }
 
-func (s *Store) Store(ctx context.Context, key string, value interface{}) error {
-	b, err := json.Marshal(value)
+// migrate moves a value stored under the legacy name to the current one,
+// keeping its TTL. SETNX keeps a value already written under the new name.
+func (s *Store) migrate(ctx context.Context, key string) error {
+	old := s.legacyKey(key)
+	val, err := s.client.Get(ctx, old).Bytes()
+	if errors.Is(err, rds.Nil) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	return s.client.Set(ctx, s.key(key), b, s.ttl).Err()
+	ttl, err := s.client.PTTL(ctx, old).Result()
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := s.client.SetNX(ctx, s.key(key), val, ttl).Err(); err != nil {
+		return err
+	}
+	return s.client.Del(ctx, old).Err()
 }
 
-func (s *Store) Retrieve(ctx context.Context, key string) (interface{}, error) {
-	val, err := s.client.Get(ctx, s.key(key)).Bytes()
+// unkey reverses key (or legacyKey) for keys found by List
+func (s *Store) unkey(k string) string {
+	if s.prefix != "" {
+		k = strings.TrimPrefix(k, s.prefix+":")
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(k, "{"), "}")
+}
+
+// versionSuffix names the companion key holding a key's version
+const versionSuffix = ":__version"
+
+// setScript writes a value and its version atomically. ARGV[3] is the
+// expected version, or -1 for an unconditional write. Each key counts its own
+// versions, so the script only touches one cluster slot. Values written
+// before versioning existed have no version key and count as version 1.
+// Delete removes the version key with the value, so a recreated key starts
+// over at version 1.
+var setScript = rds.NewScript(`
+local exists = redis.call('EXISTS', KEYS[1]) == 1
+local cur = tonumber(redis.call('GET', KEYS[2]) or (exists and '1' or '0'))
+local expected = tonumber(ARGV[3])
+if expected >= 0 then
+  local have = 0
+  if exists then
+    have = cur
+  end
+  if have ~= expected then
+    return -1
+  end
+end
+local v = cur + 1
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+  redis.call('SET', KEYS[2], v, 'PX', ttl)
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+  redis.call('SET', KEYS[2], v)
+end
+return v
+`)
+
+func (s *Store) Store(ctx context.Context, key string, value interface{}) error {
+	_, err := s.set(ctx, key, -1, value)
+	return err
+}
+
+// set runs setScript; expected is -1 for an unconditional write
+func (s *Store) set(ctx context.Context, key string, expected int64, value interface{}) (int64, error) {
+	b, err := s.codec.Marshal(value)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.migrate(ctx, key); err != nil {
+		return 0, err
+	}
+	k := s.key(key)
+	keys := []string{k, k + versionSuffix}
+	return setScript.Run(ctx, s.client, keys, b, s.ttl.Milliseconds(), expected).Int64()
+}
+
+// RetrieveVersion implements memory.VersionedStore
+func (s *Store) RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error) {
+	k := s.key(key)
+	vals, err := s.client.MGet(ctx, k, k+versionSuffix).Result()
 	if err != nil {
+		return 0, err
+	}
+	raw, ok := vals[0].(string)
+	if !ok {
+		// A legacy value has no version key: version 1, like any unversioned value
+		val, err := s.client.Get(ctx, s.legacyKey(key)).Bytes()
 		if errors.Is(err, rds.Nil) {
-			return nil, fmt.Errorf("key %s not found", key)
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 1, s.codec.Unmarshal(val, out)
+	}
+	version := uint64(1)
+	if v, ok := vals[1].(string); ok {
+		if version, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, fmt.Errorf("key %s: bad version %q", key, v)
 		}
-		return nil, err
 	}
+	return version, s.codec.Unmarshal([]byte(raw), out)
+}
+
+// CompareAndSwap implements memory.VersionedStore with a Lua script, so the
+// check and the write are atomic on the server
+func (s *Store) CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error) {
+	v, err := s.set(ctx, key, int64(version), value)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("%w: key %s", memory.ErrVersionConflict, key)
+	}
+	return uint64(v), nil
+}
+
+// Retrieve decodes into generic values (maps, slices, float64); use memory.Get
+// or RetrieveInto to get the stored type back
+func (s *Store) Retrieve(ctx context.Context, key string) (interface{}, error) {
 	var out interface{}
-	if err := json.Unmarshal(val, &out); err != nil {
+	if err := s.RetrieveInto(ctx, key, &out); err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
+// RetrieveInto implements memory.TypedStore by decoding straight into out
+func (s *Store) RetrieveInto(ctx context.Context, key string, out interface{}) error {
+	val, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, rds.Nil) {
+		val, err = s.client.Get(ctx, s.legacyKey(key)).Bytes()
+	}
+	if err != nil {
+		if errors.Is(err, rds.Nil) {
+			return fmt.Errorf("key %s %w", key, memory.ErrNotFound)
+		}
+		return err
+	}
+	return s.codec.Unmarshal(val, out)
+}
+
+// Delete removes the value, its version and any legacy copy
 func (s *Store) Delete(ctx context.Context, key string) error {
-	return s.client.Del(ctx, s.key(key)).Err()
+	_, err := s.client.Pipelined(ctx, func(p rds.Pipeliner) error {
+		s.del(ctx, p, key)
+		return nil
+	})
+	return err
+}
+
+// del queues the deletion of a key. The value and its version share a slot;
+// the legacy name may not, so it gets its own DEL.
+func (s *Store) del(ctx context.Context, p rds.Pipeliner, key string) {
+	k := s.key(key)
+	p.Del(ctx, k, k+versionSuffix)
+	p.Del(ctx, s.legacyKey(key))
 }
 
 func (s *Store) List(ctx context.Context) ([]string, error) {
 	// For simplicity, scan all keys with prefix
 	var cursor uint64
 	keys := []string{}
+	seen := map[string]bool{}
 	pattern := s.prefix + ":*"
 	if s.prefix == "" {
 		pattern = "*"
@@ -71,7 +235,14 @@ func (s *Store) List(ctx context.Context) ([]string, error) {
 			return nil, err
 		}
 		for _, k := range ks {
-			keys = append(keys, k)
+			if strings.HasSuffix(k, versionSuffix) {
+				continue
+			}
+			// A key may exist under both names while it is migrated
+			if k = s.unkey(k); !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
 		}
 		if cur == 0 {
 			break
@@ -89,10 +260,18 @@ func (s *Store) Clear(ctx context.Context) error {
 	if len(keys) == 0 {
 		return nil
 	}
-	return s.client.Del(ctx, keys...).Err()
+	// DELs per key: the keys live in different cluster slots
+	_, err = s.client.Pipelined(ctx, func(p rds.Pipeliner) error {
+		for _, k := range keys {
+			s.del(ctx, p, k)
+		}
+		return nil
+	})
+	return err
 }
 
-var _ memory.Store = (*Store)(nil)
+var _ memory.TypedStore = (*Store)(nil)
+var _ memory.VersionedStore = (*Store)(nil)
 
 type ConversationStore struct {
 	client *rds.Client
@@ -121,18 +300,23 @@ func (cs *ConversationStore) Store(ctx context.Context, key string, value interf
 }
 
 func (cs *ConversationStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	var out interface{}
+	if err := cs.RetrieveInto(ctx, key, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RetrieveInto implements memory.TypedStore by decoding straight into out
+func (cs *ConversationStore) RetrieveInto(ctx context.Context, key string, out interface{}) error {
 	val, err := cs.client.Get(ctx, cs.convKey(key)).Bytes()
 	if err != nil {
 		if errors.Is(err, rds.Nil) {
-			return nil, fmt.Errorf("key %s not found", key)
+			return fmt.Errorf("key %s %w", key, memory.ErrNotFound)
 		}
-		return nil, err
-	}
-	var out interface{}
-	if err := json.Unmarshal(val, &out); err != nil {
-		return nil, err
+		return err
 	}
-	return out, nil
+	return json.Unmarshal(val, out)
 }
 
 func (cs *ConversationStore) Delete(ctx context.Context, key string) error {
@@ -168,45 +352,258 @@ func (cs *ConversationStore) Clear(ctx context.Context) error {
 	return cs.client.Del(ctx, keys...).Err()
 }
 
+// AppendMessage adds a message after the head of the session's active branch
 func (cs *ConversationStore) AppendMessage(ctx context.Context, sessionID string, role, content string) error {
-	key := cs.convKey(sessionID)
-	// append to list for efficient streaming
-	msg := memory.Message{Role: role, Content: content, Timestamp: time.Now().Unix()}
+	_, err := cs.Append(ctx, sessionID, memory.Message{Role: role, Content: content})
+	return err
+}
+
+// Append implements memory.MessageAppender; appendScript reads the head and
+// adds the message in one step on the server
+func (cs *ConversationStore) Append(ctx context.Context, sessionID string, msg memory.Message) (string, error) {
+	return cs.add(ctx, sessionID, "", true, msg)
+}
+
+// GetMessages returns the active branch of the session
+func (cs *ConversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
+	t, err := cs.load(ctx, cs.client, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Messages(), nil
+}
+
+func (cs *ConversationStore) ClearSession(ctx context.Context, sessionID string) error {
+	return cs.client.Del(ctx, cs.convKey(sessionID)).Err()
+}
+
+func (cs *ConversationStore) AddMessage(ctx context.Context, sessionID, parentID string, msg memory.Message) (string, error) {
+	return cs.add(ctx, sessionID, parentID, false, msg)
+}
+
+func (cs *ConversationStore) Head(ctx context.Context, sessionID string) (string, error) {
+	t, err := cs.load(ctx, cs.client, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return t.Head, nil
+}
+
+func (cs *ConversationStore) Path(ctx context.Context, sessionID, messageID string) ([]memory.Node, error) {
+	t, err := cs.load(ctx, cs.client, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Path(messageID)
+}
+
+func (cs *ConversationStore) Fork(ctx context.Context, sessionID, messageID string) error {
+	return cs.update(ctx, sessionID, func(t *memory.Tree) error { return t.Checkout(messageID) })
+}
+
+func (cs *ConversationStore) Branches(ctx context.Context, sessionID string) ([]memory.Branch, error) {
+	t, err := cs.load(ctx, cs.client, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Branches(), nil
+}
+
+func (cs *ConversationStore) SwitchBranch(ctx context.Context, sessionID, leafID string) error {
+	return cs.update(ctx, sessionID, func(t *memory.Tree) error {
+		if !t.IsLeaf(leafID) {
+			return fmt.Errorf("%w: no branch ends at %s", memory.ErrMessageNotFound, leafID)
+		}
+		return t.Checkout(leafID)
+	})
+}
+
+// Sessions are Redis hashes: one field per node ("m1", "m2", ... holding the
+// node JSON) plus "head" and "seq". Appends only write the new node, so their
+// cost does not grow with the conversation.
+const (
+	headField = "head"
+	seqField  = "seq"
+)
+
+// appendScript adds a node and moves the head to it. ARGV[1] is the parent,
+// ARGV[2] the message JSON, ARGV[3] the TTL in ms; with ARGV[4] = "1" the
+// parent is the current head. It returns the new ID, 0 for an unknown parent,
+// or -1 when the session is still in an older format and must be converted.
+var appendScript = rds.NewScript(`
+local typ = redis.call('TYPE', KEYS[1]).ok
+if typ ~= 'hash' and typ ~= 'none' then
+  return -1
+end
+local parent = ARGV[1]
+if ARGV[4] == '1' then
+  parent = redis.call('HGET', KEYS[1], 'head') or ''
+elseif parent ~= '' and (not string.match(parent, '^m%d+$') or redis.call('HEXISTS', KEYS[1], parent) == 0) then
+  return 0
+end
+local id = 'm' .. redis.call('HINCRBY', KEYS[1], 'seq', 1)
+local node = '{"id":"' .. id .. '"'
+if parent ~= '' then
+  node = node .. ',"parent_id":"' .. parent .. '"'
+end
+node = node .. ',' .. string.sub(ARGV[2], 2)
+redis.call('HSET', KEYS[1], id, node, 'head', id)
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return id
+`)
+
+// add runs appendScript, converting a session stored in an older format first
+func (cs *ConversationStore) add(ctx context.Context, sessionID, parentID string, atHead bool, msg memory.Message) (string, error) {
+	if msg.Timestamp == 0 {
+		msg.Timestamp = time.Now().Unix()
+	}
 	b, err := json.Marshal(msg)
 	if err != nil {
-		return err
+		return "", err
 	}
-	if err := cs.client.RPush(ctx, key, b).Err(); err != nil {
-		return err
+	head := "0"
+	if atHead {
+		head = "1"
 	}
-	if cs.ttl > 0 {
-		_ = cs.client.Expire(ctx, key, cs.ttl).Err()
+	keys := []string{cs.convKey(sessionID)}
+	for attempt := 0; ; attempt++ {
+		res, err := appendScript.Run(ctx, cs.client, keys, parentID, b, cs.ttl.Milliseconds(), head).Result()
+		if err != nil {
+			return "", err
+		}
+		switch v := res.(type) {
+		case string:
+			return v, nil
+		case int64:
+			if v == 0 {
+				return "", fmt.Errorf("%w: %s", memory.ErrMessageNotFound, parentID)
+			}
+		}
+		if attempt > 0 {
+			return "", fmt.Errorf("conversation %s: unexpected append result %v", sessionID, res)
+		}
+		// Rewriting the tree unchanged stores it as a hash
+		if err := cs.update(ctx, sessionID, func(*memory.Tree) error { return nil }); err != nil {
+			return "", err
+		}
 	}
-	return nil
 }
 
-func (cs *ConversationStore) GetMessages(ctx context.Context, sessionID string) ([]memory.Message, error) {
+// load reads the session tree. Sessions written by older versions as a JSON
+// tree, or as a Redis list of messages (a single branch), are read too.
+func (cs *ConversationStore) load(ctx context.Context, c rds.Cmdable, sessionID string) (*memory.Tree, error) {
 	key := cs.convKey(sessionID)
-	vals, err := cs.client.LRange(ctx, key, 0, -1).Result()
+	typ, err := c.Type(ctx, key).Result()
 	if err != nil {
-		if errors.Is(err, rds.Nil) {
-			return []memory.Message{}, nil
-		}
 		return nil, err
 	}
-	msgs := make([]memory.Message, 0, len(vals))
-	for _, v := range vals {
-		var m memory.Message
-		if err := json.Unmarshal([]byte(v), &m); err != nil {
+	t := &memory.Tree{}
+	switch typ {
+	case "none":
+		return t, nil
+	case "hash":
+		return decodeTree(sessionID, c.HGetAll(ctx, key))
+	case "list":
+		vals, err := c.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
 			return nil, err
 		}
-		msgs = append(msgs, m)
+		for _, v := range vals {
+			var m memory.Message
+			if err := json.Unmarshal([]byte(v), &m); err != nil {
+				return nil, err
+			}
+			_, _ = t.Add(t.Head, m)
+		}
+		return t, nil
+	}
+	val, err := c.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(val, t); err != nil {
+		return nil, fmt.Errorf("decode conversation %s: %w", sessionID, err)
+	}
+	return t, nil
+}
+
+// decodeTree builds a tree from the fields of a session hash
+func decodeTree(sessionID string, cmd *rds.MapStringStringCmd) (*memory.Tree, error) {
+	fields, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	t := &memory.Tree{Head: fields[headField]}
+	if v, ok := fields[seqField]; ok {
+		if t.Seq, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("conversation %s: bad seq %q", sessionID, v)
+		}
+	}
+	for f, v := range fields {
+		if f == headField || f == seqField {
+			continue
+		}
+		var n memory.Node
+		if err := json.Unmarshal([]byte(v), &n); err != nil {
+			return nil, fmt.Errorf("decode conversation %s: %w", sessionID, err)
+		}
+		t.Nodes = append(t.Nodes, n)
 	}
-	return msgs, nil
+	// Nodes are kept in creation order, which is the order of their IDs
+	sort.Slice(t.Nodes, func(i, j int) bool { return nodeSeq(t.Nodes[i].ID) < nodeSeq(t.Nodes[j].ID) })
+	return t, nil
 }
 
-func (cs *ConversationStore) ClearSession(ctx context.Context, sessionID string) error {
-	return cs.client.Del(ctx, cs.convKey(sessionID)).Err()
+func nodeSeq(id string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(id, "m"))
+	return n
+}
+
+// maxTxAttempts bounds the optimistic retries of a contended session; only
+// head moves and format conversion take this path, appends use appendScript
+const maxTxAttempts = 100
+
+// update applies fn to the session tree with optimistic locking (WATCH/MULTI)
+// and rewrites the whole session hash
+func (cs *ConversationStore) update(ctx context.Context, sessionID string, fn func(*memory.Tree) error) error {
+	key := cs.convKey(sessionID)
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err := cs.client.Watch(ctx, func(tx *rds.Tx) error {
+			t, err := cs.load(ctx, tx, sessionID)
+			if err != nil {
+				return err
+			}
+			if err := fn(t); err != nil {
+				return err
+			}
+			fields := []interface{}{headField, t.Head, seqField, t.Seq}
+			for _, n := range t.Nodes {
+				b, err := json.Marshal(n)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, n.ID, b)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe rds.Pipeliner) error {
+				pipe.Del(ctx, key)
+				pipe.HSet(ctx, key, fields...)
+				if cs.ttl > 0 {
+					pipe.PExpire(ctx, key, cs.ttl)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if !errors.Is(err, rds.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("conversation %s: too much contention", sessionID)
 }
 
-var _ memory.ConversationStore = (*ConversationStore)(nil)
+var _ memory.BranchingStore = (*ConversationStore)(nil)
+var _ memory.MessageAppender = (*ConversationStore)(nil)
+var _ memory.TypedStore = (*ConversationStore)(nil)
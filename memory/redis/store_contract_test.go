@@ -4,6 +4,9 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -55,3 +58,182 @@ func TestConversationContract_Redis(t *testing.T) {
 		t.Fatal("expected messages")
 	}
 }
+
+func TestBranchingContract_Redis(t *testing.T) {
+	ctx := context.Background()
+	client := rds.NewClient(&rds.Options{Addr: "localhost:6379"})
+	t.Cleanup(func() { _ = client.Close() })
+	cs := NewConversationStore(client, "test", time.Minute)
+	_ = cs.ClearSession(ctx, "tree")
+
+	if err := cs.AppendMessage(ctx, "tree", "user", "q"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := cs.AppendMessage(ctx, "tree", "assistant", "a1"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	a1, _ := cs.Head(ctx, "tree")
+	path, _ := cs.Path(ctx, "tree", a1)
+	// Regenerate: fork at the question and add a second answer
+	if err := cs.Fork(ctx, "tree", path[0].ID); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	if err := cs.AppendMessage(ctx, "tree", "assistant", "a2"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	branches, err := cs.Branches(ctx, "tree")
+	if err != nil || len(branches) != 2 {
+		t.Fatalf("want 2 branches got %+v (%v)", branches, err)
+	}
+	if err := cs.SwitchBranch(ctx, "tree", a1); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	msgs, _ := cs.GetMessages(ctx, "tree")
+	if len(msgs) != 2 || msgs[1].Content != "a1" {
+		t.Fatalf("unexpected active branch: %+v", msgs)
+	}
+}
+
+func TestTypedRoundTrip_Redis(t *testing.T) {
+	type profile struct {
+		Name string
+		Tags []string
+	}
+	ctx := context.Background()
+	for _, codec := range []mem.Codec{mem.JSONCodec, mem.GobCodec} {
+		s := makeRedisStore(t).(*Store).WithCodec(codec)
+		want := profile{Name: "ada", Tags: []string{"a"}}
+		if err := mem.Put(ctx, s, "typed", want); err != nil {
+			t.Fatalf("%s put: %v", codec.Name(), err)
+		}
+		got, err := mem.Get[profile](ctx, s, "typed")
+		if err != nil || got.Name != want.Name || len(got.Tags) != 1 {
+			t.Fatalf("%s round trip: %+v (%v)", codec.Name(), got, err)
+		}
+		msgs := []mem.Message{{Role: "user", Content: "hi"}}
+		_ = mem.Put(ctx, s, "msgs", msgs)
+		if got, err := mem.Get[[]mem.Message](ctx, s, "msgs"); err != nil || len(got) != 1 || got[0].Content != "hi" {
+			t.Fatalf("%s messages round trip: %+v (%v)", codec.Name(), got, err)
+		}
+	}
+}
+
+func TestConcurrency_Redis(t *testing.T) {
+	ctx := context.Background()
+	s := makeRedisStore(t)
+	_ = s.Delete(ctx, "counter")
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				err := mem.Update(ctx, s, "counter", func(cur []int, _ bool) ([]int, error) {
+					return append(cur, w*10+i), nil
+				})
+				if err != nil {
+					t.Errorf("update: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if got, err := mem.Get[[]int](ctx, s, "counter"); err != nil || len(got) != 80 {
+		t.Fatalf("lost updates: %d values (%v)", len(got), err)
+	}
+	vs := s.(mem.VersionedStore)
+	var out []int
+	v, _ := vs.RetrieveVersion(ctx, "counter", &out)
+	if _, err := vs.CompareAndSwap(ctx, "counter", v+1, out); !errors.Is(err, mem.ErrVersionConflict) {
+		t.Fatalf("stale version should conflict, got %v", err)
+	}
+	keys, _ := s.List(ctx)
+	for _, k := range keys {
+		if strings.HasSuffix(k, versionSuffix) {
+			t.Fatalf("List should hide version keys: %v", keys)
+		}
+	}
+	// Delete drops the version key too, so a recreated key starts over
+	rs := s.(*Store)
+	_ = s.Delete(ctx, "counter")
+	if n, _ := rs.client.Exists(ctx, rs.key("counter")+versionSuffix).Result(); n != 0 {
+		t.Fatalf("version key left behind")
+	}
+	if nv, err := vs.CompareAndSwap(ctx, "counter", 0, out); err != nil || nv != 1 {
+		t.Fatalf("recreated key should start at version 1, got %d (%v)", nv, err)
+	}
+
+	cs := makeRedisConv(t).(*ConversationStore)
+	_ = cs.ClearSession(ctx, "busy")
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				if _, err := cs.Append(ctx, "busy", mem.Message{Role: "user", Content: "m"}); err != nil {
+					t.Errorf("append: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if msgs, err := cs.GetMessages(ctx, "busy"); err != nil || len(msgs) != 40 {
+		t.Fatalf("lost messages: %d (%v)", len(msgs), err)
+	}
+}
+
+func TestLegacyKeys_Redis(t *testing.T) {
+	ctx := context.Background()
+	s := makeRedisStore(t).(*Store)
+	_ = s.Delete(ctx, "old")
+	// Written by a version without hash tags
+	if err := s.client.Set(ctx, "test:old", `"v1"`, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Retrieve(ctx, "old"); err != nil || v != "v1" {
+		t.Fatalf("legacy value not read: %v (%v)", v, err)
+	}
+	var out string
+	if v, err := s.RetrieveVersion(ctx, "old", &out); err != nil || v != 1 || out != "v1" {
+		t.Fatalf("legacy value should be version 1: %d %q (%v)", v, out, err)
+	}
+	if keys, _ := s.List(ctx); strings.Count(strings.Join(keys, ","), "old") != 1 {
+		t.Fatalf("legacy key should be listed once: %v", keys)
+	}
+	if _, err := s.CompareAndSwap(ctx, "old", 1, "v2"); err != nil {
+		t.Fatalf("cas on legacy value: %v", err)
+	}
+	if n, _ := s.client.Exists(ctx, "test:old").Result(); n != 0 {
+		t.Fatalf("legacy key should be migrated on write")
+	}
+	if v, err := s.Retrieve(ctx, "old"); err != nil || v != "v2" {
+		t.Fatalf("migrated value: %v (%v)", v, err)
+	}
+}
+
+func TestConversationFormats_Redis(t *testing.T) {
+	ctx := context.Background()
+	cs := makeRedisConv(t).(*ConversationStore)
+	_ = cs.ClearSession(ctx, "old")
+	// A session written as a JSON tree by an older version
+	tree := `{"nodes":[{"id":"m1","role":"user","content":"q","timestamp":1}],"head":"m1","seq":1}`
+	if err := cs.client.Set(ctx, cs.convKey("old"), tree, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	id, err := cs.Append(ctx, "old", mem.Message{Role: "assistant", Content: "a"})
+	if err != nil || id != "m2" {
+		t.Fatalf("append to old session: %q (%v)", id, err)
+	}
+	if typ, _ := cs.client.Type(ctx, cs.convKey("old")).Result(); typ != "hash" {
+		t.Fatalf("session should be converted to a hash, is %s", typ)
+	}
+	path, err := cs.Path(ctx, "old", id)
+	if err != nil || len(path) != 2 || path[1].ParentID != "m1" || path[1].Content != "a" {
+		t.Fatalf("unexpected path: %+v (%v)", path, err)
+	}
+	if _, err := cs.AddMessage(ctx, "old", "head", mem.Message{Role: "user"}); !errors.Is(err, mem.ErrMessageNotFound) {
+		t.Fatalf("unknown parent should fail, got %v", err)
+	}
+}
@@ -1,6 +1,13 @@
 package memory
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is wrapped by Retrieve and RetrieveInto errors for missing keys,
+// so callers can tell an absent key from a failing backend with errors.Is
+var ErrNotFound = errors.New("not found")
 
 // Store defines the interface for agent memory/state management
 type Store interface {
@@ -40,6 +47,17 @@ type Message struct {
 	Content   string            `json:"content"`
 	Timestamp int64             `json:"timestamp"`
 	Meta      map[string]string `json:"meta,omitempty"`
+	// ToolCalls are the tool invocations requested by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a tool result message to the call it answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a tool invocation recorded in conversation history
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // VectorStore defines the interface for vector-based retrieval (RAG)
@@ -57,6 +75,19 @@ type VectorStore interface {
 	GetDocument(ctx context.Context, id string) (*Document, error)
 }
 
+// DocumentWriter is implemented by vector stores that upsert full documents
+// (including Meta) in batches
+type DocumentWriter interface {
+	// AddDocuments inserts or replaces docs by ID
+	AddDocuments(ctx context.Context, docs []Document) error
+}
+
+// FilteredVectorStore is implemented by vector stores that can restrict a
+// similarity query to documents whose Meta contains every pair in filter
+type FilteredVectorStore interface {
+	QuerySimilarFiltered(ctx context.Context, queryEmbedding []float64, limit int, filter map[string]string) ([]Document, error)
+}
+
 // Document represents a stored document with its metadata
 type Document struct {
 	ID        string            `json:"id"`
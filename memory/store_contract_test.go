@@ -2,14 +2,22 @@ package memory_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 
 	mem "github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/encrypted"
+	"github.com/KamdynS/go-agents/memory/file"
 	inm "github.com/KamdynS/go-agents/memory/inmemory"
 )
 
 type storeFactory func(t *testing.T) mem.Store
 type convFactory func(t *testing.T) mem.ConversationStore
+type branchFactory func(t *testing.T) mem.BranchingStore
 
 func runStoreContract(t *testing.T, makeStore storeFactory) {
 	t.Helper()
@@ -41,8 +49,8 @@ func runStoreContract(t *testing.T, makeStore storeFactory) {
 	if err := s.Delete(ctx, "k1"); err != nil {
 		t.Fatalf("delete: %v", err)
 	}
-	if _, err := s.Retrieve(ctx, "k1"); err == nil {
-		t.Fatalf("expected error for missing key")
+	if _, err := s.Retrieve(ctx, "k1"); !errors.Is(err, mem.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for missing key, got %v", err)
 	}
 
 	// Clear
@@ -56,6 +64,102 @@ func runStoreContract(t *testing.T, makeStore storeFactory) {
 	}
 }
 
+type profile struct {
+	Name   string             `json:"name"`
+	Tags   []string           `json:"tags"`
+	Scores map[string]float64 `json:"scores"`
+	Count  int                `json:"count"`
+}
+
+// runTypedContract checks that structured values written with Put come back
+// with their type through Get, whatever the backend's native representation
+func runTypedContract(t *testing.T, makeStore storeFactory) {
+	t.Helper()
+	ctx := context.Background()
+	s := makeStore(t)
+
+	p := profile{Name: "ada", Tags: []string{"a", "b"}, Scores: map[string]float64{"x": 1.5}, Count: 3}
+	if err := mem.Put(ctx, s, "profile", p); err != nil {
+		t.Fatalf("put struct: %v", err)
+	}
+	gotP, err := mem.Get[profile](ctx, s, "profile")
+	if err != nil || !reflect.DeepEqual(gotP, p) {
+		t.Fatalf("struct round trip: got %+v (%v)", gotP, err)
+	}
+
+	msgs := []mem.Message{{Role: "user", Content: "hi", Timestamp: 1}, {Role: "assistant", Content: "yo", Meta: map[string]string{"k": "v"}}}
+	if err := mem.Put(ctx, s, "msgs", msgs); err != nil {
+		t.Fatalf("put slice: %v", err)
+	}
+	gotM, err := mem.Get[[]mem.Message](ctx, s, "msgs")
+	if err != nil || !reflect.DeepEqual(gotM, msgs) {
+		t.Fatalf("slice round trip: got %+v (%v)", gotM, err)
+	}
+
+	if err := mem.Put(ctx, s, "n", 42); err != nil {
+		t.Fatalf("put int: %v", err)
+	}
+	if n, err := mem.Get[int](ctx, s, "n"); err != nil || n != 42 {
+		t.Fatalf("int round trip: got %v (%v)", n, err)
+	}
+
+	if _, err := mem.Get[profile](ctx, s, "missing"); err == nil {
+		t.Fatalf("expected error for missing key")
+	}
+	if _, err := mem.Get[int](ctx, s, "profile"); err == nil {
+		t.Fatalf("expected error decoding a struct into int")
+	}
+}
+
+// jsonStore mimics byte-oriented backends without RetrieveInto: values come
+// back as generic JSON (maps, slices, float64)
+type jsonStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *jsonStore) Store(ctx context.Context, key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = b
+	return nil
+}
+func (s *jsonStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s %w", key, mem.ErrNotFound)
+	}
+	var out interface{}
+	return out, json.Unmarshal(b, &out)
+}
+func (s *jsonStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+func (s *jsonStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+func (s *jsonStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = map[string][]byte{}
+	return nil
+}
+
 func runConversationContract(t *testing.T, makeConv convFactory) {
 	t.Helper()
 	ctx := context.Background()
@@ -92,6 +196,168 @@ func runConversationContract(t *testing.T, makeConv convFactory) {
 	}
 }
 
+func runBranchingContract(t *testing.T, makeBranching branchFactory) {
+	t.Helper()
+	ctx := context.Background()
+	bs := makeBranching(t)
+	session := "tree"
+
+	q, err := bs.AddMessage(ctx, session, "", mem.Message{Role: "user", Content: "q"})
+	if err != nil {
+		t.Fatalf("add root: %v", err)
+	}
+	if err := bs.AppendMessage(ctx, session, "assistant", "a1"); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	a1, _ := bs.Head(ctx, session)
+
+	// Edit and resend: fork before the question and ask something else
+	if err := bs.Fork(ctx, session, ""); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	q2, err := bs.AddMessage(ctx, session, "", mem.Message{Role: "user", Content: "q edited"})
+	if err != nil || q2 == q {
+		t.Fatalf("add edited: %v %q", err, q2)
+	}
+	msgs, _ := bs.GetMessages(ctx, session)
+	if len(msgs) != 1 || msgs[0].Content != "q edited" {
+		t.Fatalf("active branch should only hold the edit: %+v", msgs)
+	}
+
+	branches, err := bs.Branches(ctx, session)
+	if err != nil || len(branches) != 2 {
+		t.Fatalf("want 2 branches got %+v (%v)", branches, err)
+	}
+	if err := bs.SwitchBranch(ctx, session, a1); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	path, err := bs.Path(ctx, session, a1)
+	if err != nil || len(path) != 2 || path[0].ID != q || path[1].ParentID != q {
+		t.Fatalf("unexpected path: %+v (%v)", path, err)
+	}
+	if err := bs.SwitchBranch(ctx, session, q); !errors.Is(err, mem.ErrMessageNotFound) {
+		t.Fatalf("switching to an inner message should fail, got %v", err)
+	}
+	if err := bs.Fork(ctx, session, "missing"); !errors.Is(err, mem.ErrMessageNotFound) {
+		t.Fatalf("fork to unknown id should fail, got %v", err)
+	}
+}
+
+// runConcurrencyContract checks that concurrent Update calls on one key never
+// lose a write, and the compare-and-swap rules of versioned stores
+func runConcurrencyContract(t *testing.T, makeStore storeFactory) {
+	t.Helper()
+	ctx := context.Background()
+	s := makeStore(t)
+
+	const workers, perWorker = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				err := mem.Update(ctx, s, "counter", func(cur []int, _ bool) ([]int, error) {
+					return append(cur[:len(cur):len(cur)], w*perWorker+i), nil
+				})
+				if err != nil {
+					t.Errorf("update: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	got, err := mem.Get[[]int](ctx, s, "counter")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	seen := map[int]bool{}
+	for _, n := range got {
+		seen[n] = true
+	}
+	if len(got) != workers*perWorker || len(seen) != workers*perWorker {
+		t.Fatalf("lost updates: %d values, %d distinct, want %d", len(got), len(seen), workers*perWorker)
+	}
+
+	vs, ok := s.(mem.VersionedStore)
+	if !ok {
+		return
+	}
+	var out string
+	if v, err := vs.RetrieveVersion(ctx, "cas", &out); err != nil || v != 0 {
+		t.Fatalf("missing key should be version 0: %d (%v)", v, err)
+	}
+	v1, err := vs.CompareAndSwap(ctx, "cas", 0, "a")
+	if err != nil || v1 == 0 {
+		t.Fatalf("create: %d (%v)", v1, err)
+	}
+	if _, err := vs.CompareAndSwap(ctx, "cas", 0, "b"); !errors.Is(err, mem.ErrVersionConflict) {
+		t.Fatalf("create over an existing key should conflict, got %v", err)
+	}
+	if err := s.Store(ctx, "cas", "c"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if _, err := vs.CompareAndSwap(ctx, "cas", v1, "d"); !errors.Is(err, mem.ErrVersionConflict) {
+		t.Fatalf("plain Store should bump the version, got %v", err)
+	}
+	v2, err := vs.RetrieveVersion(ctx, "cas", &out)
+	if err != nil || out != "c" || v2 == v1 {
+		t.Fatalf("retrieve version: %q %d (%v)", out, v2, err)
+	}
+	_ = s.Delete(ctx, "cas")
+	if v, _ := vs.RetrieveVersion(ctx, "cas", &out); v != 0 {
+		t.Fatalf("deleted key should be version 0, got %d", v)
+	}
+	if v3, err := vs.CompareAndSwap(ctx, "cas", 0, "e"); err != nil || v3 == v1 || v3 == v2 {
+		t.Fatalf("recreated key must not reuse a version: %d (%v)", v3, err)
+	}
+}
+
+// runAppendConcurrency checks that messages appended concurrently to one
+// session all end up on the active branch
+func runAppendConcurrency(t *testing.T, makeConv convFactory) {
+	t.Helper()
+	ctx := context.Background()
+	cs := makeConv(t)
+	session := "busy"
+
+	const workers, perWorker = 8, 10
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			ap, full := cs.(mem.MessageAppender)
+			for i := 0; i < perWorker; i++ {
+				var err error
+				if full && i%2 == 1 {
+					_, err = ap.Append(ctx, session, mem.Message{Role: "assistant", Content: "m", Meta: map[string]string{"w": "x"}})
+				} else {
+					err = cs.AppendMessage(ctx, session, "user", "m")
+				}
+				if err != nil {
+					t.Errorf("append: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	msgs, err := cs.GetMessages(ctx, session)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	if len(msgs) != workers*perWorker {
+		t.Fatalf("lost messages: got %d want %d", len(msgs), workers*perWorker)
+	}
+	if bs, ok := cs.(mem.BranchingStore); ok {
+		if branches, _ := bs.Branches(ctx, session); len(branches) != 1 {
+			t.Fatalf("concurrent appends should not fork the conversation: %d branches", len(branches))
+		}
+	}
+}
+
 func TestStoreContract_InMemory(t *testing.T) {
 	runStoreContract(t, func(t *testing.T) mem.Store { return inm.NewStore() })
 }
@@ -99,3 +365,158 @@ func TestStoreContract_InMemory(t *testing.T) {
 func TestConversationContract_InMemory(t *testing.T) {
 	runConversationContract(t, func(t *testing.T) mem.ConversationStore { return inm.NewConversationStore() })
 }
+
+func TestBranchingContract_InMemory(t *testing.T) {
+	runBranchingContract(t, func(t *testing.T) mem.BranchingStore { return inm.NewConversationStore() })
+}
+
+func TestTypedContract_InMemory(t *testing.T) {
+	runTypedContract(t, func(t *testing.T) mem.Store { return inm.NewStore() })
+	runTypedContract(t, func(t *testing.T) mem.Store { return inm.NewConversationStore() })
+}
+
+func TestTypedContract_GenericJSONStore(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) mem.Store { return &jsonStore{data: map[string][]byte{}} })
+	runTypedContract(t, func(t *testing.T) mem.Store { return &jsonStore{data: map[string][]byte{}} })
+}
+
+func TestConcurrencyContract_InMemory(t *testing.T) {
+	runConcurrencyContract(t, func(t *testing.T) mem.Store { return inm.NewStore() })
+	runAppendConcurrency(t, func(t *testing.T) mem.ConversationStore { return inm.NewConversationStore() })
+}
+
+func TestConcurrencyContract_GenericJSONStore(t *testing.T) {
+	runConcurrencyContract(t, func(t *testing.T) mem.Store { return &jsonStore{data: map[string][]byte{}} })
+}
+
+// downJSONStore fails every read with a backend error
+type downJSONStore struct{ *jsonStore }
+
+func (downJSONStore) Retrieve(ctx context.Context, key string) (interface{}, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestUpdate_ReadErrorDoesNotOverwrite(t *testing.T) {
+	ctx := context.Background()
+	inner := &jsonStore{data: map[string][]byte{}}
+	_ = inner.Store(ctx, "counter", []int{1, 2})
+	err := mem.Update(ctx, downJSONStore{inner}, "counter", func(cur []int, found bool) ([]int, error) {
+		return append(cur, 3), nil
+	})
+	if err == nil {
+		t.Fatal("expected the read error to be returned")
+	}
+	if got, _ := mem.Get[[]int](ctx, inner, "counter"); len(got) != 2 {
+		t.Fatalf("value was overwritten: %v", got)
+	}
+}
+
+func openFileStore(t *testing.T) *file.Store {
+	t.Helper()
+	s, err := file.NewStore(file.Config{Dir: t.TempDir(), Sync: file.SyncNone})
+	if err != nil {
+		t.Fatalf("open file store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func openFileConv(t *testing.T) *file.ConversationStore {
+	t.Helper()
+	cs, err := file.NewConversationStore(file.Config{Dir: t.TempDir(), Sync: file.SyncNone})
+	if err != nil {
+		t.Fatalf("open file conversation store: %v", err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+	return cs
+}
+
+func TestStoreContract_File(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) mem.Store { return openFileStore(t) })
+	runTypedContract(t, func(t *testing.T) mem.Store { return openFileStore(t) })
+	runConcurrencyContract(t, func(t *testing.T) mem.Store { return openFileStore(t) })
+}
+
+func TestConversationContract_File(t *testing.T) {
+	runConversationContract(t, func(t *testing.T) mem.ConversationStore { return openFileConv(t) })
+	runBranchingContract(t, func(t *testing.T) mem.BranchingStore { return openFileConv(t) })
+	runAppendConcurrency(t, func(t *testing.T) mem.ConversationStore { return openFileConv(t) })
+}
+
+func encryptedConfig(t *testing.T) encrypted.Config {
+	t.Helper()
+	keys, err := encrypted.NewKeyring("k1", map[string][]byte{"k1": make([]byte, 32)})
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	return encrypted.Config{Keys: keys, Compress: true}
+}
+
+func encryptStore(t *testing.T, inner mem.Store) mem.Store {
+	t.Helper()
+	s, err := encrypted.NewStore(inner, encryptedConfig(t))
+	if err != nil {
+		t.Fatalf("encrypted store: %v", err)
+	}
+	return s
+}
+
+func encryptConv(t *testing.T, inner mem.ConversationStore) mem.ConversationStore {
+	t.Helper()
+	cs, err := encrypted.NewConversationStore(inner, encryptedConfig(t))
+	if err != nil {
+		t.Fatalf("encrypted conversation store: %v", err)
+	}
+	return cs
+}
+
+func TestStoreContract_Encrypted(t *testing.T) {
+	for name, inner := range map[string]func(t *testing.T) mem.Store{
+		"inmemory": func(t *testing.T) mem.Store { return inm.NewStore() },
+		"file":     func(t *testing.T) mem.Store { return openFileStore(t) },
+		"json":     func(t *testing.T) mem.Store { return &jsonStore{data: map[string][]byte{}} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			makeStore := func(t *testing.T) mem.Store { return encryptStore(t, inner(t)) }
+			runStoreContract(t, makeStore)
+			runTypedContract(t, makeStore)
+			runConcurrencyContract(t, makeStore)
+		})
+	}
+}
+
+func TestConversationContract_Encrypted(t *testing.T) {
+	makeConv := func(t *testing.T) mem.ConversationStore { return encryptConv(t, inm.NewConversationStore()) }
+	runConversationContract(t, makeConv)
+	runBranchingContract(t, func(t *testing.T) mem.BranchingStore { return makeConv(t).(mem.BranchingStore) })
+	runAppendConcurrency(t, makeConv)
+	runTranscriptContract(t, encryptConv(t, openFileConv(t)).(mem.BranchingStore), makeConv(t).(mem.BranchingStore))
+}
+
+func TestDecodeAndCodecs(t *testing.T) {
+	var p profile
+	if err := mem.Decode(`{"name":"ada","count":2}`, &p); err != nil || p.Name != "ada" || p.Count != 2 {
+		t.Fatalf("decode JSON string: %+v (%v)", p, err)
+	}
+	var s string
+	if err := mem.Decode("plain", &s); err != nil || s != "plain" {
+		t.Fatalf("strings are assigned as is: %q (%v)", s, err)
+	}
+	if err := mem.Decode(1, p); err == nil {
+		t.Fatalf("non-pointer destination should fail")
+	}
+	for _, name := range []string{"json", "gob"} {
+		c, ok := mem.LookupCodec(name)
+		if !ok {
+			t.Fatalf("codec %s not registered", name)
+		}
+		b, err := c.Marshal(profile{Name: "x", Tags: []string{"t"}})
+		if err != nil {
+			t.Fatalf("%s marshal: %v", name, err)
+		}
+		var out profile
+		if err := c.Unmarshal(b, &out); err != nil || out.Name != "x" || out.Tags[0] != "t" {
+			t.Fatalf("%s round trip: %+v (%v)", name, out, err)
+		}
+	}
+}
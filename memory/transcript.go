@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionInfo summarizes a stored conversation
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	// Messages counts every message of the session, across branches
+	Messages int `json:"messages"`
+}
+
+// SessionLister is implemented by conversation stores that can enumerate
+// their sessions natively (e.g. with a query). Other stores are listed from
+// their "conversation:<id>" keys.
+type SessionLister interface {
+	Sessions(ctx context.Context) ([]SessionInfo, error)
+}
+
+// TranscriptRecord is one message of an exported conversation; Export writes
+// one record per JSONL line, parents before children
+type TranscriptRecord struct {
+	SessionID string `json:"session_id"`
+	Node
+	// Head marks the last message of the session's active branch
+	Head bool `json:"head,omitempty"`
+}
+
+// SearchHit is a message matching a Search query
+type SearchHit struct {
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// MessageSearcher is implemented by conversation stores with native full-text search
+type MessageSearcher interface {
+	SearchMessages(ctx context.Context, query string, limit int) ([]SearchHit, error)
+}
+
+// ListSessions returns the sessions of cs sorted by last activity, most recent first
+func ListSessions(ctx context.Context, cs ConversationStore) ([]SessionInfo, error) {
+	var out []SessionInfo
+	if sl, ok := cs.(SessionLister); ok {
+		var err error
+		if out, err = sl.Sessions(ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		ids, err := sessionIDs(ctx, cs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			nodes, _, err := sessionNodes(ctx, cs, id)
+			if err != nil {
+				return nil, err
+			}
+			if len(nodes) == 0 {
+				continue
+			}
+			info := SessionInfo{ID: id, Messages: len(nodes)}
+			for _, n := range nodes {
+				ts := time.Unix(n.Timestamp, 0)
+				if info.CreatedAt.IsZero() || ts.Before(info.CreatedAt) {
+					info.CreatedAt = ts
+				}
+				if ts.After(info.LastActivity) {
+					info.LastActivity = ts
+				}
+			}
+			out = append(out, info)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].LastActivity.Equal(out[j].LastActivity) {
+			return out[i].LastActivity.After(out[j].LastActivity)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// sessionIDs derives session IDs from the "conversation:<id>" keys that the
+// conversation stores use (backends may prefix them)
+func sessionIDs(ctx context.Context, cs ConversationStore) ([]string, error) {
+	keys, err := cs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var ids []string
+	for _, k := range keys {
+		_, id, ok := strings.Cut(k, "conversation:")
+		if !ok || id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// sessionNodes returns every message of a session in creation order with the
+// ID of the active head. Plain conversation stores yield their linear history.
+func sessionNodes(ctx context.Context, cs ConversationStore, sessionID string) ([]Node, string, error) {
+	bs, ok := cs.(BranchingStore)
+	if !ok {
+		msgs, err := cs.GetMessages(ctx, sessionID)
+		if err != nil {
+			return nil, "", err
+		}
+		nodes := make([]Node, len(msgs))
+		for i, m := range msgs {
+			nodes[i] = Node{ID: "m" + strconv.Itoa(i+1), Message: m}
+			if i > 0 {
+				nodes[i].ParentID = nodes[i-1].ID
+			}
+		}
+		head := ""
+		if len(nodes) > 0 {
+			head = nodes[len(nodes)-1].ID
+		}
+		return nodes, head, nil
+	}
+	head, err := bs.Head(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	branches, err := bs.Branches(ctx, sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	seen := map[string]bool{}
+	var nodes []Node
+	for _, b := range branches {
+		path, err := bs.Path(ctx, sessionID, b.LeafID)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, n := range path {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodeLess(nodes[i].ID, nodes[j].ID) })
+	return nodes, head, nil
+}
+
+// nodeLess orders "m<seq>" IDs numerically, which is creation order
+func nodeLess(a, b string) bool {
+	na, errA := strconv.Atoi(strings.TrimPrefix(a, "m"))
+	nb, errB := strconv.Atoi(strings.TrimPrefix(b, "m"))
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return na < nb
+}
+
+// Export writes the given sessions (all sessions when none are given) to w
+// as JSONL, one TranscriptRecord per message including inactive branches
+func Export(ctx context.Context, cs ConversationStore, w io.Writer, sessions ...string) error {
+	if len(sessions) == 0 {
+		infos, err := ListSessions(ctx, cs)
+		if err != nil {
+			return err
+		}
+		for _, s := range infos {
+			sessions = append(sessions, s.ID)
+		}
+		sort.Strings(sessions)
+	}
+	enc := json.NewEncoder(w)
+	for _, id := range sessions {
+		nodes, head, err := sessionNodes(ctx, cs, id)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", id, err)
+		}
+		for _, n := range nodes {
+			if err := enc.Encode(TranscriptRecord{SessionID: id, Node: n, Head: n.ID == head}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImportStats reports what Import wrote
+type ImportStats struct {
+	Sessions int `json:"sessions"`
+	Messages int `json:"messages"`
+}
+
+// ErrInvalidTranscript is wrapped by Import errors caused by the input rather
+// than the store
+var ErrInvalidTranscript = errors.New("invalid transcript")
+
+// Import reads JSONL written by Export into cs, for migrations between
+// backends. Branching stores get the full trees (with new message IDs) and
+// their active branches; other stores get each message appended in file
+// order. Messages are added to sessions that already exist, so import into
+// an empty store to get an exact copy.
+func Import(ctx context.Context, cs ConversationStore, r io.Reader) (ImportStats, error) {
+	var stats ImportStats
+	bs, branching := cs.(BranchingStore)
+	ids := map[string]map[string]string{} // session -> exported ID -> new ID
+	heads := map[string]string{}
+	var order []string
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var rec TranscriptRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			if rerr := sc.Err(); rerr != nil {
+				// The line was cut short by a failed read
+				return stats, rerr
+			}
+			return stats, fmt.Errorf("import line %d: %w: %v", line, ErrInvalidTranscript, err)
+		}
+		if rec.SessionID == "" {
+			return stats, fmt.Errorf("import line %d: %w: missing session_id", line, ErrInvalidTranscript)
+		}
+		m, ok := ids[rec.SessionID]
+		if !ok {
+			m = map[string]string{}
+			ids[rec.SessionID] = m
+			order = append(order, rec.SessionID)
+		}
+		var newID string
+		var err error
+		switch {
+		case branching:
+			parent := ""
+			if rec.ParentID != "" {
+				if parent, ok = m[rec.ParentID]; !ok {
+					return stats, fmt.Errorf("import line %d: %w: parent %s not seen before", line, ErrInvalidTranscript, rec.ParentID)
+				}
+			}
+			newID, err = bs.AddMessage(ctx, rec.SessionID, parent, rec.Message)
+		default:
+			if ap, ok := cs.(MessageAppender); ok {
+				newID, err = ap.Append(ctx, rec.SessionID, rec.Message)
+			} else {
+				err = cs.AppendMessage(ctx, rec.SessionID, rec.Role, rec.Content)
+			}
+		}
+		if err != nil {
+			return stats, fmt.Errorf("import line %d: %w", line, err)
+		}
+		if rec.ID != "" {
+			m[rec.ID] = newID
+		}
+		if rec.Head {
+			heads[rec.SessionID] = newID
+		}
+		stats.Messages++
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("%w: %v", ErrInvalidTranscript, err)
+		}
+		return stats, err
+	}
+	if branching {
+		for session, head := range heads {
+			if err := bs.Fork(ctx, session, head); err != nil {
+				return stats, err
+			}
+		}
+	}
+	stats.Sessions = len(order)
+	return stats, nil
+}
+
+// Search finds messages containing every word of query (case-insensitive),
+// newest first. Stores implementing MessageSearcher run the search natively.
+func Search(ctx context.Context, cs ConversationStore, query string, limit int) ([]SearchHit, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if ms, ok := cs.(MessageSearcher); ok {
+		return ms.SearchMessages(ctx, query, limit)
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search: empty query")
+	}
+	ids, err := sessionIDs(ctx, cs)
+	if err != nil {
+		return nil, err
+	}
+	var hits []SearchHit
+	for _, id := range ids {
+		nodes, _, err := sessionNodes(ctx, cs, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			if matchesAll(strings.ToLower(n.Content), terms) {
+				hits = append(hits, SearchHit{SessionID: id, MessageID: n.ID, Role: n.Role, Content: n.Content, Timestamp: n.Timestamp})
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Timestamp > hits[j].Timestamp })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func matchesAll(s string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(s, t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	mem "github.com/KamdynS/go-agents/memory"
+	inm "github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+// linearStore hides the branching methods of a store to exercise the plain
+// ConversationStore paths
+type linearStore struct{ mem.ConversationStore }
+
+// seedTranscripts writes two sessions, one of them with an abandoned branch
+func seedTranscripts(t *testing.T, bs mem.BranchingStore) {
+	t.Helper()
+	ctx := context.Background()
+	q, _ := bs.AddMessage(ctx, "alpha", "", mem.Message{Role: "user", Content: "What is Go?", Timestamp: 100})
+	_, _ = bs.AddMessage(ctx, "alpha", q, mem.Message{Role: "assistant", Content: "A language", Timestamp: 101})
+	_, _ = bs.AddMessage(ctx, "alpha", q, mem.Message{Role: "assistant", Content: "Go is a programming language", Timestamp: 102,
+		ToolCalls: []mem.ToolCall{{ID: "c1", Name: "lookup", Arguments: `{}`}}})
+	_, _ = bs.AddMessage(ctx, "beta", "", mem.Message{Role: "user", Content: "hello there", Timestamp: 200})
+}
+
+func runTranscriptContract(t *testing.T, src mem.BranchingStore, dst mem.BranchingStore) {
+	t.Helper()
+	ctx := context.Background()
+	seedTranscripts(t, src)
+
+	sessions, err := mem.ListSessions(ctx, src)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("list: %+v (%v)", sessions, err)
+	}
+	if s := sessions[1]; s.ID != "alpha" || s.Messages != 3 || s.CreatedAt.Unix() != 100 || s.LastActivity.Unix() != 102 {
+		t.Fatalf("want beta first and alpha with all branches counted: %+v", sessions)
+	}
+
+	var buf bytes.Buffer
+	if err := mem.Export(ctx, src, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Fatalf("want one line per message, got %d:\n%s", n, buf.String())
+	}
+	stats, err := mem.Import(ctx, dst, &buf)
+	if err != nil || stats.Sessions != 2 || stats.Messages != 4 {
+		t.Fatalf("import: %+v (%v)", stats, err)
+	}
+
+	want, _ := src.GetMessages(ctx, "alpha")
+	got, _ := dst.GetMessages(ctx, "alpha")
+	if len(got) != 2 || got[1].Content != want[1].Content || got[1].Timestamp != 102 || len(got[1].ToolCalls) != 1 {
+		t.Fatalf("active branch not restored: %+v", got)
+	}
+	if branches, _ := dst.Branches(ctx, "alpha"); len(branches) != 2 {
+		t.Fatalf("inactive branch lost: %+v", branches)
+	}
+
+	hits, err := mem.Search(ctx, dst, "GO language", 10)
+	if err != nil || len(hits) != 1 || hits[0].Timestamp != 102 || hits[0].SessionID != "alpha" {
+		t.Fatalf("search: %+v (%v)", hits, err)
+	}
+	if hits, _ := mem.Search(ctx, dst, "go", 10); len(hits) != 2 {
+		t.Fatalf("want every message mentioning go: %+v", hits)
+	}
+	if hits, _ := mem.Search(ctx, dst, "go", 1); len(hits) != 1 {
+		t.Fatalf("limit ignored: %+v", hits)
+	}
+	if _, err := mem.Search(ctx, dst, "  ", 10); err == nil {
+		t.Fatal("empty query should fail")
+	}
+}
+
+func TestTranscripts_InMemoryToFile(t *testing.T) {
+	runTranscriptContract(t, inm.NewConversationStore(), openFileConv(t))
+}
+
+func TestTranscripts_FileToInMemory(t *testing.T) {
+	runTranscriptContract(t, openFileConv(t), inm.NewConversationStore())
+}
+
+func TestTranscripts_LinearStore(t *testing.T) {
+	ctx := context.Background()
+	src := linearStore{inm.NewConversationStore()}
+	_ = src.AppendMessage(ctx, "s", "user", "one")
+	_ = src.AppendMessage(ctx, "s", "assistant", "two")
+
+	var buf bytes.Buffer
+	if err := mem.Export(ctx, src, &buf, "s"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dst := linearStore{inm.NewConversationStore()}
+	if _, err := mem.Import(ctx, dst, &buf); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if msgs, _ := dst.GetMessages(ctx, "s"); len(msgs) != 2 || msgs[1].Content != "two" {
+		t.Fatalf("linear round trip: %+v", msgs)
+	}
+
+	if _, err := mem.Import(ctx, dst, strings.NewReader(`{"id":"m1"}`)); err == nil {
+		t.Fatal("record without session should fail")
+	}
+	bad := `{"session_id":"x","id":"m2","parent_id":"m1","role":"user","content":"orphan"}`
+	if _, err := mem.Import(ctx, inm.NewConversationStore(), strings.NewReader(bad)); err == nil {
+		t.Fatal("unknown parent should fail on branching stores")
+	}
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrMessageNotFound is returned when a message ID is not part of a conversation tree
+var ErrMessageNotFound = errors.New("message not found")
+
+// BranchingStore keeps each session as a tree of messages so a conversation can
+// be forked ("edit and resend") and regenerated without losing history. The
+// embedded ConversationStore methods operate on the active branch.
+type BranchingStore interface {
+	ConversationStore
+
+	// AddMessage adds msg as a child of parentID ("" for a new root) and makes it the head
+	AddMessage(ctx context.Context, sessionID, parentID string, msg Message) (string, error)
+
+	// Head returns the ID of the active message ("" for an empty conversation)
+	Head(ctx context.Context, sessionID string) (string, error)
+
+	// Path returns the messages from the root down to messageID
+	Path(ctx context.Context, sessionID, messageID string) ([]Node, error)
+
+	// Fork moves the head to any message ("" for before the first message);
+	// the next appended message starts a new branch from there
+	Fork(ctx context.Context, sessionID, messageID string) error
+
+	// Branches lists the leaves of the tree, one per branch
+	Branches(ctx context.Context, sessionID string) ([]Branch, error)
+
+	// SwitchBranch makes the branch ending at leafID active
+	SwitchBranch(ctx context.Context, sessionID, leafID string) error
+}
+
+// Node is a message in a conversation tree
+type Node struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id,omitempty"`
+	Message
+}
+
+// Branch describes one root-to-leaf path of a conversation tree
+type Branch struct {
+	LeafID string `json:"leaf_id"`
+	// Length is the number of messages on the branch
+	Length int `json:"length"`
+	// Active is set when the leaf is the current head
+	Active    bool  `json:"active"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Tree is the serializable form of a conversation tree. Stores hold one Tree
+// per session; its methods contain the branching logic shared by all backends.
+type Tree struct {
+	Nodes []Node `json:"nodes"`
+	Head  string `json:"head"`
+	Seq   int    `json:"seq"`
+
+	// pos and children index Nodes. They are built on first use (a decoded
+	// tree has none) and kept up to date by Add.
+	pos      map[string]int
+	children map[string][]int // node positions by parent ID; "" holds the roots
+}
+
+// reindex rebuilds the indexes unless they cover Nodes
+func (t *Tree) reindex() {
+	if t.pos != nil && len(t.pos) == len(t.Nodes) {
+		return
+	}
+	t.pos = make(map[string]int, len(t.Nodes))
+	t.children = make(map[string][]int, len(t.Nodes))
+	for i, n := range t.Nodes {
+		t.pos[n.ID] = i
+		t.children[n.ParentID] = append(t.children[n.ParentID], i)
+	}
+}
+
+func (t *Tree) index(id string) int {
+	t.reindex()
+	if i, ok := t.pos[id]; ok {
+		return i
+	}
+	return -1
+}
+
+// Add appends msg under parentID and moves the head to it
+func (t *Tree) Add(parentID string, msg Message) (string, error) {
+	t.reindex()
+	if parentID != "" && t.index(parentID) < 0 {
+		return "", fmt.Errorf("%w: %s", ErrMessageNotFound, parentID)
+	}
+	t.Seq++
+	id := "m" + strconv.Itoa(t.Seq)
+	t.pos[id] = len(t.Nodes)
+	t.children[parentID] = append(t.children[parentID], len(t.Nodes))
+	t.Nodes = append(t.Nodes, Node{ID: id, ParentID: parentID, Message: msg})
+	t.Head = id
+	return id, nil
+}
+
+// Path returns the nodes from the root to id
+func (t *Tree) Path(id string) ([]Node, error) {
+	var rev []Node
+	for id != "" {
+		i := t.index(id)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+		}
+		rev = append(rev, t.Nodes[i])
+		id = t.Nodes[i].ParentID
+	}
+	out := make([]Node, len(rev))
+	for i, n := range rev {
+		out[len(rev)-1-i] = n
+	}
+	return out, nil
+}
+
+// Messages returns the messages on the active branch
+func (t *Tree) Messages() []Message {
+	path, _ := t.Path(t.Head)
+	out := make([]Message, len(path))
+	for i, n := range path {
+		out[i] = n.Message
+	}
+	return out
+}
+
+// Checkout moves the head to id ("" for before the first message)
+func (t *Tree) Checkout(id string) error {
+	if id != "" && t.index(id) < 0 {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	t.Head = id
+	return nil
+}
+
+// Branches returns one entry per leaf in creation order
+func (t *Tree) Branches() []Branch {
+	t.reindex()
+	// Nodes are in creation order, so a parent's depth is known before its children's
+	depth := make(map[string]int, len(t.Nodes))
+	var out []Branch
+	for _, n := range t.Nodes {
+		depth[n.ID] = depth[n.ParentID] + 1
+		if len(t.children[n.ID]) > 0 {
+			continue
+		}
+		out = append(out, Branch{LeafID: n.ID, Length: depth[n.ID], Active: n.ID == t.Head, Timestamp: n.Timestamp})
+	}
+	return out
+}
+
+// IsLeaf reports whether id has no children
+func (t *Tree) IsLeaf(id string) bool {
+	return t.index(id) >= 0 && len(t.children[id]) == 0
+}
+
+// Trim keeps the last max messages of the active branch and drops everything
+// older, including branches that fork off the dropped part. It returns the
+// number of messages removed.
+func (t *Tree) Trim(max int) int {
+	path, _ := t.Path(t.Head)
+	if max <= 0 || len(path) <= max {
+		return 0
+	}
+	root := path[len(path)-max].ID
+	keep := map[string]bool{root: true}
+	// Nodes are in creation order, so parents are seen before their children
+	for _, n := range t.Nodes {
+		if keep[n.ParentID] {
+			keep[n.ID] = true
+		}
+	}
+	kept := make([]Node, 0, len(keep))
+	for _, n := range t.Nodes {
+		if !keep[n.ID] {
+			continue
+		}
+		if n.ID == root {
+			n.ParentID = ""
+		}
+		kept = append(kept, n)
+	}
+	removed := len(t.Nodes) - len(kept)
+	t.Nodes = kept
+	t.pos, t.children = nil, nil
+	return removed
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTree_BranchesAfterDecode(t *testing.T) {
+	tr := &Tree{}
+	q, _ := tr.Add("", Message{Role: "user", Content: "q"})
+	a1, _ := tr.Add(q, Message{Role: "assistant", Content: "a1"})
+	a2, _ := tr.Add(q, Message{Role: "assistant", Content: "a2"})
+
+	// A decoded tree has no index yet
+	b, _ := json.Marshal(tr)
+	var got Tree
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	branches := got.Branches()
+	if len(branches) != 2 || branches[0].LeafID != a1 || branches[1].LeafID != a2 || !branches[1].Active || branches[0].Length != 2 {
+		t.Fatalf("unexpected branches %+v", branches)
+	}
+	if got.IsLeaf(q) || !got.IsLeaf(a1) || got.IsLeaf("m9") {
+		t.Fatalf("leaf checks wrong")
+	}
+	// The index follows Add and Trim
+	a3, _ := got.Add(a1, Message{Role: "user", Content: "more"})
+	if got.IsLeaf(a1) || !got.IsLeaf(a3) {
+		t.Fatalf("index not updated by Add")
+	}
+	got.Trim(1)
+	if p, err := got.Path(a3); err != nil || len(p) != 1 || !got.IsLeaf(a3) {
+		t.Fatalf("index not rebuilt after Trim: %+v (%v)", p, err)
+	}
+}
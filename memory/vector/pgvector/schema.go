@@ -0,0 +1,70 @@
+//go:build adapters_pgvector
+
+package pgvector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// validTable accepts a table name, optionally schema qualified
+var validTable = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+// indexName derives the embedding index name from the table ("public.docs" -> "docs_embedding_idx")
+func indexName(table string) string {
+	for i := len(table) - 1; i >= 0; i-- {
+		if table[i] == '.' {
+			return table[i+1:] + "_embedding_idx"
+		}
+	}
+	return table + "_embedding_idx"
+}
+
+// migrate creates the extension, table and ANN index if they do not exist,
+// then checks that the embedding column has the configured dimension
+func (s *Store) migrate(ctx context.Context) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id text PRIMARY KEY,
+			content text NOT NULL,
+			embedding vector(%d) NOT NULL,
+			meta jsonb
+		)`, s.table, s.cfg.Dimensions),
+	}
+	switch s.cfg.Index {
+	case HNSW:
+		stmts = append(stmts, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding %s)`,
+			indexName(s.table), s.table, s.cfg.Distance.opclass()))
+	case IVFFlat:
+		stmts = append(stmts, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING ivfflat (embedding %s) WITH (lists = %d)`,
+			indexName(s.table), s.table, s.cfg.Distance.opclass(), s.cfg.IVFLists))
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("pgvector migrate: %w", err)
+		}
+	}
+	return s.checkDimensions(ctx)
+}
+
+// checkDimensions compares the declared dimension of the embedding column
+// (its type modifier) with the configured one
+func (s *Store) checkDimensions(ctx context.Context) error {
+	var dims int
+	err := s.db.QueryRow(ctx, `SELECT atttypmod FROM pg_attribute WHERE attrelid = $1::regclass AND attname = 'embedding'`, s.table).Scan(&dims)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("pgvector: table %s has no embedding column", s.table)
+	}
+	if err != nil {
+		return err
+	}
+	if dims > 0 && dims != s.cfg.Dimensions {
+		return fmt.Errorf("pgvector: table %s stores %d dimensions, configured %d: %w", s.table, dims, s.cfg.Dimensions, ErrDimensions)
+	}
+	return nil
+}
@@ -4,76 +4,335 @@ package pgvector
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/KamdynS/go-agents/memory"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// ErrDimensions is returned when an embedding does not have the configured dimension
+var ErrDimensions = errors.New("embedding dimension mismatch")
+
+// DB is the part of pgx the store uses; *pgxpool.Pool (recommended, safe for
+// concurrent use) and *pgx.Conn satisfy it
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Distance selects the pgvector distance operator
+type Distance string
+
+const (
+	// Cosine orders by cosine distance (<=>); Score is cosine similarity in [-1, 1]
+	Cosine Distance = "cosine"
+	// L2 orders by Euclidean distance (<->); Score is 1/(1+distance) in (0, 1]
+	L2 Distance = "l2"
+	// InnerProduct orders by negative inner product (<#>); Score is the inner product
+	InnerProduct Distance = "inner_product"
+)
+
+func (d Distance) operator() string {
+	switch d {
+	case L2:
+		return "<->"
+	case InnerProduct:
+		return "<#>"
+	}
+	return "<=>"
+}
+
+func (d Distance) opclass() string {
+	switch d {
+	case L2:
+		return "vector_l2_ops"
+	case InnerProduct:
+		return "vector_ip_ops"
+	}
+	return "vector_cosine_ops"
+}
+
+// similarity converts an operator result to a score where higher is more similar
+func (d Distance) similarity(dist float64) float64 {
+	switch d {
+	case L2:
+		return 1 / (1 + dist)
+	case InnerProduct:
+		return -dist
+	}
+	return 1 - dist
+}
+
+// IndexType selects the approximate nearest neighbour index created by AutoMigrate
+type IndexType string
+
+const (
+	HNSW    IndexType = "hnsw"
+	IVFFlat IndexType = "ivfflat"
+	// NoIndex creates no ANN index (exact search)
+	NoIndex IndexType = "none"
+)
+
+// Config configures a Store
+type Config struct {
+	// Table holds the documents (default "documents"); may be schema qualified
+	Table string
+	// Dimensions of the embeddings. Required with AutoMigrate; when set,
+	// embeddings of another length are rejected with ErrDimensions.
+	Dimensions int
+	// Distance is the similarity metric (default Cosine). The index must be
+	// built for the same metric.
+	Distance Distance
+	// AutoMigrate creates the vector extension, the table and the index
+	AutoMigrate bool
+	// Index is the index AutoMigrate creates (default HNSW)
+	Index IndexType
+	// IVFLists is the number of IVFFlat lists (default 100)
+	IVFLists int
+}
+
+// Store implements memory.VectorStore and memory.DocumentWriter on Postgres with pgvector
 type Store struct {
-	pool  *pgx.Conn
+	db    DB
+	cfg   Config
 	table string
 }
 
-// Expect table schema similar to:
-// CREATE EXTENSION IF NOT EXISTS vector;
-// CREATE TABLE IF NOT EXISTS documents (
-//   id text PRIMARY KEY,
-//   content text NOT NULL,
-//   embedding vector(1536),
-//   meta jsonb
-// );
+// New returns a store on db. With cfg.AutoMigrate it creates the schema and
+// checks that the table's dimension matches cfg.Dimensions.
+func New(ctx context.Context, db DB, cfg Config) (*Store, error) {
+	if cfg.Table == "" {
+		cfg.Table = "documents"
+	}
+	if !validTable.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("pgvector: invalid table name %q", cfg.Table)
+	}
+	switch cfg.Distance {
+	case "":
+		cfg.Distance = Cosine
+	case Cosine, L2, InnerProduct:
+	default:
+		return nil, fmt.Errorf("pgvector: unknown distance %q", cfg.Distance)
+	}
+	switch cfg.Index {
+	case "":
+		cfg.Index = HNSW
+	case HNSW, IVFFlat, NoIndex:
+	default:
+		return nil, fmt.Errorf("pgvector: unknown index type %q", cfg.Index)
+	}
+	if cfg.IVFLists <= 0 {
+		cfg.IVFLists = 100
+	}
+	if cfg.Dimensions < 0 {
+		return nil, fmt.Errorf("pgvector: invalid dimensions %d", cfg.Dimensions)
+	}
+	s := &Store{db: db, cfg: cfg, table: cfg.Table}
+	if cfg.AutoMigrate {
+		if cfg.Dimensions == 0 {
+			return nil, errors.New("pgvector: AutoMigrate requires Dimensions")
+		}
+		if err := s.migrate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
 
-func New(conn *pgx.Conn, table string) *Store {
-	if table == "" {
-		table = "documents"
+// checkEmbedding validates the length of an embedding and rejects NaN/Inf
+func (s *Store) checkEmbedding(v []float64) error {
+	if len(v) == 0 {
+		return errors.New("empty embedding")
+	}
+	if s.cfg.Dimensions > 0 && len(v) != s.cfg.Dimensions {
+		return fmt.Errorf("got %d dimensions, want %d: %w", len(v), s.cfg.Dimensions, ErrDimensions)
 	}
-	return &Store{pool: conn, table: table}
+	for _, f := range v {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.New("embedding contains NaN or Inf")
+		}
+	}
+	return nil
 }
 
+// AddDocument inserts or updates a document; the metadata of an existing
+// document is kept (use AddDocuments to replace it)
 func (s *Store) AddDocument(ctx context.Context, id string, content string, embedding []float64) error {
-	if len(embedding) == 0 {
-		return errors.New("empty embedding")
+	if err := s.checkEmbedding(embedding); err != nil {
+		return err
 	}
-	_, err := s.pool.Exec(ctx, fmt.Sprintf("INSERT INTO %s (id, content, embedding) VALUES ($1,$2,$3) ON CONFLICT (id) DO UPDATE SET content=excluded.content, embedding=excluded.embedding", s.table), id, content, embedding)
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, content, embedding) VALUES ($1, $2, $3::vector)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, embedding = excluded.embedding`, s.table),
+		id, content, encodeVector(embedding))
 	return err
 }
 
+// AddDocuments implements memory.DocumentWriter. Documents are streamed with
+// COPY into a temporary table and upserted in one transaction.
+func (s *Store) AddDocuments(ctx context.Context, docs []memory.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	// Keep the last copy of an ID repeated in the batch; one upsert cannot
+	// touch the same row twice
+	last := make(map[string]int, len(docs))
+	for i, d := range docs {
+		last[d.ID] = i
+	}
+	rows := make([][]any, 0, len(last))
+	for i, d := range docs {
+		if d.ID == "" {
+			return fmt.Errorf("document %d: empty ID", i)
+		}
+		if err := s.checkEmbedding(d.Embedding); err != nil {
+			return fmt.Errorf("document %s: %w", d.ID, err)
+		}
+		var meta []byte
+		if len(d.Meta) > 0 {
+			var err error
+			if meta, err = json.Marshal(d.Meta); err != nil {
+				return err
+			}
+		}
+		if last[d.ID] == i {
+			rows = append(rows, []any{d.ID, d.Content, encodeVector(d.Embedding), meta})
+		}
+	}
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		// Staging columns are text: COPY cannot encode the vector type without
+		// registering it, so the cast happens in the upsert
+		if _, err := tx.Exec(ctx, `CREATE TEMP TABLE pgvector_staging (id text, content text, embedding text, meta jsonb) ON COMMIT DROP`); err != nil {
+			return err
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"pgvector_staging"}, []string{"id", "content", "embedding", "meta"}, pgx.CopyFromRows(rows)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (id, content, embedding, meta)
+			SELECT id, content, embedding::vector, meta FROM pgvector_staging
+			ON CONFLICT (id) DO UPDATE SET content = excluded.content, embedding = excluded.embedding, meta = excluded.meta`, s.table))
+		return err
+	})
+}
+
+// QuerySimilar returns the closest documents with Score normalized so that
+// higher is more similar (see Distance); embeddings are not returned
 func (s *Store) QuerySimilar(ctx context.Context, queryEmbedding []float64, limit int) ([]memory.Document, error) {
+	return s.QuerySimilarFiltered(ctx, queryEmbedding, limit, nil)
+}
+
+// QuerySimilarFiltered implements memory.FilteredVectorStore: only documents
+// whose meta contains every pair of filter are ranked
+func (s *Store) QuerySimilarFiltered(ctx context.Context, queryEmbedding []float64, limit int, filter map[string]string) ([]memory.Document, error) {
+	if err := s.checkEmbedding(queryEmbedding); err != nil {
+		return nil, err
+	}
 	if limit <= 0 {
 		limit = 5
 	}
-	rows, err := s.pool.Query(ctx, fmt.Sprintf("SELECT id, content, embedding <#> $1 AS score FROM %s ORDER BY embedding <#> $1 ASC LIMIT $2", s.table), queryEmbedding, limit)
+	where, args := "", []any{encodeVector(queryEmbedding), limit}
+	if len(filter) > 0 {
+		f, err := json.Marshal(filter)
+		if err != nil {
+			return nil, err
+		}
+		where, args = "WHERE meta @> $3::jsonb", append(args, string(f))
+	}
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`SELECT id, content, meta, embedding %[2]s $1::vector AS distance
+		FROM %[1]s %[3]s ORDER BY embedding %[2]s $1::vector LIMIT $2`, s.table, s.cfg.Distance.operator(), where),
+		args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	out := make([]memory.Document, 0, limit)
-	for rows.Next() {
-		var id string
-		var content string
-		var score float64
-		if err := rows.Scan(&id, &content, &score); err != nil {
-			return nil, err
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (memory.Document, error) {
+		var doc memory.Document
+		var meta []byte
+		var dist float64
+		if err := row.Scan(&doc.ID, &doc.Content, &meta, &dist); err != nil {
+			return doc, err
 		}
-		out = append(out, memory.Document{ID: id, Content: content, Score: score})
-	}
-	return out, rows.Err()
+		doc.Score = s.cfg.Distance.similarity(dist)
+		return doc, decodeMeta(meta, &doc)
+	})
 }
 
 func (s *Store) DeleteDocument(ctx context.Context, id string) error {
-	_, err := s.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id=$1", s.table), id)
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.table), id)
 	return err
 }
 
+// GetDocument returns a document with its embedding and metadata
 func (s *Store) GetDocument(ctx context.Context, id string) (*memory.Document, error) {
-	row := s.pool.QueryRow(ctx, fmt.Sprintf("SELECT id, content FROM %s WHERE id=$1", s.table), id)
 	var doc memory.Document
-	if err := row.Scan(&doc.ID, &doc.Content); err != nil {
+	var embedding string
+	var meta []byte
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`SELECT id, content, embedding::text, meta FROM %s WHERE id = $1`, s.table), id).
+		Scan(&doc.ID, &doc.Content, &embedding, &meta)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("document %s not found", id)
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &doc, nil
+	if doc.Embedding, err = decodeVector(embedding); err != nil {
+		return nil, err
+	}
+	return &doc, decodeMeta(meta, &doc)
+}
+
+func decodeMeta(raw []byte, doc *memory.Document) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, &doc.Meta)
+}
+
+// encodeVector formats v as a pgvector text literal ("[1,2.5,3]")
+func encodeVector(v []float64) string {
+	var b strings.Builder
+	b.Grow(len(v) * 10)
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(f, 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
 }
 
-var _ memory.VectorStore = (*Store)(nil)
+// decodeVector parses a pgvector text literal
+func decodeVector(s string) ([]float64, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector literal %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return []float64{}, nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]float64, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector literal: %w", err)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+var (
+	_ memory.VectorStore         = (*Store)(nil)
+	_ memory.DocumentWriter      = (*Store)(nil)
+	_ memory.FilteredVectorStore = (*Store)(nil)
+)
@@ -0,0 +1,61 @@
+//go:build adapters_pgvector
+
+package pgvector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestVectorLiteral(t *testing.T) {
+	v := []float64{1, -2.5, 0.125, 1e-7}
+	lit := encodeVector(v)
+	if lit != "[1,-2.5,0.125,1e-07]" {
+		t.Fatalf("encode: %s", lit)
+	}
+	got, err := decodeVector(lit)
+	if err != nil || len(got) != 4 || got[1] != -2.5 || math.Abs(got[3]-1e-7) > 1e-12 {
+		t.Fatalf("decode: %v (%v)", got, err)
+	}
+	if _, err := decodeVector("1,2"); err == nil {
+		t.Fatal("expected error for a literal without brackets")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if s := Cosine.similarity(0); s != 1 {
+		t.Fatalf("identical vectors under cosine: %v", s)
+	}
+	if s := L2.similarity(0); s != 1 || L2.similarity(3) != 0.25 {
+		t.Fatalf("l2: %v", s)
+	}
+	if s := InnerProduct.similarity(-0.8); s != 0.8 {
+		t.Fatalf("inner product: %v", s)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	ctx := context.Background()
+	for _, cfg := range []Config{
+		{Table: "docs; DROP TABLE x"},
+		{Distance: "manhattan"},
+		{Index: "btree"},
+		{AutoMigrate: true}, // no dimensions
+	} {
+		if _, err := New(ctx, nil, cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	s, err := New(ctx, nil, Config{Table: "rag.chunks", Dimensions: 2})
+	if err != nil || s.cfg.Distance != Cosine || s.cfg.Index != HNSW || indexName(s.table) != "chunks_embedding_idx" {
+		t.Fatalf("defaults: %+v (%v)", s, err)
+	}
+	if err := s.checkEmbedding([]float64{1, 2, 3}); !errors.Is(err, ErrDimensions) {
+		t.Fatalf("want ErrDimensions, got %v", err)
+	}
+	if err := s.checkEmbedding([]float64{1, math.NaN()}); err == nil {
+		t.Fatal("NaN should be rejected")
+	}
+}
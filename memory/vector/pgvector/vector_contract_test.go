@@ -4,32 +4,119 @@ package pgvector
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"testing"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func TestVectorContract_PgVector(t *testing.T) {
+func openTestStore(t *testing.T, cfg Config) *Store {
+	t.Helper()
 	dsn := os.Getenv("DATABASE_URL")
 	if dsn == "" {
 		t.Skip("DATABASE_URL not set")
 	}
 	ctx := context.Background()
-	conn, err := pgx.Connect(ctx, dsn)
+	pool, err := pgxpool.New(ctx, dsn)
 	if err != nil {
 		t.Skipf("connect: %v", err)
 	}
-	defer conn.Close(ctx)
+	t.Cleanup(pool.Close)
+	if _, err := pool.Exec(ctx, "DROP TABLE IF EXISTS "+cfg.Table); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	cfg.AutoMigrate = true
+	s, err := New(ctx, pool, cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	return s
+}
+
+func TestVectorContract_PgVector(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Config{Table: "pgvector_test_docs", Dimensions: 3})
 
-	s := New(conn, "documents")
-	if err := s.AddDocument(ctx, "d1", "hello", []float64{0.1, 0.2}); err != nil {
+	if err := s.AddDocument(ctx, "d1", "hello", []float64{0.1, 0.2, 0.3}); err != nil {
 		t.Fatalf("add: %v", err)
 	}
-	if _, err := s.GetDocument(ctx, "d1"); err != nil {
-		t.Fatalf("get: %v", err)
+	doc, err := s.GetDocument(ctx, "d1")
+	if err != nil || doc.Content != "hello" || len(doc.Embedding) != 3 || math.Abs(doc.Embedding[2]-0.3) > 1e-6 {
+		t.Fatalf("get: %+v (%v)", doc, err)
 	}
-	if _, err := s.QuerySimilar(ctx, []float64{0.1, 0.2}, 3); err != nil {
-		t.Fatalf("query: %v", err)
+	if _, err := s.GetDocument(ctx, "missing"); err == nil {
+		t.Fatal("expected not found")
+	}
+	if err := s.AddDocument(ctx, "bad", "x", []float64{1, 2}); !errors.Is(err, ErrDimensions) {
+		t.Fatalf("want ErrDimensions, got %v", err)
+	}
+	if err := s.DeleteDocument(ctx, "d1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestAddDocumentsAndMeta(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Config{Table: "pgvector_test_batch", Dimensions: 2, Index: IVFFlat, IVFLists: 1})
+
+	var docs []memory.Document
+	for i := 0; i < 50; i++ {
+		angle := float64(i) / 50 * math.Pi / 2
+		docs = append(docs, memory.Document{
+			ID:        fmt.Sprintf("d%d", i),
+			Content:   fmt.Sprintf("doc %d", i),
+			Embedding: []float64{math.Cos(angle), math.Sin(angle)},
+			Meta:      map[string]string{"source": "batch", "n": fmt.Sprint(i)},
+		})
+	}
+	docs = append(docs, memory.Document{ID: "d0", Content: "doc 0 v2", Embedding: []float64{1, 0}, Meta: map[string]string{"source": "again"}})
+	if err := s.AddDocuments(ctx, docs); err != nil {
+		t.Fatalf("add documents: %v", err)
+	}
+
+	doc, err := s.GetDocument(ctx, "d0")
+	if err != nil || doc.Content != "doc 0 v2" || doc.Meta["source"] != "again" {
+		t.Fatalf("duplicate IDs should keep the last copy: %+v (%v)", doc, err)
+	}
+	// AddDocument keeps existing metadata
+	_ = s.AddDocument(ctx, "d1", "doc 1 edited", docs[1].Embedding)
+	if doc, _ := s.GetDocument(ctx, "d1"); doc.Meta["n"] != "1" {
+		t.Fatalf("meta lost on AddDocument: %+v", doc)
+	}
+
+	hits, err := s.QuerySimilar(ctx, []float64{1, 0}, 3)
+	if err != nil || len(hits) != 3 || hits[0].ID != "d0" || hits[0].Meta["source"] != "again" {
+		t.Fatalf("query: %+v (%v)", hits, err)
+	}
+	if math.Abs(hits[0].Score-1) > 1e-6 || hits[1].Score > hits[0].Score {
+		t.Fatalf("cosine scores should be similarities, best first: %+v", hits)
+	}
+}
+
+func TestDistances(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range []Distance{Cosine, L2, InnerProduct} {
+		t.Run(string(d), func(t *testing.T) {
+			s := openTestStore(t, Config{Table: "pgvector_test_" + string(d), Dimensions: 2, Distance: d})
+			_ = s.AddDocuments(ctx, []memory.Document{
+				{ID: "near", Embedding: []float64{1, 0.1}},
+				{ID: "far", Embedding: []float64{-1, 0}},
+			})
+			hits, err := s.QuerySimilar(ctx, []float64{1, 0}, 2)
+			if err != nil || len(hits) != 2 || hits[0].ID != "near" || hits[0].Score <= hits[1].Score {
+				t.Fatalf("%s: %+v (%v)", d, hits, err)
+			}
+		})
+	}
+}
+
+func TestAutoMigrate_DimensionMismatch(t *testing.T) {
+	s := openTestStore(t, Config{Table: "pgvector_test_dims", Dimensions: 3})
+	if _, err := New(context.Background(), s.db, Config{Table: "pgvector_test_dims", Dimensions: 4, AutoMigrate: true}); !errors.Is(err, ErrDimensions) {
+		t.Fatalf("want ErrDimensions for an existing table, got %v", err)
 	}
 }
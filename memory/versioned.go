@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrVersionConflict is returned by CompareAndSwap when the key was written
+// after the version passed in was read
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionedStore is implemented by backends that support optimistic
+// concurrency: every write gets a new version and CompareAndSwap only writes
+// if the key is still at the version that was read
+type VersionedStore interface {
+	Store
+
+	// RetrieveVersion decodes the value at key into out (a non-nil pointer) and
+	// returns its version. A missing key is not an error: out is left untouched
+	// and the version is 0.
+	RetrieveVersion(ctx context.Context, key string, out interface{}) (uint64, error)
+
+	// CompareAndSwap stores value if key is still at version (0: the key must
+	// not exist) and returns the new version. It returns ErrVersionConflict
+	// when another write got there first.
+	CompareAndSwap(ctx context.Context, key string, version uint64, value interface{}) (uint64, error)
+}
+
+// MessageAppender is implemented by conversation stores that append a full
+// message (including Meta) after the session head in one atomic step, so
+// concurrent writers on a session never overwrite each other
+type MessageAppender interface {
+	// Append adds msg after the head of the active branch and returns its ID
+	Append(ctx context.Context, sessionID string, msg Message) (string, error)
+}
+
+// Update applies fn to the value at key as one read-modify-write. fn receives
+// the current value and whether it exists, and may be called more than once,
+// so it must not have side effects. cur may share memory with the stored
+// value: return a modified copy instead of changing it in place.
+//
+// On a VersionedStore the write is a CompareAndSwap retried on conflict, which
+// is safe across processes. Other stores are serialized with a process-local
+// lock per key: concurrent goroutines do not lose updates, but writers in other
+// processes are not coordinated. They must report a missing key with an error
+// wrapping ErrNotFound; any other read error is returned without writing.
+func Update[T any](ctx context.Context, s Store, key string, fn func(cur T, found bool) (T, error)) error {
+	vs, ok := s.(VersionedStore)
+	if !ok {
+		return lockedUpdate(ctx, s, key, fn)
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var cur T
+		version, err := vs.RetrieveVersion(ctx, key, &cur)
+		if err != nil {
+			return err
+		}
+		next, err := fn(cur, version > 0)
+		if err != nil {
+			return err
+		}
+		_, err = vs.CompareAndSwap(ctx, key, version, next)
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+	}
+}
+
+// updateLocks stripes the process-local locks used by Update for stores
+// without versioning
+var updateLocks [64]sync.Mutex
+
+func lockedUpdate[T any](ctx context.Context, s Store, key string, fn func(cur T, found bool) (T, error)) error {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	mu := &updateLocks[h.Sum32()%uint32(len(updateLocks))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	cur, err := Get[T](ctx, s, key)
+	found := err == nil
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err // a backend failure, or the key does not decode as T
+	}
+	next, err := fn(cur, found)
+	if err != nil {
+		return err
+	}
+	return s.Store(ctx, key, next)
+}
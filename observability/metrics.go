@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"sync"
 	"time"
 )
 
@@ -22,6 +23,40 @@ type Metrics interface {
 	SetActiveAgents(count int)
 }
 
+// CounterMetrics is optionally implemented by Metrics backends that accept
+// named counters such as cache hits or evictions
+type CounterMetrics interface {
+	IncrementCounter(name string, labels map[string]string)
+}
+
+// CounterAdder is optionally implemented by CounterMetrics backends that can
+// add several counts at once
+type CounterAdder interface {
+	AddCounter(name string, n int, labels map[string]string)
+}
+
+// IncrementCounter increments a named counter if MetricsImpl supports it
+func IncrementCounter(name string, labels map[string]string) {
+	if c, ok := MetricsImpl.(CounterMetrics); ok {
+		c.IncrementCounter(name, labels)
+	}
+}
+
+// AddCounter adds n to a named counter if MetricsImpl supports it
+func AddCounter(name string, n int, labels map[string]string) {
+	if n <= 0 {
+		return
+	}
+	switch c := MetricsImpl.(type) {
+	case CounterAdder:
+		c.AddCounter(name, n, labels)
+	case CounterMetrics:
+		for i := 0; i < n; i++ {
+			c.IncrementCounter(name, labels)
+		}
+	}
+}
+
 // NoOpMetrics is a no-operation implementation of Metrics
 type NoOpMetrics struct{}
 
@@ -47,12 +82,16 @@ type DefaultMetrics struct {
 	tokensUsed   int64
 	errors       map[string]int64
 	activeAgents int
+
+	countersMu sync.Mutex
+	counters   map[string]int64
 }
 
 // NewDefaultMetrics creates a new DefaultMetrics instance
 func NewDefaultMetrics() *DefaultMetrics {
 	return &DefaultMetrics{
-		errors: make(map[string]int64),
+		errors:   make(map[string]int64),
+		counters: make(map[string]int64),
 	}
 }
 
@@ -81,17 +120,38 @@ func (m *DefaultMetrics) SetActiveAgents(count int) {
 	m.activeAgents = count
 }
 
+// IncrementCounter implements CounterMetrics; labels are ignored
+func (m *DefaultMetrics) IncrementCounter(name string, labels map[string]string) {
+	m.AddCounter(name, 1, labels)
+}
+
+// AddCounter implements CounterAdder; labels are ignored
+func (m *DefaultMetrics) AddCounter(name string, n int, labels map[string]string) {
+	m.countersMu.Lock()
+	defer m.countersMu.Unlock()
+	m.counters[name] += int64(n)
+}
+
 // GetStats returns current statistics
 func (m *DefaultMetrics) GetStats() map[string]interface{} {
+	m.countersMu.Lock()
+	counters := make(map[string]int64, len(m.counters))
+	for k, v := range m.counters {
+		counters[k] = v
+	}
+	m.countersMu.Unlock()
 	return map[string]interface{}{
 		"requests":       m.requests,
 		"total_latency":  m.totalLatency.String(),
 		"tokens_used":    m.tokensUsed,
 		"errors":         m.errors,
 		"active_agents":  m.activeAgents,
+		"counters":       counters,
 	}
 }
 
 // Ensure implementations satisfy the interface
 var _ Metrics = (*NoOpMetrics)(nil)
-var _ Metrics = (*DefaultMetrics)(nil)
\ No newline at end of file
+var _ Metrics = (*DefaultMetrics)(nil)
+var _ CounterMetrics = (*DefaultMetrics)(nil)
+var _ CounterAdder = (*DefaultMetrics)(nil)
\ No newline at end of file
@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"sync"
 	"testing"
 	"time"
 )
@@ -29,3 +30,21 @@ func TestDefaultMetrics(t *testing.T) {
 		t.Fatalf("active wrong: %+v", s)
 	}
 }
+
+func TestDefaultMetrics_CountersConcurrent(t *testing.T) {
+	m := NewDefaultMetrics()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.IncrementCounter("llm_cache", nil)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := m.GetStats()["counters"].(map[string]int64)["llm_cache"]; got != 800 {
+		t.Fatalf("lost counter increments: %d", got)
+	}
+}
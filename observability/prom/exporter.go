@@ -3,6 +3,7 @@ package prom
 import (
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/KamdynS/go-agents/observability"
@@ -16,6 +17,9 @@ type Exporter struct {
 	tokens   map[string]float64
 	errors   map[string]float64
 	active   float64
+
+	countersMu sync.Mutex
+	counters   map[string]map[string]float64
 }
 
 // New creates a new in-process exporter.
@@ -25,6 +29,7 @@ func New() *Exporter {
 		latency:  make(map[string]float64),
 		tokens:   make(map[string]float64),
 		errors:   make(map[string]float64),
+		counters: make(map[string]map[string]float64),
 	}
 }
 
@@ -48,6 +53,14 @@ func Handler(e *Exporter) http.Handler {
 		for k, v := range e.errors {
 			_, _ = w.Write([]byte("goagents_errors_total{label=\"" + k + "\"} " + formatFloat(v) + "\n"))
 		}
+		// Named counters
+		e.countersMu.Lock()
+		for name, byLabel := range e.counters {
+			for k, v := range byLabel {
+				_, _ = w.Write([]byte("goagents_" + name + "_total{label=\"" + k + "\"} " + formatFloat(v) + "\n"))
+			}
+		}
+		e.countersMu.Unlock()
 		// Active agents
 		_, _ = w.Write([]byte("goagents_active_agents " + formatFloat(e.active) + "\n"))
 	})
@@ -70,6 +83,17 @@ func (e *Exporter) RecordError(errorType string, labels map[string]string) {
 	e.errors[key]++
 }
 func (e *Exporter) SetActiveAgents(count int) { e.active = float64(count) }
+func (e *Exporter) IncrementCounter(name string, labels map[string]string) {
+	e.AddCounter(name, 1, labels)
+}
+func (e *Exporter) AddCounter(name string, n int, labels map[string]string) {
+	e.countersMu.Lock()
+	defer e.countersMu.Unlock()
+	if e.counters[name] == nil {
+		e.counters[name] = make(map[string]float64)
+	}
+	e.counters[name][labelKey(labels)] += float64(n)
+}
 
 func labelKey(labels map[string]string) string {
 	if v, ok := labels["route"]; ok {
@@ -78,8 +102,16 @@ func labelKey(labels map[string]string) string {
 	if v, ok := labels["direction"]; ok {
 		return v + "|" + labels["model"]
 	}
+	if v, ok := labels["result"]; ok {
+		return v
+	}
+	if v, ok := labels["reason"]; ok {
+		return labels["store"] + "|" + v
+	}
 	return "generic"
 }
 
 // Ensure interface compliance
 var _ observability.Metrics = (*Exporter)(nil)
+var _ observability.CounterMetrics = (*Exporter)(nil)
+var _ observability.CounterAdder = (*Exporter)(nil)
@@ -3,6 +3,7 @@ package prom
 import (
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -22,3 +23,23 @@ func TestExporterMetricsAndHandler(t *testing.T) {
 		t.Fatalf("unexpected metrics body: %s", body)
 	}
 }
+
+func TestExporterCountersConcurrent(t *testing.T) {
+	e := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e.IncrementCounter("llm_cache", map[string]string{"result": "hit"})
+			}
+		}()
+	}
+	wg.Wait()
+	rr := httptest.NewRecorder()
+	Handler(e).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `goagents_llm_cache_total{label="hit"} 800`) {
+		t.Fatalf("lost counter increments: %s", rr.Body.String())
+	}
+}
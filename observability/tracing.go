@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -56,6 +57,7 @@ const (
 	AttrToolName     = "genai.tool.name"
 	AttrTokensInput  = "genai.tokens.input"
 	AttrTokensOutput = "genai.tokens.output"
+	AttrAgentName    = "agent.name"
 )
 
 // Global, swappable implementations (no-ops by default)
@@ -105,6 +107,7 @@ func (s *NoOpSpan) Context() context.Context {
 
 // DefaultTracer is a simple in-memory tracer for development
 type DefaultTracer struct {
+	mu    sync.Mutex
 	spans []SpanData
 }
 
@@ -156,7 +159,9 @@ func (t *DefaultTracer) SpanFromContext(ctx context.Context) Span {
 
 // GetSpans returns all recorded spans
 func (t *DefaultTracer) GetSpans() []SpanData {
-	return t.spans
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SpanData(nil), t.spans...)
 }
 
 // DefaultSpan is a simple in-memory span implementation
@@ -221,7 +226,10 @@ func (s *DefaultSpan) End() {
 		Attributes: s.attributes,
 		Events:     s.events,
 	}
+	// Spans from concurrent workers end on different goroutines
+	s.tracer.mu.Lock()
 	s.tracer.spans = append(s.tracer.spans, spanData)
+	s.tracer.mu.Unlock()
 }
 
 // Context implements Span interface
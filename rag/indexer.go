@@ -0,0 +1,268 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// Indexer keeps a VectorStore in sync with a corpus. It records the chunk
+// hashes of every source in State, so a run only embeds new or changed chunks
+// and removes the chunks of changed and deleted sources.
+type Indexer struct {
+	Store    memory.VectorStore
+	Embedder Embedder
+	// State persists what was indexed (any memory.Store; use a durable one
+	// such as memory/file or Redis to keep it across restarts)
+	State memory.Store
+	// Namespace separates indexes sharing a State store (default "default")
+	Namespace string
+	// ChunkSize is passed to Chunk (default 1200)
+	ChunkSize int
+}
+
+// IndexStats reports what an indexing run changed
+type IndexStats struct {
+	Added     int `json:"added"`     // new sources
+	Updated   int `json:"updated"`   // sources whose chunks changed
+	Unchanged int `json:"unchanged"` // sources skipped
+	Deleted   int `json:"deleted"`   // sources removed from the index
+	// Embedded counts chunks sent to the embedder; Removed counts chunks deleted from the store
+	Embedded int `json:"embedded"`
+	Removed  int `json:"removed"`
+}
+
+// indexedSource is the State record of one source
+type indexedSource struct {
+	Chunks []string `json:"chunks"` // chunk IDs, derived from content hashes
+}
+
+// Sync loads the corpus and indexes it as a whole (see Index)
+func (ix *Indexer) Sync(ctx context.Context, l Loader) (IndexStats, error) {
+	srcs, err := l.Load(ctx)
+	if err != nil {
+		return IndexStats{}, err
+	}
+	return ix.Index(ctx, srcs)
+}
+
+// Index treats srcs as the complete corpus: new and changed sources are
+// indexed and previously indexed sources missing from srcs are deleted.
+// Use Update to index a subset without deleting the rest.
+func (ix *Indexer) Index(ctx context.Context, srcs []Source) (IndexStats, error) {
+	stats, err := ix.Update(ctx, srcs)
+	if err != nil {
+		return stats, err
+	}
+	keep := make(map[string]bool, len(srcs))
+	for _, s := range srcs {
+		keep[s.ID] = true
+	}
+	indexed, err := ix.indexedIDs(ctx)
+	if err != nil {
+		return stats, err
+	}
+	var stale []string
+	for _, id := range indexed {
+		if !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	removed, err := ix.Remove(ctx, stale...)
+	stats.Deleted += len(stale)
+	stats.Removed += removed
+	return stats, err
+}
+
+// Update indexes new and changed sources and leaves the others alone
+func (ix *Indexer) Update(ctx context.Context, srcs []Source) (IndexStats, error) {
+	var stats IndexStats
+	if ix.Store == nil || ix.Embedder == nil || ix.State == nil {
+		return stats, errors.New("rag: Indexer needs Store, Embedder and State")
+	}
+	seen := make(map[string]bool, len(srcs))
+	for _, src := range srcs {
+		if src.ID == "" {
+			return stats, errors.New("rag: source without ID")
+		}
+		if seen[src.ID] {
+			return stats, fmt.Errorf("rag: duplicate source ID %s", src.ID)
+		}
+		seen[src.ID] = true
+		if err := ix.indexSource(ctx, src, &stats); err != nil {
+			return stats, fmt.Errorf("index %s: %w", src.ID, err)
+		}
+	}
+	return stats, nil
+}
+
+func (ix *Indexer) indexSource(ctx context.Context, src Source, stats *IndexStats) error {
+	prev, found, err := ix.load(ctx, src.ID)
+	if err != nil {
+		return err
+	}
+	had := make(map[string]bool, len(prev.Chunks))
+	for _, id := range prev.Chunks {
+		had[id] = true
+	}
+
+	var next indexedSource
+	var docs []memory.Document
+	inNext := map[string]bool{}
+	for i, text := range Chunk(src.Content, ix.chunkSize()) {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		id := chunkID(src, text)
+		if inNext[id] {
+			continue // repeated chunk within the source
+		}
+		inNext[id] = true
+		next.Chunks = append(next.Chunks, id)
+		if had[id] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		vec, err := ix.Embedder.EmbedText(ctx, text)
+		if err != nil {
+			return fmt.Errorf("embed chunk %d: %w", i, err)
+		}
+		stats.Embedded++
+		meta := map[string]string{"source_id": src.ID, "chunk": strconv.Itoa(i)}
+		for k, v := range src.Meta {
+			meta[k] = v
+		}
+		docs = append(docs, memory.Document{ID: id, Content: text, Embedding: vec, Meta: meta})
+	}
+
+	changed := len(docs) > 0 || len(next.Chunks) != len(prev.Chunks)
+	if !changed {
+		stats.Unchanged++
+		return nil
+	}
+	// Write new chunks before dropping old ones so queries never see a gap;
+	// rerunning after a failure re-embeds at most the chunks of this source
+	if err := ix.write(ctx, docs); err != nil {
+		return err
+	}
+	for _, id := range prev.Chunks {
+		if !inNext[id] {
+			if err := ix.Store.DeleteDocument(ctx, id); err != nil {
+				return err
+			}
+			stats.Removed++
+		}
+	}
+	if err := ix.State.Store(ctx, ix.stateKey(src.ID), next); err != nil {
+		return err
+	}
+	if found {
+		stats.Updated++
+	} else {
+		stats.Added++
+	}
+	return nil
+}
+
+// write upserts chunks, in one batch when the store supports it (keeping Meta)
+func (ix *Indexer) write(ctx context.Context, docs []memory.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if w, ok := ix.Store.(memory.DocumentWriter); ok {
+		return w.AddDocuments(ctx, docs)
+	}
+	for _, d := range docs {
+		if err := ix.Store.AddDocument(ctx, d.ID, d.Content, d.Embedding); err != nil {
+			return fmt.Errorf("upsert %s: %w", d.ID, err)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the chunks of the given sources from the store and the
+// state, returning the number of chunks deleted
+func (ix *Indexer) Remove(ctx context.Context, sourceIDs ...string) (int, error) {
+	removed := 0
+	for _, id := range sourceIDs {
+		prev, found, err := ix.load(ctx, id)
+		if err != nil {
+			return removed, err
+		}
+		if !found {
+			continue
+		}
+		for _, cid := range prev.Chunks {
+			if err := ix.Store.DeleteDocument(ctx, cid); err != nil {
+				return removed, fmt.Errorf("remove %s: %w", id, err)
+			}
+			removed++
+		}
+		if err := ix.State.Delete(ctx, ix.stateKey(id)); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// load returns the state record of a source; only a key missing from State
+// (memory.ErrNotFound) means the source was never indexed
+func (ix *Indexer) load(ctx context.Context, sourceID string) (indexedSource, bool, error) {
+	rec, err := memory.Get[indexedSource](ctx, ix.State, ix.stateKey(sourceID))
+	if errors.Is(err, memory.ErrNotFound) {
+		return indexedSource{}, false, nil
+	}
+	return rec, err == nil, err
+}
+
+// indexedIDs lists the source IDs recorded in the state
+func (ix *Indexer) indexedIDs(ctx context.Context) ([]string, error) {
+	keys, err := ix.State.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ix.stateKey("")
+	var ids []string
+	for _, k := range keys {
+		if id, ok := strings.CutPrefix(k, prefix); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (ix *Indexer) stateKey(sourceID string) string {
+	ns := ix.Namespace
+	if ns == "" {
+		ns = "default"
+	}
+	return "rag_index:" + ns + ":" + sourceID
+}
+
+func (ix *Indexer) chunkSize() int {
+	if ix.ChunkSize > 0 {
+		return ix.ChunkSize
+	}
+	return 1200
+}
+
+// chunkID derives a stable vector ID from the source, the chunk text and the
+// source metadata, so unchanged chunks keep their ID when others move and a
+// metadata change re-indexes the source
+func chunkID(src Source, text string) string {
+	h := sha256.New()
+	meta, _ := json.Marshal(src.Meta) // map keys are sorted
+	h.Write(meta)
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return src.ID + "#" + hex.EncodeToString(h.Sum(nil))[:16]
+}
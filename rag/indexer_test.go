@@ -0,0 +1,207 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+// countingEmb embeds by text length and records what it embedded
+type countingEmb struct{ texts []string }
+
+func (e *countingEmb) EmbedText(ctx context.Context, input string) ([]float64, error) {
+	e.texts = append(e.texts, input)
+	return []float64{float64(len(input)), 1}, nil
+}
+
+// mapVS is a vector store keyed by ID that supports batch writes
+type mapVS struct {
+	docs    map[string]memory.Document
+	batches int
+}
+
+func newMapVS() *mapVS { return &mapVS{docs: map[string]memory.Document{}} }
+
+func (m *mapVS) AddDocument(ctx context.Context, id, content string, vector []float64) error {
+	m.docs[id] = memory.Document{ID: id, Content: content, Embedding: vector}
+	return nil
+}
+func (m *mapVS) AddDocuments(ctx context.Context, docs []memory.Document) error {
+	m.batches++
+	for _, d := range docs {
+		m.docs[d.ID] = d
+	}
+	return nil
+}
+func (m *mapVS) QuerySimilar(ctx context.Context, vector []float64, topK int) ([]memory.Document, error) {
+	return nil, nil
+}
+func (m *mapVS) DeleteDocument(ctx context.Context, id string) error {
+	if _, ok := m.docs[id]; !ok {
+		return errors.New("not found")
+	}
+	delete(m.docs, id)
+	return nil
+}
+func (m *mapVS) GetDocument(ctx context.Context, id string) (*memory.Document, error) {
+	d, ok := m.docs[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &d, nil
+}
+
+func (m *mapVS) sources() []string {
+	set := map[string]bool{}
+	for _, d := range m.docs {
+		set[d.Meta["source_id"]] = true
+	}
+	var out []string
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestIndexerIncremental(t *testing.T) {
+	ctx := context.Background()
+	vs, emb, state := newMapVS(), &countingEmb{}, inmemory.NewStore()
+	ix := &Indexer{Store: vs, Embedder: emb, State: state, ChunkSize: 10}
+
+	corpus := []Source{
+		{ID: "a", Content: "alpha one\n\nalpha two", Meta: map[string]string{"lang": "en"}},
+		{ID: "b", Content: "beta"},
+	}
+	stats, err := ix.Index(ctx, corpus)
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	if stats.Added != 2 || stats.Embedded != 3 || len(vs.docs) != 3 {
+		t.Fatalf("first run: %+v, %d docs", stats, len(vs.docs))
+	}
+	for _, d := range vs.docs {
+		if d.Meta["source_id"] == "a" && d.Meta["lang"] != "en" {
+			t.Fatalf("source meta not copied: %+v", d)
+		}
+	}
+
+	// nothing changed: nothing embedded
+	emb.texts = nil
+	stats, _ = ix.Index(ctx, corpus)
+	if stats.Unchanged != 2 || stats.Embedded != 0 || len(emb.texts) != 0 {
+		t.Fatalf("unchanged run: %+v", stats)
+	}
+
+	// one chunk edited, one source deleted, one added
+	corpus = []Source{
+		{ID: "a", Content: "alpha one\n\nalpha 2", Meta: map[string]string{"lang": "en"}},
+		{ID: "c", Content: "gamma"},
+	}
+	stats, err = ix.Index(ctx, corpus)
+	if err != nil {
+		t.Fatalf("reindex: %v", err)
+	}
+	if stats.Updated != 1 || stats.Added != 1 || stats.Deleted != 1 || stats.Embedded != 2 || stats.Removed != 2 {
+		t.Fatalf("reindex: %+v", stats)
+	}
+	if strings.Join(emb.texts, "|") != "alpha 2|gamma" {
+		t.Fatalf("only new chunks should be embedded: %q", emb.texts)
+	}
+	if got := strings.Join(vs.sources(), ","); got != "a,c" || len(vs.docs) != 3 {
+		t.Fatalf("store sources %s, %d docs", got, len(vs.docs))
+	}
+
+	// a metadata change re-indexes the source
+	corpus[1].Meta = map[string]string{"lang": "el"}
+	stats, _ = ix.Index(ctx, corpus)
+	if stats.Updated != 1 || stats.Unchanged != 1 || stats.Removed != 1 {
+		t.Fatalf("meta change: %+v", stats)
+	}
+}
+
+func TestIndexerUpdateAndRemove(t *testing.T) {
+	ctx := context.Background()
+	vs, state := newMapVS(), inmemory.NewStore()
+	ix := &Indexer{Store: vs, Embedder: &countingEmb{}, State: state, Namespace: "docs"}
+	if _, err := ix.Index(ctx, []Source{{ID: "a", Content: "alpha"}, {ID: "b", Content: "beta"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Update leaves sources it was not given alone
+	if _, err := ix.Update(ctx, []Source{{ID: "c", Content: "gamma"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(vs.sources(), ","); got != "a,b,c" {
+		t.Fatalf("after update: %s", got)
+	}
+	n, err := ix.Remove(ctx, "b", "missing")
+	if err != nil || n != 1 {
+		t.Fatalf("remove: %d (%v)", n, err)
+	}
+	if got := strings.Join(vs.sources(), ","); got != "a,c" {
+		t.Fatalf("after remove: %s", got)
+	}
+	keys, _ := state.List(ctx)
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "rag_index:docs:a,rag_index:docs:c" {
+		t.Fatalf("state keys: %v", keys)
+	}
+	// another namespace in the same state store does not see these sources
+	other := &Indexer{Store: vs, Embedder: &countingEmb{}, State: state, Namespace: "other"}
+	if stats, _ := other.Index(ctx, nil); stats.Deleted != 0 {
+		t.Fatalf("namespaces should be separate: %+v", stats)
+	}
+	if _, err := ix.Update(ctx, []Source{{ID: "x"}, {ID: "x"}}); err == nil {
+		t.Fatal("expected duplicate ID error")
+	}
+}
+
+func TestIndexerSyncWithLoader(t *testing.T) {
+	ctx := context.Background()
+	vs := &fakeVS{} // no batch writer: falls back to AddDocument
+	ix := &Indexer{Store: vs, Embedder: fakeEmb{vec: []float64{1}}, State: inmemory.NewStore()}
+	stats, err := ix.Sync(ctx, FileLoader{Path: "testdata/missing.txt"})
+	if err == nil {
+		t.Fatalf("expected load error, got %+v", stats)
+	}
+	stats, err = ix.Index(ctx, []Source{{ID: "a", Content: "one\n\ntwo"}})
+	if err != nil || stats.Embedded != 1 || len(vs.docs) != 1 {
+		t.Fatalf("fallback: %+v, %d docs (%v)", stats, len(vs.docs), err)
+	}
+}
+
+// kvStore lets plainState embed a store without clashing with its Store method
+type kvStore = memory.Store
+
+// plainState hides versioning and counts List calls
+type plainState struct {
+	kvStore
+	lists int
+}
+
+func (s *plainState) List(ctx context.Context) ([]string, error) {
+	s.lists++
+	return s.kvStore.List(ctx)
+}
+
+func TestIndexerPlainStateListsOnce(t *testing.T) {
+	ctx := context.Background()
+	state := &plainState{kvStore: inmemory.NewStore()}
+	ix := &Indexer{Store: newMapVS(), Embedder: &countingEmb{}, State: state}
+	srcs := []Source{{ID: "a", Content: "alpha"}, {ID: "b", Content: "beta"}, {ID: "c", Content: "gamma"}}
+	if _, err := ix.Index(ctx, srcs); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ix.Index(ctx, srcs[:2])
+	if err != nil || stats.Unchanged != 2 || stats.Deleted != 1 {
+		t.Fatalf("unexpected stats %+v (%v)", stats, err)
+	}
+	if state.lists != 2 {
+		t.Fatalf("want one List per run, got %d", state.lists)
+	}
+}
@@ -0,0 +1,461 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source is a loaded document before chunking. ID is stable across loads
+// (e.g. a relative path) so the Indexer can detect changes and deletions.
+type Source struct {
+	ID      string            `json:"id"`
+	Content string            `json:"content"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// Loader produces the sources of a corpus
+type Loader interface {
+	Load(ctx context.Context) ([]Source, error)
+}
+
+// Parser turns the bytes of one file into sources; id is the file's ID
+type Parser func(id string, data []byte) ([]Source, error)
+
+// ParseText returns the file as a single source
+func ParseText(id string, data []byte) ([]Source, error) {
+	return []Source{{ID: id, Content: string(data)}}, nil
+}
+
+// ParseMarkdown returns the body of a Markdown file. YAML front matter
+// (between "---" lines) becomes metadata; scalar values are kept as strings
+// and lists are joined with ", ". Without a title field, the first "# "
+// heading is used.
+func ParseMarkdown(id string, data []byte) ([]Source, error) {
+	body := string(data)
+	meta := map[string]string{}
+	if fm, rest, ok := splitFrontMatter(body); ok {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal([]byte(fm), &raw); err != nil {
+			return nil, fmt.Errorf("%s: front matter: %w", id, err)
+		}
+		for k, v := range raw {
+			meta[k] = metaString(v)
+		}
+		body = rest
+	}
+	if meta["title"] == "" {
+		for _, line := range strings.Split(body, "\n") {
+			if t, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+				meta["title"] = strings.TrimSpace(t)
+				break
+			}
+		}
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return []Source{{ID: id, Content: strings.TrimSpace(body), Meta: meta}}, nil
+}
+
+func splitFrontMatter(s string) (fm, rest string, ok bool) {
+	s = strings.TrimPrefix(s, "\ufeff")
+	if !strings.HasPrefix(s, "---\n") && !strings.HasPrefix(s, "---\r\n") {
+		return "", s, false
+	}
+	start := strings.Index(s, "\n") + 1
+	for i := start; i < len(s); {
+		end := strings.Index(s[i:], "\n")
+		line := s[i:]
+		if end >= 0 {
+			line = s[i : i+end]
+		}
+		if strings.TrimRight(line, "\r") == "---" {
+			if end < 0 {
+				return s[start:i], "", true
+			}
+			return s[start:i], s[i+end+1:], true
+		}
+		if end < 0 {
+			break
+		}
+		i += end + 1
+	}
+	return "", s, false
+}
+
+func metaString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = metaString(e)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// ParseHTML extracts readable text: script, style and similar elements are
+// dropped, block elements become line breaks and entities are decoded. The
+// <title> is stored as metadata.
+func ParseHTML(id string, data []byte) ([]Source, error) {
+	text, title := htmlToText(string(data))
+	var meta map[string]string
+	if title != "" {
+		meta = map[string]string{"title": title}
+	}
+	return []Source{{ID: id, Content: text, Meta: meta}}, nil
+}
+
+var (
+	skipElements  = map[string]bool{"script": true, "style": true, "noscript": true, "template": true, "svg": true}
+	blockElements = map[string]bool{
+		"p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true, "tr": true, "table": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true, "article": true,
+		"header": true, "footer": true, "nav": true, "blockquote": true, "pre": true, "hr": true, "title": true,
+	}
+	// inlineElements are joined to the surrounding text without a space
+	inlineElements = map[string]bool{
+		"a": true, "b": true, "i": true, "em": true, "strong": true, "span": true, "code": true,
+		"small": true, "sub": true, "sup": true, "u": true, "s": true, "mark": true, "abbr": true,
+	}
+)
+
+func htmlToText(s string) (text, title string) {
+	var out, titleBuf strings.Builder
+	skip := "" // element whose content is being skipped
+	inTitle := false
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			lt = len(s)
+		}
+		if lt > 0 {
+			chunk := s[:lt]
+			if inTitle {
+				titleBuf.WriteString(chunk)
+			}
+			if skip == "" {
+				out.WriteString(chunk)
+			}
+			s = s[lt:]
+			continue
+		}
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+3:]
+			continue
+		}
+		gt := strings.IndexByte(s, '>')
+		if gt < 0 {
+			break
+		}
+		tag := s[1:gt]
+		s = s[gt+1:]
+		closing := strings.HasPrefix(tag, "/")
+		name := strings.ToLower(strings.TrimLeft(tag, "/"))
+		if i := strings.IndexAny(name, " \t\r\n/"); i >= 0 {
+			name = name[:i]
+		}
+		if name == "title" {
+			inTitle = !closing
+		}
+		if skip != "" {
+			if closing && name == skip {
+				skip = ""
+			}
+			continue
+		}
+		if !closing && skipElements[name] {
+			skip = name
+			continue
+		}
+		switch {
+		case blockElements[name]:
+			out.WriteString("\n")
+		case inlineElements[name]:
+		default:
+			out.WriteString(" ")
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(html.UnescapeString(out.String()), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	title = strings.Join(strings.Fields(html.UnescapeString(titleBuf.String())), " ")
+	// The title is metadata; drop it from the body when it leads
+	if len(lines) > 0 && lines[0] == title {
+		lines = lines[1:]
+	}
+	return strings.Join(lines, "\n"), title
+}
+
+// CSVParser turns each row of a CSV file (with a header row) into a source
+type CSVParser struct {
+	// IDColumn names the column holding a stable row ID; rows are numbered otherwise
+	IDColumn string
+	// ContentColumns become "column: value" lines of the content (default all)
+	ContentColumns []string
+	// MetaColumns are copied to the metadata
+	MetaColumns []string
+	// Comma is the field delimiter (default ',')
+	Comma rune
+}
+
+// Parse implements Parser; row IDs are "<id>#<row ID or number>"
+func (p CSVParser) Parse(id string, data []byte) ([]Source, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	if p.Comma != 0 {
+		r.Comma = p.Comma
+	}
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+	content := p.ContentColumns
+	if len(content) == 0 {
+		content = header
+	}
+	for _, c := range append(append([]string{}, content...), p.MetaColumns...) {
+		if _, ok := col[strings.TrimSpace(c)]; !ok {
+			return nil, fmt.Errorf("%s: unknown column %q", id, c)
+		}
+	}
+	if _, ok := col[p.IDColumn]; p.IDColumn != "" && !ok {
+		return nil, fmt.Errorf("%s: unknown ID column %q", id, p.IDColumn)
+	}
+
+	var out []Source
+	for n := 1; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		rowID := fmt.Sprint(n)
+		if p.IDColumn != "" {
+			rowID = rec[col[p.IDColumn]]
+		}
+		var b strings.Builder
+		for _, c := range content {
+			c = strings.TrimSpace(c)
+			if v := rec[col[c]]; v != "" {
+				fmt.Fprintf(&b, "%s: %s\n", c, v)
+			}
+		}
+		src := Source{ID: id + "#" + rowID, Content: strings.TrimSpace(b.String())}
+		for _, c := range p.MetaColumns {
+			if src.Meta == nil {
+				src.Meta = map[string]string{}
+			}
+			src.Meta[c] = rec[col[strings.TrimSpace(c)]]
+		}
+		out = append(out, src)
+	}
+}
+
+// DefaultParsers maps file extensions to parsers
+func DefaultParsers() map[string]Parser {
+	return map[string]Parser{
+		".txt":      ParseText,
+		".text":     ParseText,
+		".md":       ParseMarkdown,
+		".markdown": ParseMarkdown,
+		".html":     ParseHTML,
+		".htm":      ParseHTML,
+		".csv":      CSVParser{}.Parse,
+	}
+}
+
+// FileLoader loads one file
+type FileLoader struct {
+	// FS to read from; nil reads the OS file system
+	FS   fs.FS
+	Path string
+	// Parser defaults to the DefaultParsers entry for the extension, then ParseText
+	Parser Parser
+}
+
+// Load implements Loader; the source ID is the path and Meta["source"] is set to it
+func (l FileLoader) Load(ctx context.Context) ([]Source, error) {
+	var data []byte
+	var err error
+	if l.FS != nil {
+		data, err = fs.ReadFile(l.FS, l.Path)
+	} else {
+		data, err = os.ReadFile(l.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	parse := l.Parser
+	if parse == nil {
+		if parse = DefaultParsers()[strings.ToLower(path.Ext(l.Path))]; parse == nil {
+			parse = ParseText
+		}
+	}
+	id := filepath.ToSlash(l.Path)
+	srcs, err := parse(id, data)
+	if err != nil {
+		return nil, err
+	}
+	return withSource(srcs, id), nil
+}
+
+// DirLoader walks a directory and parses the files matching its globs
+type DirLoader struct {
+	// FS to walk; nil walks Root on the OS file system
+	FS   fs.FS
+	Root string
+	// Include globs, matched against slash-separated paths relative to Root.
+	// "**" matches any number of directories; a glob without "/" matches the
+	// base name. Default: every file with a parser.
+	Include []string
+	// Exclude globs take precedence over Include
+	Exclude []string
+	// Parsers by lower-case extension (default DefaultParsers); files without
+	// one are skipped
+	Parsers map[string]Parser
+}
+
+// Load implements Loader; source IDs are paths relative to Root
+func (l DirLoader) Load(ctx context.Context) ([]Source, error) {
+	fsys, root := l.FS, l.Root
+	if fsys == nil {
+		fsys, root = os.DirFS(l.Root), "."
+	}
+	if root == "" {
+		root = "."
+	}
+	parsers := l.Parsers
+	if parsers == nil {
+		parsers = DefaultParsers()
+	}
+	var files []string
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel := relPath(root, p)
+		if matchAny(l.Exclude, rel) || (len(l.Include) > 0 && !matchAny(l.Include, rel)) {
+			return nil
+		}
+		if parsers[strings.ToLower(path.Ext(p))] != nil {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var out []Source
+	for _, p := range files {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		id := relPath(root, p)
+		srcs, err := parsers[strings.ToLower(path.Ext(p))](id, data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, withSource(srcs, id)...)
+	}
+	return out, nil
+}
+
+// relPath returns p relative to the walked root
+func relPath(root, p string) string {
+	if root == "." {
+		return p
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+}
+
+func withSource(srcs []Source, id string) []Source {
+	for i := range srcs {
+		if srcs[i].Meta == nil {
+			srcs[i].Meta = map[string]string{}
+		}
+		srcs[i].Meta["source"] = id
+	}
+	return srcs
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, g := range globs {
+		if matchGlob(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches slash-separated name against pattern, where a "**"
+// segment matches zero or more segments. A pattern without "/" is matched
+// against the base name.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
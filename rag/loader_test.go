@@ -0,0 +1,132 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMarkdownFrontMatter(t *testing.T) {
+	doc := "---\ntitle: Setup\ntags: [go, rag]\nversion: 2\n---\n# Ignored heading\n\nInstall it.\n"
+	srcs, err := ParseMarkdown("setup.md", []byte(doc))
+	if err != nil || len(srcs) != 1 {
+		t.Fatalf("parse: %+v (%v)", srcs, err)
+	}
+	s := srcs[0]
+	if s.Meta["title"] != "Setup" || s.Meta["tags"] != "go, rag" || s.Meta["version"] != "2" {
+		t.Fatalf("meta: %+v", s.Meta)
+	}
+	if strings.Contains(s.Content, "---") || !strings.HasSuffix(s.Content, "Install it.") {
+		t.Fatalf("content: %q", s.Content)
+	}
+
+	srcs, _ = ParseMarkdown("plain.md", []byte("intro\n# Heading\nbody"))
+	if srcs[0].Meta["title"] != "Heading" {
+		t.Fatalf("heading title: %+v", srcs[0].Meta)
+	}
+	if _, err := ParseMarkdown("bad.md", []byte("---\n: [\n---\nx")); err == nil {
+		t.Fatal("expected front matter error")
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	page := `<html><head><title>Docs &amp; more</title><style>p{color:red}</style></head>
+<body><h1>Intro</h1><p>Hello <b>world</b>.</p><script>alert(1)</script><ul><li>one</li><li>two</li></ul></body></html>`
+	srcs, err := ParseHTML("page.html", []byte(page))
+	if err != nil || len(srcs) != 1 {
+		t.Fatalf("parse: %+v (%v)", srcs, err)
+	}
+	s := srcs[0]
+	if s.Meta["title"] != "Docs & more" {
+		t.Fatalf("title: %+v", s.Meta)
+	}
+	for _, want := range []string{"Intro", "Hello world.", "one", "two"} {
+		if !strings.Contains(s.Content, want) {
+			t.Errorf("content missing %q: %q", want, s.Content)
+		}
+	}
+	for _, unwanted := range []string{"alert", "color", "<"} {
+		if strings.Contains(s.Content, unwanted) {
+			t.Errorf("content contains %q: %q", unwanted, s.Content)
+		}
+	}
+}
+
+func TestCSVParser(t *testing.T) {
+	data := "sku,name,description,category\nA1,Widget,Small part,tools\nB2,Gadget,,toys\n"
+	p := CSVParser{IDColumn: "sku", ContentColumns: []string{"name", "description"}, MetaColumns: []string{"category"}}
+	srcs, err := p.Parse("products.csv", []byte(data))
+	if err != nil || len(srcs) != 2 {
+		t.Fatalf("parse: %+v (%v)", srcs, err)
+	}
+	if srcs[0].ID != "products.csv#A1" || srcs[0].Content != "name: Widget\ndescription: Small part" || srcs[0].Meta["category"] != "tools" {
+		t.Fatalf("row 1: %+v", srcs[0])
+	}
+	if srcs[1].Content != "name: Gadget" {
+		t.Fatalf("empty cells should be skipped: %q", srcs[1].Content)
+	}
+	if _, err := (CSVParser{MetaColumns: []string{"nope"}}).Parse("x.csv", []byte(data)); err == nil {
+		t.Fatal("expected unknown column error")
+	}
+	srcs, _ = CSVParser{}.Parse("x.csv", []byte(data))
+	if srcs[1].ID != "x.csv#2" {
+		t.Fatalf("rows should be numbered without an ID column: %s", srcs[1].ID)
+	}
+}
+
+func TestDirLoaderGlobs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a.md":              {Data: []byte("# A\nalpha")},
+		"docs/guide/b.txt":       {Data: []byte("beta")},
+		"docs/guide/deep/c.html": {Data: []byte("<p>gamma</p>")},
+		"docs/drafts/d.md":       {Data: []byte("draft")},
+		"docs/image.png":         {Data: []byte{0x89}},
+		"other/e.txt":            {Data: []byte("outside")},
+	}
+	l := DirLoader{FS: fsys, Root: "docs", Exclude: []string{"drafts/**"}}
+	srcs, err := l.Load(context.Background())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var ids []string
+	for _, s := range srcs {
+		ids = append(ids, s.ID)
+		if s.Meta["source"] != s.ID {
+			t.Errorf("source meta: %+v", s)
+		}
+	}
+	if strings.Join(ids, ",") != "a.md,guide/b.txt,guide/deep/c.html" {
+		t.Fatalf("ids: %v", ids)
+	}
+
+	l = DirLoader{FS: fsys, Root: "docs", Include: []string{"guide/**/*.html", "*.md"}}
+	srcs, _ = l.Load(context.Background())
+	if len(srcs) != 3 || srcs[0].ID != "a.md" || srcs[2].ID != "guide/deep/c.html" {
+		t.Fatalf("include: %+v", srcs)
+	}
+
+	fl := FileLoader{FS: fsys, Path: "docs/guide/deep/c.html"}
+	srcs, err = fl.Load(context.Background())
+	if err != nil || len(srcs) != 1 || srcs[0].Content != "gamma" {
+		t.Fatalf("file loader: %+v (%v)", srcs, err)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.md", "a/b/c.md", true},
+		{"**/*.md", "c.md", true},
+		{"a/**/c.md", "a/x/y/c.md", true},
+		{"a/*.md", "a/x/c.md", false},
+		{"a/**", "a/x/c.md", true},
+		{"b/**", "a/b/c.md", false},
+	} {
+		if got := matchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("matchGlob(%q, %q) = %v", tc.pattern, tc.name, got)
+		}
+	}
+}
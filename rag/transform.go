@@ -0,0 +1,359 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// QueryTransformer derives extra retrieval queries from a question
+type QueryTransformer interface {
+	Transform(ctx context.Context, question string) ([]string, error)
+}
+
+// TransformerFunc adapts a function to QueryTransformer
+type TransformerFunc func(ctx context.Context, question string) ([]string, error)
+
+func (f TransformerFunc) Transform(ctx context.Context, question string) ([]string, error) {
+	return f(ctx, question)
+}
+
+// Rewriter turns a follow-up question into a standalone one using the chat history
+type Rewriter interface {
+	Rewrite(ctx context.Context, question string, history []llm.Message) (string, error)
+}
+
+// Retriever runs a question through optional rewriting and query strategies,
+// queries the store once per resulting query and fuses the results
+type Retriever struct {
+	Store    memory.VectorStore
+	Embedder Embedder
+	// Rewriter makes the question standalone before the strategies run (optional)
+	Rewriter Rewriter
+	// Strategies add queries; the (rewritten) question is always searched too
+	Strategies []QueryTransformer
+	// PerQuery is the number of candidates fetched per query (default topK)
+	PerQuery int
+	// Fuse merges the ranked lists of all queries (default ReciprocalRankFusion)
+	Fuse func(lists [][]memory.Document) []memory.Document
+}
+
+// Retrieve returns the topK fused and deduplicated documents for question.
+// history is the recent conversation, used by the Rewriter.
+func (r *Retriever) Retrieve(ctx context.Context, question string, history []llm.Message, topK int) ([]memory.Document, error) {
+	if r.Store == nil || r.Embedder == nil {
+		return nil, errors.New("rag: Retriever needs Store and Embedder")
+	}
+	if topK <= 0 {
+		topK = 5
+	}
+	queries, err := r.Queries(ctx, question, history)
+	if err != nil {
+		return nil, err
+	}
+	perQuery := r.PerQuery
+	if perQuery <= 0 {
+		perQuery = topK
+	}
+
+	lists := make([][]memory.Document, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			lists[i], errs[i] = Query(ctx, r.Store, r.Embedder, q, perQuery)
+		}(i, q)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	fuse := r.Fuse
+	if fuse == nil {
+		fuse = ReciprocalRankFusion
+	}
+	docs := fuse(lists)
+	if len(docs) > topK {
+		docs = docs[:topK]
+	}
+	return docs, nil
+}
+
+// Queries returns the queries Retrieve searches: the rewritten question
+// followed by the strategies' queries, without duplicates
+func (r *Retriever) Queries(ctx context.Context, question string, history []llm.Message) ([]string, error) {
+	if r.Rewriter != nil {
+		q, err := r.Rewriter.Rewrite(ctx, question, history)
+		if err != nil {
+			return nil, fmt.Errorf("rag: rewrite: %w", err)
+		}
+		if strings.TrimSpace(q) != "" {
+			question = q
+		}
+	}
+
+	extra := make([][]string, len(r.Strategies))
+	errs := make([]error, len(r.Strategies))
+	var wg sync.WaitGroup
+	for i, s := range r.Strategies {
+		wg.Add(1)
+		go func(i int, s QueryTransformer) {
+			defer wg.Done()
+			extra[i], errs[i] = s.Transform(ctx, question)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("rag: %T: %w", s, errs[i])
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	queries := []string{question}
+	seen := map[string]bool{normalizeQuery(question): true}
+	for _, qs := range extra {
+		for _, q := range qs {
+			q = strings.TrimSpace(q)
+			if key := normalizeQuery(q); q != "" && !seen[key] {
+				seen[key] = true
+				queries = append(queries, q)
+			}
+		}
+	}
+	return queries, nil
+}
+
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+// rrfK dampens the weight of top ranks, as in the original RRF paper
+const rrfK = 60
+
+// ReciprocalRankFusion merges ranked lists: a document scores the sum of
+// 1/(60+rank) over the lists it appears in, so documents found by several
+// queries rise. Documents are deduplicated by ID (by content when the ID is
+// empty) and Score is set to the fused score.
+func ReciprocalRankFusion(lists [][]memory.Document) []memory.Document {
+	type entry struct {
+		doc   memory.Document
+		score float64
+		first int // order of first appearance, for stable ties
+	}
+	byKey := map[string]*entry{}
+	var order []*entry
+	for _, list := range lists {
+		for rank, d := range list {
+			key := d.ID
+			if key == "" {
+				key = "content:" + d.Content
+			}
+			e, ok := byKey[key]
+			if !ok {
+				e = &entry{doc: d, first: len(order)}
+				byKey[key] = e
+				order = append(order, e)
+			}
+			e.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].score != order[j].score {
+			return order[i].score > order[j].score
+		}
+		return order[i].first < order[j].first
+	})
+	out := make([]memory.Document, len(order))
+	for i, e := range order {
+		out[i] = e.doc
+		out[i].Score = e.score
+	}
+	return out
+}
+
+// ----- LLM strategies -----
+
+// MultiQuery asks the model for alternative phrasings of the question
+type MultiQuery struct {
+	Model llm.Client
+	// N is the number of queries requested (default 3)
+	N int
+	// Prompt overrides the default instructions
+	Prompt string
+}
+
+const defaultMultiQueryPrompt = "You help a search engine find documents. Write %d different search queries " +
+	"for the user's question, each covering a different wording or aspect of it. Keep each query short."
+
+// multiQueries is the structured output requested by MultiQuery
+type multiQueries struct {
+	llm.BaseStructured
+	Queries []string `json:"queries" description:"Search queries"`
+}
+
+func (multiQueries) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"queries": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Search queries",
+			},
+		},
+		"required": []string{"queries"},
+	}
+}
+
+func (m MultiQuery) Transform(ctx context.Context, question string) ([]string, error) {
+	if m.Model == nil {
+		return nil, errors.New("no model configured")
+	}
+	n := m.N
+	if n <= 0 {
+		n = 3
+	}
+	prompt := m.Prompt
+	if prompt == "" {
+		prompt = fmt.Sprintf(defaultMultiQueryPrompt, n)
+	}
+	resp, err := llm.StructuredChat(ctx, m.Model, llm.StructuredRequest[multiQueries]{
+		SystemPrompt: prompt,
+		Messages:     []llm.Message{{Role: "user", Content: question}},
+		OutputType:   multiQueries{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qs := resp.Data.Queries
+	if len(qs) > n {
+		qs = qs[:n]
+	}
+	return qs, nil
+}
+
+// HyDE (hypothetical document embeddings) has the model write a plausible
+// answer passage and searches with it: answers tend to sit closer to the
+// relevant documents in embedding space than short questions do
+type HyDE struct {
+	Model llm.Client
+	// Prompt overrides the default instructions
+	Prompt string
+}
+
+const defaultHyDEPrompt = "Write a short passage, as it would appear in a reference document, that answers the " +
+	"user's question. Do not mention that you are guessing; facts may be approximate."
+
+func (h HyDE) Transform(ctx context.Context, question string) ([]string, error) {
+	return single(ctx, h.Model, orDefault(h.Prompt, defaultHyDEPrompt), question)
+}
+
+// StepBack asks the model for a more general question whose answer gives
+// background for the original one (e.g. "how does X work" for a question
+// about one setting of X)
+type StepBack struct {
+	Model llm.Client
+	// Prompt overrides the default instructions
+	Prompt string
+}
+
+const defaultStepBackPrompt = "Rewrite the user's question as a more general, step-back question about the " +
+	"underlying concept or topic. Reply with the question only."
+
+func (s StepBack) Transform(ctx context.Context, question string) ([]string, error) {
+	return single(ctx, s.Model, orDefault(s.Prompt, defaultStepBackPrompt), question)
+}
+
+// ConversationRewriter resolves references to earlier turns ("and its
+// price?") so the question can be searched on its own
+type ConversationRewriter struct {
+	Model llm.Client
+	// Turns is the number of recent user/assistant messages considered (default 6)
+	Turns int
+	// Prompt overrides the default instructions
+	Prompt string
+}
+
+const defaultRewritePrompt = "Given a conversation and a follow-up question, rewrite the follow-up as a " +
+	"standalone search query that includes any names or details it refers to. " +
+	"If it is already standalone, return it unchanged. Reply with the query only."
+
+// Rewrite returns question unchanged, without calling the model, when there is no history
+func (c ConversationRewriter) Rewrite(ctx context.Context, question string, history []llm.Message) (string, error) {
+	turns := c.Turns
+	if turns <= 0 {
+		turns = 6
+	}
+	var recent []llm.Message
+	for i := len(history) - 1; i >= 0 && len(recent) < turns; i-- {
+		m := history[i]
+		if (m.Role == "user" || m.Role == "assistant") && strings.TrimSpace(m.Content) != "" {
+			recent = append(recent, m)
+		}
+	}
+	// the history may already end with the question itself
+	if len(recent) > 0 && recent[0].Role == "user" && strings.TrimSpace(recent[0].Content) == strings.TrimSpace(question) {
+		recent = recent[1:]
+	}
+	if len(recent) == 0 {
+		return question, nil
+	}
+	var b strings.Builder
+	b.WriteString("Conversation:\n")
+	for i := len(recent) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%s: %s\n", recent[i].Role, strings.TrimSpace(recent[i].Content))
+	}
+	fmt.Fprintf(&b, "\nFollow-up question: %s", question)
+	qs, err := single(ctx, c.Model, orDefault(c.Prompt, defaultRewritePrompt), b.String())
+	if err != nil {
+		return "", err
+	}
+	if len(qs) == 0 {
+		return question, nil
+	}
+	return qs[0], nil
+}
+
+// single asks the model for one piece of text
+func single(ctx context.Context, model llm.Client, system, input string) ([]string, error) {
+	if model == nil {
+		return nil, errors.New("no model configured")
+	}
+	resp, err := model.Chat(ctx, &llm.ChatRequest{
+		SystemPrompt: system,
+		Messages:     []llm.Message{{Role: "user", Content: input}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := strings.TrimSpace(resp.Content)
+	if out == "" {
+		return nil, nil
+	}
+	return []string{out}, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+var (
+	_ QueryTransformer = MultiQuery{}
+	_ QueryTransformer = HyDE{}
+	_ QueryTransformer = StepBack{}
+	_ QueryTransformer = TransformerFunc(nil)
+	_ Rewriter         = ConversationRewriter{}
+)
@@ -0,0 +1,198 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KamdynS/go-agents/llm"
+	"github.com/KamdynS/go-agents/memory"
+)
+
+// routeLLM answers by the first system prompt fragment it finds
+type routeLLM struct {
+	mu      sync.Mutex
+	replies map[string]string
+	inputs  []string
+}
+
+func (r *routeLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inputs = append(r.inputs, req.Messages[len(req.Messages)-1].Content)
+	for frag, reply := range r.replies {
+		if strings.Contains(req.SystemPrompt, frag) {
+			return &llm.Response{Content: reply}, nil
+		}
+	}
+	return nil, errors.New("unexpected prompt: " + req.SystemPrompt)
+}
+func (r *routeLLM) Completion(ctx context.Context, prompt string) (*llm.Response, error) {
+	return nil, errors.New("not used")
+}
+func (r *routeLLM) Stream(ctx context.Context, req *llm.ChatRequest, out chan<- *llm.Response) error {
+	return errors.New("not used")
+}
+func (r *routeLLM) Model() string          { return "route" }
+func (r *routeLLM) Provider() llm.Provider { return llm.ProviderOpenAI }
+func (r *routeLLM) Validate() error        { return nil }
+
+// wordEmb embeds the query text itself so keywordVS can match on it
+type wordEmb struct{}
+
+func (wordEmb) EmbedText(ctx context.Context, input string) ([]float64, error) {
+	v := make([]float64, len(input))
+	for i, c := range input {
+		v[i] = float64(c)
+	}
+	return v, nil
+}
+
+// keywordVS returns, for each query, the documents containing one of its words
+type keywordVS struct {
+	fakeVS
+	docs []memory.Document
+}
+
+func (k *keywordVS) QuerySimilar(ctx context.Context, vector []float64, topK int) ([]memory.Document, error) {
+	var b strings.Builder
+	for _, c := range vector {
+		b.WriteRune(rune(c))
+	}
+	var out []memory.Document
+	for _, w := range strings.Fields(strings.ToLower(b.String())) {
+		for _, d := range k.docs {
+			if strings.Contains(strings.ToLower(d.Content), w) && len(out) < topK && !containsDoc(out, d.ID) {
+				out = append(out, d)
+			}
+		}
+	}
+	return out, nil
+}
+
+func containsDoc(docs []memory.Document, id string) bool {
+	for _, d := range docs {
+		if d.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReciprocalRankFusion(t *testing.T) {
+	a := memory.Document{ID: "a", Content: "A"}
+	b := memory.Document{ID: "b", Content: "B"}
+	c := memory.Document{ID: "c", Content: "C"}
+	anon := memory.Document{Content: "same"}
+	got := ReciprocalRankFusion([][]memory.Document{
+		{a, b, anon},
+		{c, b, anon},
+		{b},
+	})
+	var ids []string
+	for _, d := range got {
+		ids = append(ids, d.ID+d.Content)
+	}
+	// documents in more lists rank higher; a and c tie and keep first-seen order
+	if strings.Join(ids, ",") != "bB,same,aA,cC" {
+		t.Fatalf("fused order: %v", ids)
+	}
+	if got[0].Score <= got[1].Score || got[2].Score != got[3].Score {
+		t.Fatalf("scores: %+v", got)
+	}
+}
+
+func TestRetrieverStrategies(t *testing.T) {
+	model := &routeLLM{replies: map[string]string{
+		"search queries":    `{"queries": ["goroutine scheduling", "Goroutine  Scheduling", "channel buffering", "extra"]}`,
+		"short passage":     "Goroutines are multiplexed onto threads by the runtime scheduler.",
+		"step-back":         "How does Go concurrency work?",
+		"standalone search": "How are goroutines scheduled?",
+	}}
+	vs := &keywordVS{docs: []memory.Document{
+		{ID: "sched", Content: "The scheduler multiplexes goroutines onto threads"},
+		{ID: "chan", Content: "Channel buffering decouples senders"},
+		{ID: "conc", Content: "Concurrency in Go"},
+		{ID: "gc", Content: "Garbage collection"},
+	}}
+	r := &Retriever{
+		Store:    vs,
+		Embedder: wordEmb{},
+		Rewriter: ConversationRewriter{Model: model},
+		Strategies: []QueryTransformer{
+			MultiQuery{Model: model, N: 3},
+			HyDE{Model: model},
+			StepBack{Model: model},
+		},
+	}
+	history := []llm.Message{
+		{Role: "user", Content: "Tell me about goroutines"},
+		{Role: "assistant", Content: "They are lightweight threads."},
+		{Role: "user", Content: "how are they scheduled?"},
+	}
+	queries, err := r.Queries(context.Background(), "how are they scheduled?", history)
+	if err != nil {
+		t.Fatalf("queries: %v", err)
+	}
+	// rewritten question first, the duplicate phrasing and the 4th query dropped
+	want := []string{
+		"How are goroutines scheduled?",
+		"goroutine scheduling",
+		"channel buffering",
+		"Goroutines are multiplexed onto threads by the runtime scheduler.",
+		"How does Go concurrency work?",
+	}
+	if strings.Join(queries, "|") != strings.Join(want, "|") {
+		t.Fatalf("queries:\n%q\nwant\n%q", queries, want)
+	}
+	var rewriteInput string
+	for _, in := range model.inputs {
+		if strings.Contains(in, "Follow-up question") {
+			rewriteInput = in
+		}
+	}
+	if !strings.Contains(rewriteInput, "user: Tell me about goroutines") || strings.Count(rewriteInput, "how are they scheduled?") != 1 {
+		t.Fatalf("rewrite prompt: %q", rewriteInput)
+	}
+
+	docs, err := r.Retrieve(context.Background(), "how are they scheduled?", history, 3)
+	if err != nil {
+		t.Fatalf("retrieve: %v", err)
+	}
+	if len(docs) != 3 || docs[0].ID != "sched" {
+		t.Fatalf("retrieve: %+v", docs)
+	}
+	seen := map[string]bool{}
+	for _, d := range docs {
+		if seen[d.ID] {
+			t.Fatalf("duplicate %s in %+v", d.ID, docs)
+		}
+		seen[d.ID] = true
+	}
+	if BuildContext(docs) == "" {
+		t.Fatal("empty context")
+	}
+}
+
+func TestRetrieverWithoutStrategies(t *testing.T) {
+	vs := &keywordVS{docs: []memory.Document{{ID: "x", Content: "alpha"}}}
+	r := &Retriever{Store: vs, Embedder: wordEmb{}, Rewriter: ConversationRewriter{}}
+	// no history: the rewriter does not need a model
+	docs, err := r.Retrieve(context.Background(), "alpha", nil, 0)
+	if err != nil || len(docs) != 1 || docs[0].ID != "x" {
+		t.Fatalf("retrieve: %+v (%v)", docs, err)
+	}
+
+	failing := TransformerFunc(func(ctx context.Context, q string) ([]string, error) {
+		return nil, errors.New("boom")
+	})
+	r.Strategies = []QueryTransformer{failing}
+	if _, err := r.Retrieve(context.Background(), "alpha", nil, 1); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected strategy error, got %v", err)
+	}
+	if _, err := (HyDE{}).Transform(context.Background(), "q"); err == nil {
+		t.Fatal("expected error without a model")
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/KamdynS/go-agents/memory"
+	obs "github.com/KamdynS/go-agents/observability"
+)
+
+// listConversationsHandler returns the stored sessions, most recently active first.
+// Usage: GET /conversations
+func (s *Server) listConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessions, err := memory.ListSessions(r.Context(), s.config.Conversations)
+	if err != nil {
+		log.Printf("List conversations error: %v", err)
+		s.writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []memory.SessionInfo{}
+	}
+	obs.InjectHTTPHeaders(w, r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"sessions": sessions})
+}
+
+// exportConversationsHandler streams sessions as JSONL.
+// Usage: GET /conversations/export[?session=<id>&session=<id>]
+func (s *Server) exportConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Buffer so a failing store still gets a proper error status
+	var buf bytes.Buffer
+	if err := memory.Export(r.Context(), s.config.Conversations, &buf, r.URL.Query()["session"]...); err != nil {
+		log.Printf("Export conversations error: %v", err)
+		s.writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	obs.InjectHTTPHeaders(w, r.Context())
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// importConversationsHandler loads JSONL written by the export endpoint.
+// Usage: POST /conversations/import
+func (s *Server) importConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxImportBytes)
+	stats, err := memory.Import(r.Context(), s.config.Conversations, r.Body)
+	if err != nil {
+		// Records before the failing line are already imported
+		status, msg := http.StatusInternalServerError, err.Error()
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, memory.ErrInvalidTranscript):
+			status = http.StatusBadRequest
+		case errors.As(err, &tooLarge):
+			status = http.StatusRequestEntityTooLarge
+		default:
+			log.Printf("Import conversations error: %v", err)
+			msg = "Internal server error"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{"error": msg, "imported": stats})
+		return
+	}
+	obs.InjectHTTPHeaders(w, r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"imported": stats})
+}
+
+// searchConversationsHandler finds messages containing every word of q.
+// Usage: GET /conversations/search?q=<words>&limit=50
+func (s *Server) searchConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		s.writeError(w, "missing q", http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			s.writeError(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	hits, err := memory.Search(r.Context(), s.config.Conversations, q, limit)
+	if err != nil {
+		log.Printf("Search conversations error: %v", err)
+		s.writeError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if hits == nil {
+		hits = []memory.SearchHit{}
+	}
+	obs.InjectHTTPHeaders(w, r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"hits": hits})
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KamdynS/go-agents/memory"
+	"github.com/KamdynS/go-agents/memory/inmemory"
+)
+
+func serve(server *Server, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// failingStore fails every write, as a store that is down would
+type failingStore struct{ *inmemory.ConversationStore }
+
+func (failingStore) AddMessage(context.Context, string, string, memory.Message) (string, error) {
+	return "", errors.New("disk full")
+}
+
+func TestServer_ConversationsDisabledByDefault(t *testing.T) {
+	server := NewServer(NewMockAgent(), Config{})
+	if w := serve(server, "GET", "/conversations", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a conversation store, got %d", w.Code)
+	}
+}
+
+func TestServer_Conversations(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewConversationStore()
+	_ = store.AppendMessage(ctx, "s1", "user", "Tell me about Go")
+	_ = store.AppendMessage(ctx, "s1", "assistant", "Go is fast")
+	server := NewServer(NewMockAgent(), Config{Conversations: store})
+
+	w := serve(server, "GET", "/conversations", "")
+	var list struct{ Sessions []memory.SessionInfo }
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Sessions) != 1 || list.Sessions[0].Messages != 2 {
+		t.Fatalf("Unexpected list response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(server, "GET", "/conversations/export?session=s1", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Unexpected export response %d: %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	export := w.Body.String()
+	if strings.Count(export, "\n") != 2 {
+		t.Fatalf("Expected 2 JSONL lines, got:\n%s", export)
+	}
+
+	target := inmemory.NewConversationStore()
+	other := NewServer(NewMockAgent(), Config{Conversations: target})
+	if w := serve(other, "GET", "/conversations/import", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET import, got %d", w.Code)
+	}
+	w = serve(other, "POST", "/conversations/import", export)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"messages":2`) {
+		t.Fatalf("Unexpected import response %d: %s", w.Code, w.Body.String())
+	}
+	if msgs, _ := target.GetMessages(ctx, "s1"); len(msgs) != 2 || msgs[1].Content != "Go is fast" {
+		t.Fatalf("Import did not restore the session: %+v", msgs)
+	}
+	if w := serve(other, "POST", "/conversations/import", "not json"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid JSONL, got %d", w.Code)
+	}
+	broken := NewServer(NewMockAgent(), Config{Conversations: failingStore{inmemory.NewConversationStore()}})
+	if w := serve(broken, "POST", "/conversations/import", export); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "disk full") {
+		t.Errorf("Expected an opaque 500 for store errors, got %d: %s", w.Code, w.Body.String())
+	}
+	small := NewServer(NewMockAgent(), Config{Conversations: inmemory.NewConversationStore(), MaxImportBytes: 10})
+	if w := serve(small, "POST", "/conversations/import", export); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized body, got %d", w.Code)
+	}
+
+	w = serve(other, "GET", "/conversations/search?q=go+fast", "")
+	var search struct{ Hits []memory.SearchHit }
+	if err := json.Unmarshal(w.Body.Bytes(), &search); err != nil || len(search.Hits) != 1 || search.Hits[0].Role != "assistant" {
+		t.Fatalf("Unexpected search response %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(other, "GET", "/conversations/search", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without q, got %d", w.Code)
+	}
+	if w := serve(other, "GET", "/conversations/search?q=go&limit=x", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid limit, got %d", w.Code)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/KamdynS/go-agents/agent/core"
+	"github.com/KamdynS/go-agents/memory"
 	obs "github.com/KamdynS/go-agents/observability"
 	wf "github.com/KamdynS/go-agents/workflow"
 )
@@ -28,6 +29,12 @@ type Config struct {
 	RequestTimeout time.Duration
 	// MaxRequestBodyBytes limits the size of inbound JSON payloads. Defaults to 1 MiB.
 	MaxRequestBodyBytes int64
+	// Conversations enables the /conversations endpoints (list, export,
+	// import, search) on this store. They expose full transcripts and have
+	// no authentication; keep them behind your own auth or on an internal port.
+	Conversations memory.ConversationStore
+	// MaxImportBytes limits the size of JSONL imports. Defaults to 32 MiB.
+	MaxImportBytes int64
 }
 
 // NewServer creates a new HTTP server for an agent
@@ -47,6 +54,9 @@ func NewServer(agent core.Agent, config Config) *Server {
 	if config.MaxRequestBodyBytes == 0 {
 		config.MaxRequestBodyBytes = 1 << 20 // 1 MiB
 	}
+	if config.MaxImportBytes == 0 {
+		config.MaxImportBytes = 32 << 20 // 32 MiB
+	}
 
 	s := &Server{
 		agent:  agent,
@@ -81,6 +91,12 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	// Optional debug-only endpoints (no CORS by design). These return Mermaid for registered workflows.
 	mux.HandleFunc("/debug/workflows", s.listWorkflowsHandler)
 	mux.HandleFunc("/debug/workflows/mermaid", s.workflowMermaidHandler)
+	if s.config.Conversations != nil {
+		mux.HandleFunc("/conversations", s.listConversationsHandler)
+		mux.HandleFunc("/conversations/export", s.exportConversationsHandler)
+		mux.HandleFunc("/conversations/import", s.importConversationsHandler)
+		mux.HandleFunc("/conversations/search", s.searchConversationsHandler)
+	}
 }
 
 // ChatRequest represents an incoming chat request
@@ -137,7 +153,12 @@ func (s *Server) chatHandler(w http.ResponseWriter, r *http.Request) {
 		Meta:    req.Meta,
 	}
 
-	response, err := s.agent.Run(r.Context(), input)
+	// Multi-agent setups (e.g. supervisor.Team) route follow-ups by session
+	ctx := r.Context()
+	if req.SessionID != "" {
+		ctx = core.WithSessionID(ctx, req.SessionID)
+	}
+	response, err := s.agent.Run(ctx, input)
 	if err != nil {
 		log.Printf("Agent error: %v", err)
 		s.writeError(w, "Internal server error", http.StatusInternalServerError)
@@ -194,8 +215,12 @@ func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
 	// Create a channel for streaming responses
 	output := make(chan core.Message)
 
+	ctx := r.Context()
+	if req.SessionID != "" {
+		ctx = core.WithSessionID(ctx, req.SessionID)
+	}
 	go func() {
-		if err := s.agent.RunStream(r.Context(), input, output); err != nil {
+		if err := s.agent.RunStream(ctx, input, output); err != nil {
 			log.Printf("Streaming error: %v", err)
 		}
 	}()
@@ -217,8 +242,13 @@ func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
 				Meta:      message.Meta,
 			}
 
+			// Handoff notices get their own event type so clients can switch owner
+			event := "message"
+			if message.Meta["event"] == "handoff" {
+				event = "handoff"
+			}
 			data, _ := json.Marshal(resp)
-			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
 			flusher.Flush()
 
 		case <-r.Context().Done():
@@ -689,3 +689,35 @@ func TestServer_Shutdown(t *testing.T) {
 		t.Errorf("Shutdown error: %v", err)
 	}
 }
+
+// sessionAgent reports the session id seen in context and emits a handoff notice when streaming
+type sessionAgent struct{}
+
+func (sessionAgent) Run(ctx context.Context, input core.Message) (core.Message, error) {
+	return core.Message{Role: "assistant", Content: "session=" + core.SessionIDFromContext(ctx)}, nil
+}
+func (sessionAgent) RunStream(ctx context.Context, input core.Message, output chan<- core.Message) error {
+	defer close(output)
+	output <- core.Message{Role: "system", Meta: map[string]string{"event": "handoff", "to": "billing"}}
+	output <- core.Message{Role: "assistant", Content: "session=" + core.SessionIDFromContext(ctx)}
+	return nil
+}
+
+func TestServer_SessionRoutingAndHandoffEvents(t *testing.T) {
+	server := NewServer(sessionAgent{}, Config{})
+	body, _ := json.Marshal(ChatRequest{Message: "hi", SessionID: "s-42"})
+
+	w := httptest.NewRecorder()
+	server.chatHandler(w, httptest.NewRequest("POST", "/chat", bytes.NewReader(body)))
+	var resp ChatResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Message != "session=s-42" {
+		t.Fatalf("session id should reach the agent: %v %q", err, resp.Message)
+	}
+
+	w = httptest.NewRecorder()
+	server.streamHandler(w, httptest.NewRequest("POST", "/chat/stream", bytes.NewReader(body)))
+	out := w.Body.String()
+	if !strings.Contains(out, "event: handoff\ndata: ") || !strings.Contains(out, "session=s-42") {
+		t.Fatalf("expected handoff event and session-aware reply, got %q", out)
+	}
+}